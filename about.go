@@ -0,0 +1,198 @@
+package tinydates
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"tinydates/about"
+	"tinydates/store"
+	"unicode"
+	"unicode/utf8"
+)
+
+// The limits on what a user tells about themselves, lengths are counted in
+// characters.
+const (
+	maxBioLength    = 500
+	maxPrompts      = 3
+	maxAnswerLength = 150
+	maxInterests    = 10
+)
+
+// WithCatalog sets the prompts and interests users may pick from,
+// about.Default by default.
+func WithCatalog(catalog *about.Catalog) Option {
+	return func(td *tinydates) {
+		td.catalog = catalog
+	}
+}
+
+func (td tinydates) Catalog(ctx context.Context) Catalog {
+	catalog := Catalog{
+		Prompts:   make([]Prompt, 0, len(td.catalog.Prompts)),
+		Interests: make([]Interest, 0, len(td.catalog.Interests)),
+	}
+	for _, prompt := range td.catalog.Prompts {
+		catalog.Prompts = append(catalog.Prompts, Prompt{
+			Id:   prompt.Id,
+			Text: prompt.Text,
+		})
+	}
+	for _, interest := range td.catalog.Interests {
+		catalog.Interests = append(catalog.Interests, Interest{
+			Id:       interest.Id,
+			Label:    interest.Label,
+			Category: interest.Category,
+		})
+	}
+
+	return catalog
+}
+
+func (td tinydates) GetAbout(
+	ctx context.Context,
+	token string,
+) (About, error) {
+	id, err := td.caller(ctx, token)
+	if err != nil {
+		return About{}, err
+	}
+
+	stored, err := td.store.GetAbout(ctx, id)
+	if errors.Is(err, store.ErrNotFound) {
+		return About{}, ErrUserNotFound.Wrap(err)
+	}
+	if err != nil {
+		td.logger.ErrorContext(ctx, "failed to get about", "id", id, "err", err)
+		return About{}, storeError(err, ErrInternalService)
+	}
+
+	return fromStoreAbout(stored), nil
+}
+
+func (td tinydates) SetAbout(
+	ctx context.Context,
+	token string,
+	a About,
+) (About, error) {
+	id, err := td.caller(ctx, token)
+	if err != nil {
+		return About{}, err
+	}
+
+	stored, err := td.about(a)
+	if err != nil {
+		return About{}, err
+	}
+
+	// a deck ranked by shared interests is kept until it is rebuilt
+	err = td.store.SetAbout(ctx, id, stored)
+	if errors.Is(err, store.ErrNotFound) {
+		return About{}, ErrUserNotFound.Wrap(err)
+	}
+	if err != nil {
+		td.logger.ErrorContext(ctx, "failed to set about", "id", id, "err", err)
+		return About{}, storeError(err, ErrInternalService)
+	}
+
+	return fromStoreAbout(stored), nil
+}
+
+// about checks a against the limits and the catalog, returning it as it is
+// stored: trimmed, with the prompts and interests by id and the interests
+// sorted without duplicates.
+func (td tinydates) about(a About) (store.About, error) {
+	bio, err := text("bio", a.Bio, maxBioLength)
+	if err != nil {
+		return store.About{}, err
+	}
+
+	if len(a.Prompts) > maxPrompts {
+		return store.About{}, ErrAboutInvalid.Wrap(
+			fmt.Errorf("more than %d prompts", maxPrompts),
+		)
+	}
+	prompts := make([]store.PromptAnswer, 0, len(a.Prompts))
+	answered := make(map[string]bool, len(a.Prompts))
+	for _, answer := range a.Prompts {
+		prompt, ok := td.catalog.Prompt(answer.Prompt)
+		if !ok {
+			return store.About{}, ErrUnknownPrompt.Wrap(fmt.Errorf("prompt %q", answer.Prompt))
+		}
+		if answered[prompt] {
+			return store.About{}, ErrAboutInvalid.Wrap(
+				fmt.Errorf("prompt %q answered twice", prompt),
+			)
+		}
+		answered[prompt] = true
+
+		written, err := text("answer", answer.Answer, maxAnswerLength)
+		if err != nil {
+			return store.About{}, err
+		}
+		if written == "" {
+			return store.About{}, ErrAboutInvalid.Wrap(
+				fmt.Errorf("prompt %q not answered", prompt),
+			)
+		}
+
+		prompts = append(prompts, store.PromptAnswer{Prompt: prompt, Answer: written})
+	}
+
+	interests := make([]string, 0, len(a.Interests))
+	for _, name := range a.Interests {
+		interest, ok := td.catalog.Interest(name)
+		if !ok {
+			return store.About{}, ErrUnknownInterest.Wrap(fmt.Errorf("interest %q", name))
+		}
+		interests = append(interests, interest)
+	}
+	// counted once spelled the same, however they were supplied
+	interests = dedupe(interests)
+	slices.Sort(interests)
+	if len(interests) > maxInterests {
+		return store.About{}, ErrAboutInvalid.Wrap(
+			fmt.Errorf("more than %d interests", maxInterests),
+		)
+	}
+
+	return store.About{Bio: bio, Prompts: prompts, Interests: interests}, nil
+}
+
+// text returns the free text value of the named field trimmed, failing when
+// it is longer than maxLength characters or holds control characters other
+// than line breaks.
+func text(field, value string, maxLength int) (string, error) {
+	value = strings.TrimSpace(value)
+
+	if !utf8.ValidString(value) {
+		return "", ErrAboutInvalid.Wrap(fmt.Errorf("%s is not valid UTF-8", field))
+	}
+	if utf8.RuneCountInString(value) > maxLength {
+		return "", ErrAboutInvalid.Wrap(
+			fmt.Errorf("%s longer than %d characters", field, maxLength),
+		)
+	}
+	if strings.ContainsFunc(value, func(r rune) bool {
+		return unicode.IsControl(r) && r != '\n' && r != '\r'
+	}) {
+		return "", ErrAboutInvalid.Wrap(fmt.Errorf("%s holds control characters", field))
+	}
+
+	return value, nil
+}
+
+func fromStoreAbout(stored store.About) About {
+	prompts := make([]PromptAnswer, 0, len(stored.Prompts))
+	for _, answer := range stored.Prompts {
+		prompts = append(prompts, PromptAnswer{Prompt: answer.Prompt, Answer: answer.Answer})
+	}
+
+	return About{
+		Bio:       stored.Bio,
+		Prompts:   prompts,
+		Interests: append([]string{}, stored.Interests...),
+	}
+}
@@ -0,0 +1,161 @@
+// Package about is the catalogue of what users may tell about themselves on
+// their profile: the prompts opening the short answers they write, and the
+// interests, grouped by category, they tag it with. Answers and interests are
+// stored by id. An interest is also found by the label users pick it by, so
+// that "Board Games" is stored as "board-games", while a prompt is only known
+// by its id as its text may be reworded at any time.
+package about
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"tinydates/spelling"
+)
+
+// Prompt is a prompt users may answer on their profile.
+type Prompt struct {
+	// Id is the stable value stored for the prompt.
+	Id string `json:"id"`
+
+	// Text is the prompt shown to users, such as "My ideal Sunday is…".
+	Text string `json:"text"`
+}
+
+// Interest is an interest of the catalogue.
+type Interest struct {
+	// Id is the stable value stored and returned for the interest.
+	Id string `json:"id"`
+
+	// Label is the name shown to users.
+	Label string `json:"label"`
+
+	// Category groups the interests shown together, such as "outdoors".
+	Category string `json:"category,omitempty"`
+}
+
+// Catalog holds the prompts and interests users may pick from.
+type Catalog struct {
+	Prompts   []Prompt   `json:"prompts"`
+	Interests []Interest `json:"interests"`
+
+	// prompts and interests index the id by its spellings
+	prompts   spelling.Index
+	interests spelling.Index
+}
+
+// Default returns the catalogue used when no other is configured.
+func Default() *Catalog {
+	c, err := New(
+		[]Prompt{
+			{Id: "ideal-sunday", Text: "My ideal Sunday is…"},
+			{Id: "green-flag", Text: "A green flag I look for is…"},
+			{Id: "simple-pleasures", Text: "My simplest pleasures are…"},
+			{Id: "geek-out", Text: "I geek out on…"},
+			{Id: "two-truths", Text: "Two truths and a lie…"},
+			{Id: "first-round", Text: "The first round is on me if…"},
+		},
+		[]Interest{
+			{Id: "hiking", Label: "Hiking", Category: "outdoors"},
+			{Id: "camping", Label: "Camping", Category: "outdoors"},
+			{Id: "climbing", Label: "Climbing", Category: "outdoors"},
+			{Id: "cycling", Label: "Cycling", Category: "outdoors"},
+			{Id: "gardening", Label: "Gardening", Category: "outdoors"},
+			{Id: "running", Label: "Running", Category: "sports"},
+			{Id: "football", Label: "Football", Category: "sports"},
+			{Id: "swimming", Label: "Swimming", Category: "sports"},
+			{Id: "tennis", Label: "Tennis", Category: "sports"},
+			{Id: "yoga", Label: "Yoga", Category: "sports"},
+			{Id: "cooking", Label: "Cooking", Category: "food and drink"},
+			{Id: "baking", Label: "Baking", Category: "food and drink"},
+			{Id: "coffee", Label: "Coffee", Category: "food and drink"},
+			{Id: "tea", Label: "Tea", Category: "food and drink"},
+			{Id: "wine", Label: "Wine", Category: "food and drink"},
+			{Id: "films", Label: "Films", Category: "arts"},
+			{Id: "live-music", Label: "Live music", Category: "arts"},
+			{Id: "photography", Label: "Photography", Category: "arts"},
+			{Id: "reading", Label: "Reading", Category: "arts"},
+			{Id: "theatre", Label: "Theatre", Category: "arts"},
+			{Id: "board-games", Label: "Board games", Category: "games"},
+			{Id: "chess", Label: "Chess", Category: "games"},
+			{Id: "video-games", Label: "Video games", Category: "games"},
+			{Id: "travel", Label: "Travel"},
+			{Id: "dogs", Label: "Dogs"},
+			{Id: "cats", Label: "Cats"},
+			{Id: "volunteering", Label: "Volunteering"},
+		},
+	)
+	if err != nil {
+		panic(err)
+	}
+
+	return c
+}
+
+// New returns the catalogue of prompts and interests, failing when one has no
+// id or two of them share a spelling.
+func New(prompts []Prompt, interests []Interest) (*Catalog, error) {
+	c := &Catalog{
+		Prompts:   prompts,
+		Interests: interests,
+		prompts:   spelling.New(),
+		interests: spelling.New(),
+	}
+
+	for _, prompt := range prompts {
+		if _, ok := c.prompts.Lookup(prompt.Id); ok {
+			return nil, fmt.Errorf("prompt %q listed twice", prompt.Id)
+		}
+		if strings.TrimSpace(prompt.Text) == "" {
+			return nil, fmt.Errorf("prompt %q has no text", prompt.Id)
+		}
+		if err := c.prompts.Add(prompt.Id, prompt.Id); err != nil {
+			return nil, fmt.Errorf("prompt %q: %w", prompt.Id, err)
+		}
+	}
+
+	for _, interest := range interests {
+		if _, ok := c.interests.Lookup(interest.Id); ok {
+			return nil, fmt.Errorf("interest %q listed twice", interest.Id)
+		}
+		for _, name := range []string{interest.Id, interest.Label} {
+			if err := c.interests.Add(name, interest.Id); err != nil {
+				return nil, fmt.Errorf("interest %q: %w", interest.Id, err)
+			}
+		}
+	}
+
+	return c, nil
+}
+
+// ReadCatalog reads a catalogue from a JSON object such as {"prompts":
+// [{"id": "ideal-sunday", "text": "My ideal Sunday is…"}], "interests":
+// [{"id": "hiking", "label": "Hiking", "category": "outdoors"}]}.
+func ReadCatalog(r io.Reader) (*Catalog, error) {
+	var c Catalog
+
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&c); err != nil {
+		return nil, fmt.Errorf("invalid profile catalog: %w", err)
+	}
+
+	catalog, err := New(c.Prompts, c.Interests)
+	if err != nil {
+		return nil, fmt.Errorf("invalid profile catalog: %w", err)
+	}
+
+	return catalog, nil
+}
+
+// Prompt returns the id of the prompt spelled name, false when there is none.
+func (c *Catalog) Prompt(name string) (string, bool) {
+	return c.prompts.Lookup(name)
+}
+
+// Interest returns the id of the interest spelled name, by its id or its
+// label, false when there is none.
+func (c *Catalog) Interest(name string) (string, bool) {
+	return c.interests.Lookup(name)
+}
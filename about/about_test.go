@@ -0,0 +1,107 @@
+package about_test
+
+import (
+	"strings"
+	"testing"
+	"tinydates/about"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInterestsAreFoundByLabel(t *testing.T) {
+	catalog := about.Default()
+
+	// every interest of the default catalogue is picked by its label
+	for _, interest := range catalog.Interests {
+		id, ok := catalog.Interest(interest.Label)
+		require.True(t, ok, interest.Label)
+		require.Equal(t, interest.Id, id, interest.Label)
+	}
+
+	id, ok := catalog.Interest("Live Music")
+	require.True(t, ok)
+	require.Equal(t, "live-music", id)
+
+	// categories group interests, they are no interest themselves
+	_, ok = catalog.Interest("outdoors")
+	require.False(t, ok)
+}
+
+func TestPromptsAreOnlyFoundById(t *testing.T) {
+	catalog := about.Default()
+
+	for _, prompt := range catalog.Prompts {
+		require.NotEmpty(t, strings.TrimSpace(prompt.Text), prompt.Id)
+
+		id, ok := catalog.Prompt(prompt.Id)
+		require.True(t, ok, prompt.Id)
+		require.Equal(t, prompt.Id, id)
+
+		// the text may be reworded, answers must not depend on it
+		_, ok = catalog.Prompt(prompt.Text)
+		require.False(t, ok, prompt.Text)
+	}
+}
+
+func TestReadCatalogReplacesTheDefault(t *testing.T) {
+	catalog, err := about.ReadCatalog(strings.NewReader(`{
+		"prompts": [{"id": "weekend", "text": "This weekend I…"}],
+		"interests": [
+			{"id": "knitting", "label": "Knitting", "category": "crafts"},
+			{"id": "pottery", "label": "Ceramics"}
+		]
+	}`))
+	require.NoError(t, err)
+
+	require.Equal(t, []about.Interest{
+		{Id: "knitting", Label: "Knitting", Category: "crafts"},
+		{Id: "pottery", Label: "Ceramics"},
+	}, catalog.Interests)
+	id, ok := catalog.Interest("ceramics")
+	require.True(t, ok)
+	require.Equal(t, "pottery", id)
+
+	// nothing of the default catalogue is left
+	_, ok = catalog.Interest("hiking")
+	require.False(t, ok)
+	_, ok = catalog.Prompt("ideal-sunday")
+	require.False(t, ok)
+}
+
+func TestNewRefusesAmbiguousCatalogs(t *testing.T) {
+	for name, tc := range map[string]struct {
+		prompts   []about.Prompt
+		interests []about.Interest
+	}{
+		"prompt without text": {
+			prompts: []about.Prompt{{Id: "weekend", Text: " "}},
+		},
+		"prompt listed twice, however spelled": {
+			prompts: []about.Prompt{
+				{Id: "weekend", Text: "This weekend I…"},
+				{Id: "Weekend", Text: "Next weekend I…"},
+			},
+		},
+		"interest listed twice": {
+			interests: []about.Interest{
+				{Id: "tea", Label: "Tea"},
+				{Id: "tea", Label: "Chai"},
+			},
+		},
+		"label of another interest": {
+			interests: []about.Interest{
+				{Id: "tea", Label: "Tea"},
+				{Id: "chai", Label: "tea"},
+			},
+		},
+		"interest without label": {
+			interests: []about.Interest{{Id: "tea"}},
+		},
+	} {
+		_, err := about.New(tc.prompts, tc.interests)
+		require.Error(t, err, name)
+	}
+
+	_, err := about.ReadCatalog(strings.NewReader(`{"interests": [], "colours": []}`))
+	require.ErrorContains(t, err, "invalid profile catalog")
+}
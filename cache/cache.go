@@ -1,15 +1,63 @@
 package cache
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 // Cache are the core methods required from the cache for tinydates.
 type Cache interface {
-	// StartSession inserts a new token into the cache
-	StartSession(ctx context.Context, token string) error
+	// StartSession inserts a new token into the cache for the user with the
+	// supplied id
+	StartSession(ctx context.Context, token string, id int) error
 
 	// Authorized checks whether a token exists in the cache
 	Authorized(ctx context.Context, token string) bool
 
+	// Session returns the id of the user a token was started for, and false
+	// when the token does not exist or was started without one
+	Session(ctx context.Context, token string) (int, bool)
+
 	// EndSession removes a token from the cache
 	EndSession(ctx context.Context, token string) error
+
+	// SetDeck replaces the deck of the user with the supplied id, served
+	// after its first deck.Served profiles; the deck expires ttl after it was
+	// set, last served from or swiped on. The swipes of profiles left out of
+	// the new deck are forgotten.
+	SetDeck(ctx context.Context, id int, deck Deck, ttl time.Duration) error
+
+	// Deck returns the deck of the user with the supplied id without the
+	// profiles they swiped, and false when they have none
+	Deck(ctx context.Context, id int) (Deck, bool, error)
+
+	// Served records that the deck of the user with the supplied id was
+	// served up to the profile last included, the next page starts after it.
+	// A last profile the deck does not hold leaves it served from its front.
+	Served(ctx context.Context, id int, last int, ttl time.Duration) error
+
+	// Swiped removes swipee from the deck of the user with the supplied id,
+	// including a deck set later from profiles read before the swipe
+	Swiped(ctx context.Context, id int, swipee int, ttl time.Duration) error
+
+	// DropDeck removes the deck of the user with the supplied id
+	DropDeck(ctx context.Context, id int) error
+}
+
+// Deck is a queue of profiles precomputed for a user to discover, served
+// from its front.
+type Deck struct {
+	// Key identifies what the deck was built for, such as the sort and
+	// filters of discovery, a deck is only served for the same key.
+	Key string
+
+	// Ids are the profiles of the deck, in the order they are served.
+	Ids []int
+
+	// Served is the number of Ids, from the front, already served.
+	Served int
+
+	// Complete is whether the deck holds every profile there was when it was
+	// built, so that refilling it would not find more.
+	Complete bool
 }
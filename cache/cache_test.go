@@ -0,0 +1,90 @@
+package cache_test
+
+import (
+	"context"
+	"testing"
+	"time"
+	"tinydates/cache"
+	"tinydates/cache/cachetest"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gomodule/redigo/redis"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInMemoryCache(t *testing.T) {
+	cachetest.Run(t, func(
+		t *testing.T,
+		ttl time.Duration,
+	) (cache.Cache, func(time.Duration)) {
+		return cache.NewTinydatesInMemoryCache(ttl), time.Sleep
+	})
+}
+
+func TestRedisCache(t *testing.T) {
+	cachetest.Run(t, func(
+		t *testing.T,
+		ttl time.Duration,
+	) (cache.Cache, func(time.Duration)) {
+		server := miniredis.RunT(t)
+		pool := &redis.Pool{
+			MaxIdle: 4,
+			Dial: func() (redis.Conn, error) {
+				return redis.Dial("tcp", server.Addr())
+			},
+		}
+		t.Cleanup(func() { pool.Close() })
+
+		// miniredis only expires keys when its clock is moved forward
+		return cache.NewTinydatesRedisCache(pool, ttl, nil, nil), server.FastForward
+	})
+}
+
+func TestRedisCacheHonoursLegacySessions(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	pool := &redis.Pool{
+		Dial: func() (redis.Conn, error) {
+			return redis.Dial("tcp", server.Addr())
+		},
+	}
+	t.Cleanup(func() { pool.Close() })
+	c := cache.NewTinydatesRedisCache(pool, time.Hour, nil, nil)
+
+	// started by a release keeping every session in a single set
+	_, err := server.SAdd(cache.SESSION_KEY, "legacy")
+	require.NoError(t, err)
+
+	require.True(t, c.Authorized(ctx, "legacy"))
+	require.False(t, c.Authorized(ctx, "other"))
+
+	// without the id of their user, which only a new login keeps
+	_, ok := c.Session(ctx, "legacy")
+	require.False(t, ok)
+
+	require.NoError(t, c.EndSession(ctx, "legacy"))
+	require.False(t, c.Authorized(ctx, "legacy"))
+}
+
+func TestRedisCacheForgetsSwipesLeftOutOfARebuiltDeck(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	pool := &redis.Pool{
+		Dial: func() (redis.Conn, error) {
+			return redis.Dial("tcp", server.Addr())
+		},
+	}
+	t.Cleanup(func() { pool.Close() })
+	c := cache.NewTinydatesRedisCache(pool, time.Hour, nil, nil)
+
+	require.NoError(t, c.SetDeck(ctx, 1, cache.Deck{Ids: []int{2, 3, 4}}, time.Hour))
+	for _, swipee := range []int{2, 3, 4} {
+		require.NoError(t, c.Swiped(ctx, 1, swipee, time.Hour))
+	}
+
+	// the hash of an active user holds no more than the deck
+	require.NoError(t, c.SetDeck(ctx, 1, cache.Deck{Ids: []int{4, 5}}, time.Hour))
+	fields, err := server.HKeys(cache.DECK_KEY + ":1")
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"key", "ids", "complete", "swiped:4"}, fields)
+}
@@ -0,0 +1,274 @@
+package cachetest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+	"tinydates/cache"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Factory creates the cache to run the suite against with sessions expiring
+// ttl after they start, ttl is zero when sessions should never expire. It
+// also returns advance, which moves the clock seen by the cache forward by d;
+// caches using the wall clock can simply pass time.Sleep.
+type Factory func(
+	t *testing.T,
+	ttl time.Duration,
+) (c cache.Cache, advance func(d time.Duration))
+
+// Run checks that a cache implementation honours the full cache.Cache
+// contract: the session lifecycle, expiry, decks and concurrent use. A new backend
+// is validated with a single call from its tests.
+func Run(t *testing.T, newCache Factory) {
+	t.Run("session lifecycle", func(t *testing.T) {
+		ctx := context.Background()
+		c, _ := newCache(t, 0)
+
+		require.False(t, c.Authorized(ctx, "token"))
+
+		require.NoError(t, c.StartSession(ctx, "token", 1))
+		require.True(t, c.Authorized(ctx, "token"))
+		require.False(t, c.Authorized(ctx, "other"))
+
+		// the session is of the user it was started for
+		id, ok := c.Session(ctx, "token")
+		require.True(t, ok)
+		require.Equal(t, 1, id)
+		_, ok = c.Session(ctx, "other")
+		require.False(t, ok)
+
+		// starting a session twice is idempotent
+		require.NoError(t, c.StartSession(ctx, "token", 1))
+		require.True(t, c.Authorized(ctx, "token"))
+
+		require.NoError(t, c.EndSession(ctx, "token"))
+		require.False(t, c.Authorized(ctx, "token"))
+		_, ok = c.Session(ctx, "token")
+		require.False(t, ok)
+
+		// ending an unknown session is not an error
+		require.NoError(t, c.EndSession(ctx, "unknown"))
+	})
+
+	t.Run("sessions are independent", func(t *testing.T) {
+		ctx := context.Background()
+		c, _ := newCache(t, 0)
+
+		require.NoError(t, c.StartSession(ctx, "first", 1))
+		require.NoError(t, c.StartSession(ctx, "second", 2))
+		require.NoError(t, c.EndSession(ctx, "first"))
+
+		require.False(t, c.Authorized(ctx, "first"))
+		require.True(t, c.Authorized(ctx, "second"))
+		id, ok := c.Session(ctx, "second")
+		require.True(t, ok)
+		require.Equal(t, 2, id)
+	})
+
+	t.Run("expiry", func(t *testing.T) {
+		ctx := context.Background()
+		const ttl = 300 * time.Millisecond
+		c, advance := newCache(t, ttl)
+
+		require.NoError(t, c.StartSession(ctx, "expiring", 1))
+		require.True(t, c.Authorized(ctx, "expiring"))
+
+		advance(ttl / 2)
+		require.True(t, c.Authorized(ctx, "expiring"))
+
+		// restarting a session renews its lifetime
+		require.NoError(t, c.StartSession(ctx, "renewed", 1))
+
+		advance(ttl / 2)
+		require.False(t, c.Authorized(ctx, "expiring"))
+		require.True(t, c.Authorized(ctx, "renewed"))
+
+		advance(ttl)
+		require.False(t, c.Authorized(ctx, "renewed"))
+		_, ok := c.Session(ctx, "renewed")
+		require.False(t, ok)
+
+		// an expired session can be started again
+		require.NoError(t, c.StartSession(ctx, "expiring", 1))
+		require.True(t, c.Authorized(ctx, "expiring"))
+	})
+
+	t.Run("no expiry without a ttl", func(t *testing.T) {
+		ctx := context.Background()
+		c, advance := newCache(t, 0)
+
+		require.NoError(t, c.StartSession(ctx, "forever", 1))
+		advance(300 * time.Millisecond)
+		require.True(t, c.Authorized(ctx, "forever"))
+	})
+
+	t.Run("decks", func(t *testing.T) {
+		ctx := context.Background()
+		c, _ := newCache(t, 0)
+
+		_, ok, err := c.Deck(ctx, 1)
+		require.NoError(t, err)
+		require.False(t, ok)
+
+		deck := cache.Deck{Key: "distance", Ids: []int{4, 2, 3}, Complete: true}
+		require.NoError(t, c.SetDeck(ctx, 1, deck, time.Hour))
+		require.NoError(t, c.SetDeck(ctx, 2, cache.Deck{Key: "empty", Ids: []int{}}, time.Hour))
+
+		got, ok, err := c.Deck(ctx, 1)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, deck, got)
+
+		// an empty deck is still a deck, and decks are kept by user
+		got, ok, err = c.Deck(ctx, 2)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, cache.Deck{Key: "empty", Ids: []int{}}, got)
+
+		require.NoError(t, c.Swiped(ctx, 1, 2, time.Hour))
+		got, _, err = c.Deck(ctx, 1)
+		require.NoError(t, err)
+		require.Equal(t, []int{4, 3}, got.Ids)
+
+		// a deck built before a swipe is set after it, without the swipee
+		require.NoError(t, c.Swiped(ctx, 1, 5, time.Hour))
+		deck = cache.Deck{Key: "recommended", Ids: []int{5, 6, 2}}
+		require.NoError(t, c.SetDeck(ctx, 1, deck, time.Hour))
+		got, _, err = c.Deck(ctx, 1)
+		require.NoError(t, err)
+		require.Equal(t, cache.Deck{Key: "recommended", Ids: []int{6}}, got)
+
+		// a deck leaving out a swipee forgets the swipe, which only held back
+		// decks built before it
+		require.NoError(t, c.SetDeck(ctx, 1, cache.Deck{Key: "distance", Ids: []int{6}}, time.Hour))
+		require.NoError(t, c.SetDeck(ctx, 1, cache.Deck{Key: "distance", Ids: []int{2, 6}}, time.Hour))
+		got, _, err = c.Deck(ctx, 1)
+		require.NoError(t, err)
+		require.Equal(t, []int{2, 6}, got.Ids)
+
+		require.NoError(t, c.DropDeck(ctx, 1))
+		_, ok, err = c.Deck(ctx, 1)
+		require.NoError(t, err)
+		require.False(t, ok)
+
+		// dropping a missing deck is not an error
+		require.NoError(t, c.DropDeck(ctx, 1))
+	})
+
+	t.Run("deck paging", func(t *testing.T) {
+		ctx := context.Background()
+		c, _ := newCache(t, 0)
+
+		require.NoError(t, c.SetDeck(ctx, 1, cache.Deck{Ids: []int{2, 3, 4, 5}}, time.Hour))
+		require.NoError(t, c.Served(ctx, 1, 3, time.Hour))
+		got, _, err := c.Deck(ctx, 1)
+		require.NoError(t, err)
+		require.Equal(t, cache.Deck{Ids: []int{2, 3, 4, 5}, Served: 2}, got)
+
+		// swipes take profiles out whether they were served or not
+		require.NoError(t, c.Swiped(ctx, 1, 2, time.Hour))
+		require.NoError(t, c.Swiped(ctx, 1, 4, time.Hour))
+		got, _, err = c.Deck(ctx, 1)
+		require.NoError(t, err)
+		require.Equal(t, cache.Deck{Ids: []int{3, 5}, Served: 1}, got)
+
+		// as does swiping the last profile served
+		require.NoError(t, c.Swiped(ctx, 1, 3, time.Hour))
+		got, _, err = c.Deck(ctx, 1)
+		require.NoError(t, err)
+		require.Equal(t, cache.Deck{Ids: []int{5}, Served: 0}, got)
+
+		require.NoError(t, c.Served(ctx, 1, 5, time.Hour))
+		got, _, err = c.Deck(ctx, 1)
+		require.NoError(t, err)
+		require.Equal(t, 1, got.Served)
+
+		// a profile the deck does not hold serves it from the front
+		require.NoError(t, c.Served(ctx, 1, 9, time.Hour))
+		got, _, err = c.Deck(ctx, 1)
+		require.NoError(t, err)
+		require.Equal(t, 0, got.Served)
+
+		// as does setting it anew, unless told what was served
+		require.NoError(t, c.Served(ctx, 1, 5, time.Hour))
+		require.NoError(t, c.SetDeck(ctx, 1, cache.Deck{Ids: []int{5, 6}}, time.Hour))
+		got, _, err = c.Deck(ctx, 1)
+		require.NoError(t, err)
+		require.Equal(t, cache.Deck{Ids: []int{5, 6}, Served: 0}, got)
+
+		require.NoError(t, c.SetDeck(ctx, 1, cache.Deck{Ids: []int{5, 6, 7}, Served: 2}, time.Hour))
+		got, _, err = c.Deck(ctx, 1)
+		require.NoError(t, err)
+		require.Equal(t, cache.Deck{Ids: []int{5, 6, 7}, Served: 2}, got)
+	})
+
+	t.Run("deck expiry", func(t *testing.T) {
+		ctx := context.Background()
+		const ttl = 300 * time.Millisecond
+		c, advance := newCache(t, 0)
+
+		require.NoError(t, c.SetDeck(ctx, 1, cache.Deck{Ids: []int{2, 3}}, ttl))
+		advance(ttl / 2)
+
+		// swiping keeps the deck for another ttl
+		require.NoError(t, c.Swiped(ctx, 1, 2, ttl))
+		advance(ttl / 2)
+		got, ok, err := c.Deck(ctx, 1)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, []int{3}, got.Ids)
+
+		advance(ttl)
+		_, ok, err = c.Deck(ctx, 1)
+		require.NoError(t, err)
+		require.False(t, ok)
+	})
+
+	t.Run("concurrency", func(t *testing.T) {
+		ctx := context.Background()
+		c, _ := newCache(t, time.Hour)
+
+		const workers = 16
+		const sessions = 50
+
+		var wg sync.WaitGroup
+		errs := make(chan error, workers*sessions*2)
+
+		for w := 0; w < workers; w++ {
+			wg.Add(1)
+			go func(w int) {
+				defer wg.Done()
+
+				for s := 0; s < sessions; s++ {
+					token := fmt.Sprintf("worker-%d-session-%d", w, s)
+					errs <- c.StartSession(ctx, token, w)
+					if !c.Authorized(ctx, token) {
+						errs <- fmt.Errorf("session %s not authorized", token)
+					}
+					// every other session is ended so that reads and
+					// deletes interleave across workers
+					if s%2 == 0 {
+						errs <- c.EndSession(ctx, token)
+					}
+				}
+			}(w)
+		}
+		wg.Wait()
+		close(errs)
+
+		for err := range errs {
+			require.NoError(t, err)
+		}
+
+		for w := 0; w < workers; w++ {
+			for s := 0; s < sessions; s++ {
+				token := fmt.Sprintf("worker-%d-session-%d", w, s)
+				require.Equal(t, s%2 != 0, c.Authorized(ctx, token), token)
+			}
+		}
+	})
+}
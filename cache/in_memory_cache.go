@@ -1,25 +1,54 @@
 package cache
 
-import "context"
+import (
+	"context"
+	"sync"
+	"time"
+)
 
 // tinydatesInMemoryCache provide access to the Cache methods for an in memory
-// cache, this uses a map of string to interface that effectively works as a
-// set allowing only for unique values as the key, the `interface{}` value
-// takes up so memory so is not stored - demonstrating new dependency injection
-// during testing
+// cache, this uses a map of token to the session of the user it was started
+// for that effectively works as a set allowing only for unique values as the
+// key; a zero expiry never expires - demonstrating new dependency injection
+// during testing. Decks are kept by user id.
 type tinydatesInMemoryCache struct {
-	Cache map[string]interface{}
+	mu    sync.RWMutex
+	ttl   time.Duration
+	Cache map[string]inMemorySession
+	decks map[int]*inMemoryDeck
 }
 
-func NewTinydatesInMemoryCache() Cache {
-	return &tinydatesInMemoryCache{Cache: make(map[string]interface{})}
+// inMemorySession is the user a session was started for and the time it
+// expires.
+type inMemorySession struct {
+	id     int
+	expiry time.Time
+}
+
+// NewTinydatesInMemoryCache creates an in memory cache whose sessions expire
+// ttl after they are started, a ttl of zero keeps them until they are ended.
+func NewTinydatesInMemoryCache(ttl time.Duration) Cache {
+	return &tinydatesInMemoryCache{
+		ttl:   ttl,
+		Cache: make(map[string]inMemorySession),
+		decks: make(map[int]*inMemoryDeck),
+	}
 }
 
 func (cache *tinydatesInMemoryCache) StartSession(
 	ctx context.Context,
 	token string,
+	id int,
 ) error {
-	cache.Cache[token] = struct{}{}
+	session := inMemorySession{id: id}
+	if cache.ttl > 0 {
+		session.expiry = time.Now().Add(cache.ttl)
+	}
+
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	cache.Cache[token] = session
 	return nil
 }
 
@@ -27,14 +56,183 @@ func (cache *tinydatesInMemoryCache) Authorized(
 	ctx context.Context,
 	token string,
 ) bool {
-	_, exists := cache.Cache[token]
-	return exists
+	_, ok := cache.Session(ctx, token)
+	return ok
+}
+
+func (cache *tinydatesInMemoryCache) Session(
+	ctx context.Context,
+	token string,
+) (int, bool) {
+	cache.mu.RLock()
+	session, exists := cache.Cache[token]
+	cache.mu.RUnlock()
+
+	if !exists {
+		return 0, false
+	}
+	if !session.expiry.IsZero() && !time.Now().Before(session.expiry) {
+		// expired sessions are removed lazily on their next lookup
+		cache.mu.Lock()
+		if current, ok := cache.Cache[token]; ok && current == session {
+			delete(cache.Cache, token)
+		}
+		cache.mu.Unlock()
+		return 0, false
+	}
+
+	return session.id, true
 }
 
 func (cache *tinydatesInMemoryCache) EndSession(
 	ctx context.Context,
 	token string,
 ) error {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
 	delete(cache.Cache, token)
 	return nil
 }
+
+// inMemoryDeck is a deck along with the profiles swiped since it was set and
+// the number of its profiles served, swiped or not.
+type inMemoryDeck struct {
+	deck   Deck
+	swiped map[int]bool
+	served int
+	expiry time.Time
+}
+
+func (cache *tinydatesInMemoryCache) SetDeck(
+	ctx context.Context,
+	id int,
+	deck Deck,
+	ttl time.Duration,
+) error {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	current := cache.live(id)
+	if current == nil {
+		current = &inMemoryDeck{swiped: make(map[int]bool)}
+		cache.decks[id] = current
+	}
+
+	// the swipes of the profiles of the new deck are kept as it may have
+	// been built before them, the others are forgotten
+	inDeck := make(map[int]bool, len(deck.Ids))
+	for _, profile := range deck.Ids {
+		inDeck[profile] = true
+	}
+	for profile := range current.swiped {
+		if !inDeck[profile] {
+			delete(current.swiped, profile)
+		}
+	}
+
+	current.deck = Deck{
+		Key:      deck.Key,
+		Ids:      append(make([]int, 0, len(deck.Ids)), deck.Ids...),
+		Complete: deck.Complete,
+	}
+	current.served = min(deck.Served, len(deck.Ids))
+	current.expiry = time.Now().Add(ttl)
+
+	return nil
+}
+
+func (cache *tinydatesInMemoryCache) Deck(
+	ctx context.Context,
+	id int,
+) (Deck, bool, error) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	current := cache.live(id)
+	if current == nil || current.deck.Ids == nil {
+		return Deck{}, false, nil
+	}
+
+	deck := current.deck
+	deck.Ids = make([]int, 0, len(current.deck.Ids))
+	for i, profile := range current.deck.Ids {
+		if !current.swiped[profile] {
+			deck.Ids = append(deck.Ids, profile)
+		}
+		if i < current.served {
+			deck.Served = len(deck.Ids)
+		}
+	}
+
+	return deck, true, nil
+}
+
+func (cache *tinydatesInMemoryCache) Served(
+	ctx context.Context,
+	id int,
+	last int,
+	ttl time.Duration,
+) error {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	current := cache.live(id)
+	if current == nil {
+		return nil
+	}
+
+	current.served = 0
+	for i, profile := range current.deck.Ids {
+		if profile == last {
+			current.served = i + 1
+			break
+		}
+	}
+	current.expiry = time.Now().Add(ttl)
+
+	return nil
+}
+
+func (cache *tinydatesInMemoryCache) Swiped(
+	ctx context.Context,
+	id int,
+	swipee int,
+	ttl time.Duration,
+) error {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	current := cache.live(id)
+	if current == nil {
+		current = &inMemoryDeck{swiped: make(map[int]bool)}
+		cache.decks[id] = current
+	}
+	current.swiped[swipee] = true
+	current.expiry = time.Now().Add(ttl)
+
+	return nil
+}
+
+func (cache *tinydatesInMemoryCache) DropDeck(ctx context.Context, id int) error {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	delete(cache.decks, id)
+	return nil
+}
+
+// live returns the deck of the user with the supplied id unless it expired,
+// expired decks are removed. The write lock must be held.
+func (cache *tinydatesInMemoryCache) live(id int) *inMemoryDeck {
+	current, ok := cache.decks[id]
+	if !ok {
+		return nil
+	}
+	if !time.Now().Before(current.expiry) {
+		delete(cache.decks, id)
+		return nil
+	}
+
+	return current
+}
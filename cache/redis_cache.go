@@ -2,30 +2,165 @@ package cache
 
 import (
 	"context"
+	"errors"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+	"tinydates/logging"
 
 	"github.com/gomodule/redigo/redis"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// SESSION_KEY prefixes the key each session token is stored under, holding
+// the id of the user it was started for; a key per token lets redis expire
+// every session independently. Releases before SESSION_TTL kept every token
+// as a member of the set at SESSION_KEY itself; those sessions are still
+// honoured until they are ended, see legacySession.
 const SESSION_KEY = "tinysession"
 
+// DECK_KEY prefixes the key the deck of each user is stored under, a hash
+// holding the deck along with a field for every profile swiped since so that
+// both expire together.
+const DECK_KEY = "tinydeck"
+
+// fields of the deck hash, each swiped profile is a swipedField prefixed
+// field
+const (
+	deckKeyField      = "key"
+	deckIdsField      = "ids"
+	deckCompleteField = "complete"
+	deckServedField   = "served"
+	swipedField       = "swiped:"
+)
+
 // tinydatesRedisCache provide access to the Cache methods for a Redis backed
 // cache.
 type tinydatesRedisCache struct {
-	Cache *redis.Pool
+	Cache  *redis.Pool
+	TTL    time.Duration
+	Logger *slog.Logger
+	Tracer trace.Tracer
+}
+
+// NewTinydatesRedisCache creates a redis backed cache whose sessions expire
+// ttl after they are started, or never when ttl is zero. A nil logger
+// discards output and a nil tracer provider falls back to the global one.
+func NewTinydatesRedisCache(
+	cache *redis.Pool,
+	ttl time.Duration,
+	logger *slog.Logger,
+	tp trace.TracerProvider,
+) Cache {
+	if logger == nil {
+		logger = logging.Discard()
+	}
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+
+	return &tinydatesRedisCache{
+		Cache:  cache,
+		TTL:    ttl,
+		Logger: logger,
+		Tracer: tp.Tracer("tinydates/cache"),
+	}
 }
 
-func NewTinydatesRedisCache(cache *redis.Pool) Cache {
-	return &tinydatesRedisCache{Cache: cache}
+// do runs a single redis command on a pooled connection inside its own span,
+// the context is honoured while waiting on the server.
+func (cache *tinydatesRedisCache) do(
+	ctx context.Context,
+	command string,
+	args ...interface{},
+) (interface{}, error) {
+	ctx, span := cache.Tracer.Start(
+		ctx,
+		"redis "+command,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "redis"),
+			attribute.String("db.operation", command),
+		),
+	)
+	defer span.End()
+
+	conn, err := cache.Cache.GetContext(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	defer conn.Close()
+
+	reply, err := redis.DoContext(conn, ctx, command, args...)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	return reply, err
+}
+
+// transaction runs commands on a pooled connection as a single MULTI/EXEC
+// transaction inside its own span.
+func (cache *tinydatesRedisCache) transaction(
+	ctx context.Context,
+	commands ...[]interface{},
+) error {
+	ctx, span := cache.Tracer.Start(
+		ctx,
+		"redis MULTI",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "redis"),
+			attribute.String("db.operation", "MULTI"),
+		),
+	)
+	defer span.End()
+
+	err := func() error {
+		conn, err := cache.Cache.GetContext(ctx)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		if err := conn.Send("MULTI"); err != nil {
+			return err
+		}
+		for _, command := range commands {
+			if err := conn.Send(command[0].(string), command[1:]...); err != nil {
+				return err
+			}
+		}
+
+		_, err = redis.DoContext(conn, ctx, "EXEC")
+		return err
+	}()
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	return err
 }
 
 func (cache *tinydatesRedisCache) StartSession(
 	ctx context.Context,
 	token string,
+	id int,
 ) error {
-	conn := cache.Cache.Get()
-	defer conn.Close()
+	args := []interface{}{sessionKey(token), id}
+	if cache.TTL > 0 {
+		args = append(args, "PX", cache.TTL.Milliseconds())
+	}
 
-	if _, err := conn.Do("SADD", SESSION_KEY, token); err != nil {
+	if _, err := cache.do(ctx, "SET", args...); err != nil {
 		return err
 	}
 
@@ -36,29 +171,201 @@ func (cache *tinydatesRedisCache) Authorized(
 	ctx context.Context,
 	token string,
 ) bool {
-	conn := cache.Cache.Get()
-	defer conn.Close()
-
-	// for simplicity not handling errors and just returning key not found
-	exists, err := redis.Bool(conn.Do("SISMEMBER", SESSION_KEY, token))
+	// for simplicity not handling errors and just returning key not found,
+	// the error is logged so an unavailable cache can still be diagnosed
+	exists, err := redis.Bool(cache.do(ctx, "EXISTS", sessionKey(token)))
 	if err != nil {
+		cache.Logger.ErrorContext(ctx, "failed to check session", "err", err)
 		return false
 	}
+	if exists {
+		return true
+	}
 
-	return exists
+	return cache.legacySession(ctx, token)
+}
 
+func (cache *tinydatesRedisCache) Session(
+	ctx context.Context,
+	token string,
+) (int, bool) {
+	// legacy sessions were started without the id of their user, they are
+	// only authorized
+	id, err := redis.Int(cache.do(ctx, "GET", sessionKey(token)))
+	if errors.Is(err, redis.ErrNil) {
+		return 0, false
+	}
+	if err != nil {
+		cache.Logger.ErrorContext(ctx, "failed to get session", "err", err)
+		return 0, false
+	}
+
+	return id, true
+}
+
+// legacySession reports whether token was started by a release keeping the
+// sessions in the set at SESSION_KEY, so that upgrading does not sign every
+// user out. Such sessions never expire, they last until they are ended.
+func (cache *tinydatesRedisCache) legacySession(
+	ctx context.Context,
+	token string,
+) bool {
+	member, err := redis.Bool(cache.do(ctx, "SISMEMBER", SESSION_KEY, token))
+	if err != nil {
+		cache.Logger.ErrorContext(ctx, "failed to check legacy session", "err", err)
+		return false
+	}
+
+	return member
 }
 
 func (cache *tinydatesRedisCache) EndSession(
 	ctx context.Context,
 	token string,
 ) error {
-	conn := cache.Cache.Get()
-	defer conn.Close()
+	return cache.transaction(
+		ctx,
+		[]interface{}{"DEL", sessionKey(token)},
+		[]interface{}{"SREM", SESSION_KEY, token},
+	)
+}
+
+func (cache *tinydatesRedisCache) SetDeck(
+	ctx context.Context,
+	id int,
+	deck Deck,
+	ttl time.Duration,
+) error {
+	ids := make([]string, 0, len(deck.Ids))
+	for _, profile := range deck.Ids {
+		ids = append(ids, strconv.Itoa(profile))
+	}
+
+	complete := "0"
+	if deck.Complete {
+		complete = "1"
+	}
+
+	fields, err := redis.Strings(cache.do(ctx, "HKEYS", deckKey(id)))
+	if err != nil {
+		return err
+	}
+
+	// the swipes of the profiles of the new deck are kept as it may have been
+	// built before them, the others are dropped so that the hash does not
+	// grow with every swipe; a swipe made since they were read is kept
+	stale := []interface{}{"HDEL", deckKey(id), deckServedField}
+	inDeck := make(map[string]bool, len(ids))
+	for _, profile := range ids {
+		inDeck[profile] = true
+	}
+	for _, field := range fields {
+		profile, ok := strings.CutPrefix(field, swipedField)
+		if ok && !inDeck[profile] {
+			stale = append(stale, field)
+		}
+	}
+
+	set := []interface{}{
+		"HSET", deckKey(id),
+		deckKeyField, deck.Key,
+		deckIdsField, strings.Join(ids, ","),
+		deckCompleteField, complete,
+	}
+	if deck.Served > 0 {
+		set = append(set, deckServedField, ids[min(deck.Served, len(ids))-1])
+	}
+
+	return cache.transaction(
+		ctx,
+		stale,
+		set,
+		[]interface{}{"PEXPIRE", deckKey(id), ttl.Milliseconds()},
+	)
+}
 
-	if _, err := conn.Do("SREM", SESSION_KEY, token); err != nil {
+func (cache *tinydatesRedisCache) Deck(
+	ctx context.Context,
+	id int,
+) (Deck, bool, error) {
+	fields, err := redis.StringMap(cache.do(ctx, "HGETALL", deckKey(id)))
+	if err != nil {
+		return Deck{}, false, err
+	}
+
+	ids, ok := fields[deckIdsField]
+	if !ok {
+		return Deck{}, false, nil
+	}
+
+	deck := Deck{
+		Key:      fields[deckKeyField],
+		Ids:      make([]int, 0, strings.Count(ids, ",")+1),
+		Complete: fields[deckCompleteField] == "1",
+	}
+	// a last profile served the deck does not hold was served from a deck it
+	// replaced, it is served from its front
+	last, served := fields[deckServedField]
+	for _, field := range strings.Split(ids, ",") {
+		if field == "" {
+			continue
+		}
+		if _, swiped := fields[swipedField+field]; !swiped {
+			profile, err := strconv.Atoi(field)
+			if err != nil {
+				return Deck{}, false, err
+			}
+			deck.Ids = append(deck.Ids, profile)
+		}
+		if served && field == last {
+			deck.Served = len(deck.Ids)
+		}
+	}
+
+	return deck, true, nil
+}
+
+func (cache *tinydatesRedisCache) Served(
+	ctx context.Context,
+	id int,
+	last int,
+	ttl time.Duration,
+) error {
+	return cache.transaction(
+		ctx,
+		[]interface{}{"HSET", deckKey(id), deckServedField, strconv.Itoa(last)},
+		[]interface{}{"PEXPIRE", deckKey(id), ttl.Milliseconds()},
+	)
+}
+
+func (cache *tinydatesRedisCache) Swiped(
+	ctx context.Context,
+	id int,
+	swipee int,
+	ttl time.Duration,
+) error {
+	return cache.transaction(
+		ctx,
+		[]interface{}{"HSET", deckKey(id), swipedField + strconv.Itoa(swipee), 1},
+		[]interface{}{"PEXPIRE", deckKey(id), ttl.Milliseconds()},
+	)
+}
+
+func (cache *tinydatesRedisCache) DropDeck(ctx context.Context, id int) error {
+	if _, err := cache.do(ctx, "DEL", deckKey(id)); err != nil {
 		return err
 	}
 
 	return nil
 }
+
+// deckKey returns the redis key the deck of the user with the supplied id is
+// stored under.
+func deckKey(id int) string {
+	return DECK_KEY + ":" + strconv.Itoa(id)
+}
+
+// sessionKey returns the redis key the session for token is stored under.
+func sessionKey(token string) string {
+	return SESSION_KEY + ":" + token
+}
@@ -0,0 +1,567 @@
+// Package client is the Go client of version 1 of the tinydates HTTP API,
+// served under /v1. A Client holds the session of a single user, started by
+// Login and sent along with every later call.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"tinydates"
+	"tinydates/logging"
+)
+
+// Retry configures how failed calls are retried. Only Login, Discover, the
+// preferences, identity, taxonomy, about, catalog, Photos and ReorderPhotos
+// calls are retried, repeating CreateUser, Swipe or AddPhoto would create a
+// second user, swipe or photo.
+// Attempts are made on connection errors and on 502, 503 and 504 responses.
+type Retry struct {
+	// Attempts is the total number of attempts, one or less never retries.
+	Attempts int
+
+	// Backoff is the longest wait before the first retry, it doubles for
+	// every retry after that up to MaxBackoff. The wait is randomised so that
+	// clients failing together do not retry together.
+	Backoff time.Duration
+
+	// MaxBackoff caps the wait between two attempts.
+	MaxBackoff time.Duration
+}
+
+// DefaultRetry makes three attempts over about a second.
+var DefaultRetry = Retry{
+	Attempts:   3,
+	Backoff:    200 * time.Millisecond,
+	MaxBackoff: 2 * time.Second,
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithHTTPClient sends the requests with hc instead of http.DefaultClient.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) { c.http = hc }
+}
+
+// WithRetry replaces DefaultRetry.
+func WithRetry(retry Retry) Option {
+	return func(c *Client) { c.retry = retry }
+}
+
+// WithToken resumes a session started earlier.
+func WithToken(token string) Option {
+	return func(c *Client) { c.token = token }
+}
+
+// Client calls the tinydates API at a base URL. It is safe for concurrent
+// use.
+type Client struct {
+	baseURL string
+	http    *http.Client
+	retry   Retry
+
+	mu    sync.RWMutex
+	token string
+}
+
+// New creates a client of the API served at baseURL, such as
+// "http://localhost:8080".
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		http:    http.DefaultClient,
+		retry:   DefaultRetry,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// Token returns the session token, empty until Login succeeds.
+func (c *Client) Token() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.token
+}
+
+// CreateUser creates a random user.
+func (c *Client) CreateUser(ctx context.Context) (tinydates.User, error) {
+	var user tinydates.User
+	err := c.do(ctx, call{
+		method: http.MethodGet,
+		path:   "/v1/user/create",
+	}, &user)
+
+	return user, err
+}
+
+// Login starts a session for the user, kept by the client for the calls that
+// need one.
+func (c *Client) Login(
+	ctx context.Context,
+	email, password string,
+) (tinydates.LoginResponse, error) {
+	var response tinydates.LoginResponse
+	if err := c.do(ctx, call{
+		method:    http.MethodPost,
+		path:      "/v1/login",
+		body:      tinydates.LoginRequest{Email: email, Password: password},
+		retryable: true,
+	}, &response); err != nil {
+		return tinydates.LoginResponse{}, err
+	}
+
+	c.mu.Lock()
+	c.token = response.Token
+	c.mu.Unlock()
+
+	return response, nil
+}
+
+// AgeRange bounds the age of discovered profiles, both ends included. A zero
+// Max leaves the range open above.
+type AgeRange struct {
+	Min int
+	Max int
+}
+
+// DiscoverOptions refines the profiles returned by Discover, the zero value
+// returns the profiles the stored preferences of the user ask for by
+// distance. The filters set replace those preferences for the call.
+type DiscoverOptions struct {
+	// Age, when set, only keeps profiles within the range.
+	Age *AgeRange
+
+	// MaxDistance, when set, only keeps profiles this close or closer.
+	MaxDistance *int
+
+	// Genders, when set, only keeps profiles of these genders.
+	Genders []string
+
+	// Sort names the ranking strategy ordering the profiles, such as
+	// "recommended", distance when empty.
+	Sort string
+
+	// Limit is the most profiles returned, a page of them when zero.
+	Limit int
+
+	// OrderByPopularity orders the profiles by popularity instead.
+	//
+	// Deprecated: set Sort to "popularity".
+	OrderByPopularity bool
+}
+
+// Discover finds the profiles that are a match for the user logged in.
+func (c *Client) Discover(
+	ctx context.Context,
+	opts DiscoverOptions,
+) (tinydates.DiscoverResponse, error) {
+	query := url.Values{}
+	if opts.Age != nil {
+		query.Set("minAge", strconv.Itoa(opts.Age.Min))
+		if opts.Age.Max != 0 {
+			query.Set("maxAge", strconv.Itoa(opts.Age.Max))
+		}
+	}
+	if opts.MaxDistance != nil {
+		query.Set("maxDistance", strconv.Itoa(*opts.MaxDistance))
+	}
+	for _, gender := range opts.Genders {
+		query.Add("gender", gender)
+	}
+	if opts.Sort != "" {
+		query.Set("sort", opts.Sort)
+	}
+	if opts.Limit != 0 {
+		query.Set("limit", strconv.Itoa(opts.Limit))
+	}
+	if opts.OrderByPopularity {
+		query.Set("orderByPopularity", "true")
+	}
+
+	var response tinydates.DiscoverResponse
+	err := c.do(ctx, call{
+		method:    http.MethodGet,
+		path:      "/v1/discover",
+		query:     query,
+		retryable: true,
+	}, &response)
+
+	return response, err
+}
+
+// Preferences returns the discovery preferences of the user logged in.
+func (c *Client) Preferences(ctx context.Context) (tinydates.Preferences, error) {
+	var preferences tinydates.Preferences
+	err := c.do(ctx, call{
+		method:    http.MethodGet,
+		path:      "/v1/me/preferences",
+		retryable: true,
+	}, &preferences)
+
+	return preferences, err
+}
+
+// SetPreferences replaces the discovery preferences of the user logged in,
+// returning them as stored.
+func (c *Client) SetPreferences(
+	ctx context.Context,
+	preferences tinydates.Preferences,
+) (tinydates.Preferences, error) {
+	var stored tinydates.Preferences
+	err := c.do(ctx, call{
+		method:    http.MethodPut,
+		path:      "/v1/me/preferences",
+		body:      preferences,
+		retryable: true,
+	}, &stored)
+
+	return stored, err
+}
+
+// Identity returns the gender and pronouns of the user logged in.
+func (c *Client) Identity(ctx context.Context) (tinydates.Identity, error) {
+	var identity tinydates.Identity
+	err := c.do(ctx, call{
+		method:    http.MethodGet,
+		path:      "/v1/me/identity",
+		retryable: true,
+	}, &identity)
+
+	return identity, err
+}
+
+// SetIdentity replaces the gender and pronouns of the user logged in,
+// returning them as stored.
+func (c *Client) SetIdentity(
+	ctx context.Context,
+	identity tinydates.Identity,
+) (tinydates.Identity, error) {
+	var stored tinydates.Identity
+	err := c.do(ctx, call{
+		method:    http.MethodPut,
+		path:      "/v1/me/identity",
+		body:      identity,
+		retryable: true,
+	}, &stored)
+
+	return stored, err
+}
+
+// Taxonomy returns the genders and pronouns users may pick from.
+func (c *Client) Taxonomy(ctx context.Context) (tinydates.Taxonomy, error) {
+	var taxonomy tinydates.Taxonomy
+	err := c.do(ctx, call{
+		method:    http.MethodGet,
+		path:      "/v1/genders",
+		retryable: true,
+	}, &taxonomy)
+
+	return taxonomy, err
+}
+
+// Catalog returns the prompts and interests users may pick from.
+func (c *Client) Catalog(ctx context.Context) (tinydates.Catalog, error) {
+	var catalog tinydates.Catalog
+	err := c.do(ctx, call{
+		method:    http.MethodGet,
+		path:      "/v1/interests",
+		retryable: true,
+	}, &catalog)
+
+	return catalog, err
+}
+
+// About returns the bio, answered prompts and interests of the user logged
+// in.
+func (c *Client) About(ctx context.Context) (tinydates.About, error) {
+	var about tinydates.About
+	err := c.do(ctx, call{
+		method:    http.MethodGet,
+		path:      "/v1/me/about",
+		retryable: true,
+	}, &about)
+
+	return about, err
+}
+
+// SetAbout replaces the bio, answered prompts and interests of the user
+// logged in, returning them as stored.
+func (c *Client) SetAbout(
+	ctx context.Context,
+	about tinydates.About,
+) (tinydates.About, error) {
+	var stored tinydates.About
+	err := c.do(ctx, call{
+		method:    http.MethodPut,
+		path:      "/v1/me/about",
+		body:      about,
+		retryable: true,
+	}, &stored)
+
+	return stored, err
+}
+
+// Photos returns the photos of the user logged in, in order.
+func (c *Client) Photos(ctx context.Context) ([]tinydates.Photo, error) {
+	var photos []tinydates.Photo
+	err := c.do(ctx, call{
+		method:    http.MethodGet,
+		path:      "/v1/me/photos",
+		retryable: true,
+	}, &photos)
+
+	return photos, err
+}
+
+// AddPhoto uploads a JPEG, PNG or GIF image read from image as the last photo
+// of the user logged in.
+func (c *Client) AddPhoto(
+	ctx context.Context,
+	image io.Reader,
+) (tinydates.Photo, error) {
+	var form bytes.Buffer
+	writer := multipart.NewWriter(&form)
+	part, err := writer.CreateFormFile("photo", "photo")
+	if err != nil {
+		return tinydates.Photo{}, err
+	}
+	if _, err := io.Copy(part, image); err != nil {
+		return tinydates.Photo{}, err
+	}
+	if err := writer.Close(); err != nil {
+		return tinydates.Photo{}, err
+	}
+
+	var photo tinydates.Photo
+	err = c.do(ctx, call{
+		method:      http.MethodPost,
+		path:        "/v1/me/photos",
+		raw:         form.Bytes(),
+		contentType: writer.FormDataContentType(),
+	}, &photo)
+
+	return photo, err
+}
+
+// ReorderPhotos orders the photos of the user logged in as listed by
+// photoIds, which names every one of them once.
+func (c *Client) ReorderPhotos(
+	ctx context.Context,
+	photoIds []int,
+) ([]tinydates.Photo, error) {
+	var photos []tinydates.Photo
+	err := c.do(ctx, call{
+		method:    http.MethodPut,
+		path:      "/v1/me/photos/order",
+		body:      tinydates.PhotoOrder{Ids: photoIds},
+		retryable: true,
+	}, &photos)
+
+	return photos, err
+}
+
+// DeletePhoto deletes a photo of the user logged in.
+func (c *Client) DeletePhoto(ctx context.Context, photoId int) error {
+	return c.do(ctx, call{
+		method: http.MethodDelete,
+		path:   "/v1/me/photos/" + strconv.Itoa(photoId),
+	}, nil)
+}
+
+// Swipe records the decision of the swiper on the swipee.
+func (c *Client) Swipe(
+	ctx context.Context,
+	req tinydates.SwipeRequest,
+) (tinydates.SwipeResponse, error) {
+	var response tinydates.SwipeResponse
+	err := c.do(ctx, call{
+		method: http.MethodPost,
+		path:   "/v1/swipe",
+		body:   req,
+	}, &response)
+
+	return response, err
+}
+
+// call describes a request to the API. Its body is either encoded from body
+// as JSON or sent as raw, of contentType.
+type call struct {
+	method      string
+	path        string
+	query       url.Values
+	header      http.Header
+	body        any
+	raw         []byte
+	contentType string
+	retryable   bool
+}
+
+// do sends the call, retrying it when allowed, and decodes the successful
+// response into out unless it is nil.
+func (c *Client) do(ctx context.Context, call call, out any) error {
+	attempts := 1
+	if call.retryable {
+		attempts = max(attempts, c.retry.Attempts)
+	}
+
+	var err error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			if waitErr := c.wait(ctx, attempt); waitErr != nil {
+				return err
+			}
+		}
+
+		var retry bool
+		retry, err = c.send(ctx, call, out)
+		if !retry {
+			return err
+		}
+	}
+
+	return err
+}
+
+// wait sleeps before the retry numbered attempt, returning early with the
+// context error when ctx is done.
+func (c *Client) wait(ctx context.Context, attempt int) error {
+	backoff := c.retry.Backoff << (attempt - 1)
+	if c.retry.MaxBackoff > 0 && (backoff > c.retry.MaxBackoff || backoff <= 0) {
+		backoff = c.retry.MaxBackoff
+	}
+	if backoff <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(time.Duration(rand.Int63n(int64(backoff))))
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// send makes a single attempt at the call, reporting whether its failure is
+// worth retrying.
+func (c *Client) send(ctx context.Context, call call, out any) (bool, error) {
+	var body io.Reader
+	contentType := call.contentType
+	switch {
+	case call.body != nil:
+		encoded, err := json.Marshal(call.body)
+		if err != nil {
+			return false, err
+		}
+		body = bytes.NewReader(encoded)
+		contentType = "application/json"
+	case call.raw != nil:
+		body = bytes.NewReader(call.raw)
+	}
+
+	target := c.baseURL + call.path
+	if len(call.query) > 0 {
+		target += "?" + call.query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, call.method, target, body)
+	if err != nil {
+		return false, err
+	}
+	for name, values := range call.header {
+		req.Header[name] = values
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if token := c.Token(); token != "" {
+		// for simplicity the API does not use Authorization: <scheme> <token>
+		req.Header.Set("Authorization", token)
+	}
+	if id := logging.RequestID(ctx); id != "" {
+		req.Header.Set("X-Request-Id", id)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		// a done context is final, anything else may be a passing failure
+		return ctx.Err() == nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		switch resp.StatusCode {
+		case http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout:
+			return true, decodeError(resp)
+		default:
+			return false, decodeError(resp)
+		}
+	}
+
+	if out == nil {
+		return false, nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return false, fmt.Errorf("invalid response: %w", err)
+	}
+
+	return false, nil
+}
+
+// decodeError reads the problem details of an error response. When its code
+// is known the matching sentinel error is returned, wrapping a
+// *ResponseError, so that errors.Is(err, tinydates.ErrUnauthorized) holds.
+func decodeError(resp *http.Response) error {
+	responseErr := &ResponseError{StatusCode: resp.StatusCode}
+
+	if err := json.NewDecoder(resp.Body).Decode(&responseErr.Problem); err != nil {
+		// not problem details, such as an error from a proxy
+		return responseErr
+	}
+
+	if sentinel, ok := tinydates.ErrorByCode(responseErr.Problem.Code); ok {
+		return sentinel.Wrap(responseErr)
+	}
+
+	return responseErr
+}
+
+// ResponseError is an error response of the API. Problem is left empty when
+// the body could not be read as problem details.
+type ResponseError struct {
+	StatusCode int
+	Problem    tinydates.ProblemDetails
+}
+
+func (e *ResponseError) Error() string {
+	if e.Problem.Code == "" {
+		return fmt.Sprintf("tinydates: status %d", e.StatusCode)
+	}
+
+	return fmt.Sprintf(
+		"tinydates: status %d %s, request %s",
+		e.StatusCode,
+		e.Problem.Code,
+		e.Problem.RequestId,
+	)
+}
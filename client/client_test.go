@@ -0,0 +1,170 @@
+package client_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+	"tinydates"
+	"tinydates/client"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fastRetry keeps the tests quick.
+var fastRetry = client.Retry{Attempts: 3, Backoff: time.Millisecond}
+
+// problem answers with the problem details of err.
+func problem(w http.ResponseWriter, r *http.Request, err *tinydates.Error) {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(err.Status)
+	json.NewEncoder(w).Encode(tinydates.NewProblemDetails(r, err))
+}
+
+func TestLoginKeepsTheSession(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/v1/login":
+				json.NewEncoder(w).Encode(tinydates.LoginResponse{Token: "token"})
+			case "/v1/discover":
+				assert.Equal(t, "token", r.Header.Get("Authorization"))
+				assert.Equal(t, "18", r.URL.Query().Get("minAge"))
+				assert.Equal(t, "30", r.URL.Query().Get("maxAge"))
+				assert.Equal(t, "true", r.URL.Query().Get("orderByPopularity"))
+				json.NewEncoder(w).Encode(tinydates.DiscoverResponse{
+					Results: tinydates.DiscoveredUsers{{Id: 3}},
+				})
+			}
+		},
+	))
+	defer server.Close()
+
+	ctx := context.Background()
+	c := client.New(server.URL)
+	_, err := c.Login(ctx, "a@mail.com", "password")
+	require.NoError(t, err)
+	require.Equal(t, "token", c.Token())
+
+	found, err := c.Discover(ctx, client.DiscoverOptions{
+		Age:               &client.AgeRange{Min: 18, Max: 30},
+		OrderByPopularity: true,
+	})
+	require.NoError(t, err)
+	require.Equal(t, 3, found.Results[0].Id)
+}
+
+func TestErrorsMapOntoSentinels(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			problem(w, r, tinydates.ErrUnauthorized)
+		},
+	))
+	defer server.Close()
+
+	_, err := client.New(server.URL).Discover(
+		context.Background(),
+		client.DiscoverOptions{},
+	)
+	require.ErrorIs(t, err, tinydates.ErrUnauthorized)
+	require.NotErrorIs(t, err, tinydates.ErrInvalidPassword)
+
+	var responseErr *client.ResponseError
+	require.ErrorAs(t, err, &responseErr)
+	require.Equal(t, http.StatusUnauthorized, responseErr.StatusCode)
+	require.Equal(t, "/v1/discover", responseErr.Problem.Instance)
+}
+
+func TestRetriesPassingFailures(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if calls.Add(1) < 3 {
+				problem(w, r, tinydates.ErrTimeout)
+				return
+			}
+			json.NewEncoder(w).Encode(tinydates.DiscoverResponse{})
+		},
+	))
+	defer server.Close()
+
+	c := client.New(server.URL, client.WithRetry(fastRetry))
+	_, err := c.Discover(context.Background(), client.DiscoverOptions{})
+	require.NoError(t, err)
+	require.Equal(t, int32(3), calls.Load())
+}
+
+func TestGivesUpAfterTheLastAttempt(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			problem(w, r, tinydates.ErrCanceled)
+		},
+	))
+	defer server.Close()
+
+	c := client.New(server.URL, client.WithRetry(fastRetry))
+	_, err := c.Discover(context.Background(), client.DiscoverOptions{})
+	require.ErrorIs(t, err, tinydates.ErrCanceled)
+	require.Equal(t, int32(3), calls.Load())
+}
+
+func TestDoesNotRetryCallsThatAreNotIdempotent(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			problem(w, r, tinydates.ErrTimeout)
+		},
+	))
+	defer server.Close()
+
+	c := client.New(server.URL, client.WithRetry(fastRetry))
+	_, err := c.Swipe(context.Background(), tinydates.SwipeRequest{})
+	require.ErrorIs(t, err, tinydates.ErrTimeout)
+	_, err = c.CreateUser(context.Background())
+	require.ErrorIs(t, err, tinydates.ErrTimeout)
+	require.Equal(t, int32(2), calls.Load())
+}
+
+func TestClientErrorsAreNotRetried(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			problem(w, r, tinydates.ErrInvalidPassword)
+		},
+	))
+	defer server.Close()
+
+	c := client.New(server.URL, client.WithRetry(fastRetry))
+	_, err := c.Login(context.Background(), "a@mail.com", "wrong")
+	require.ErrorIs(t, err, tinydates.ErrInvalidPassword)
+	require.Equal(t, int32(1), calls.Load())
+}
+
+func TestContextStopsRetrying(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			problem(w, r, tinydates.ErrTimeout)
+		},
+	))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	c := client.New(server.URL, client.WithRetry(client.Retry{
+		Attempts: 100,
+		Backoff:  time.Second,
+	}))
+	start := time.Now()
+	_, err := c.Discover(ctx, client.DiscoverOptions{})
+	require.Error(t, err)
+	require.Less(t, time.Since(start), time.Second)
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"tinydates/about"
+)
+
+// newCatalog returns the prompts and interests users may pick from, read from
+// the file at PROFILE_CATALOG when it is set and about.Default otherwise.
+// Prompts and interests already stored are not rewritten, so the file should
+// keep the ids in use.
+func newCatalog() (*about.Catalog, error) {
+	path := os.Getenv("PROFILE_CATALOG")
+	if path == "" {
+		return about.Default(), nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open profile catalog: %w", err)
+	}
+	defer file.Close()
+
+	return about.ReadCatalog(file)
+}
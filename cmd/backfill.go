@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"time"
+	"tinydates/rating"
+	"tinydates/store"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const backfillRatingsUsage = `usage: tinydates backfill-ratings
+
+Recomputes the desirability of every user of the STORE_BACKEND database by
+replaying the history of swipes, as if each had been rated when it was made.
+Run it once after upgrading to rated desirability, or whenever scores may
+have drifted; swipes made while it runs are overwritten, running it again
+catches them up.
+`
+
+// runBackfillRatings runs the backfill-ratings subcommand described by
+// backfillRatingsUsage.
+func runBackfillRatings(
+	ctx context.Context,
+	logger *slog.Logger,
+	args []string,
+) error {
+	flags := flag.NewFlagSet("backfill-ratings", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), backfillRatingsUsage)
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	// the store is left uninstrumented to keep its backfill methods
+	dataStore, closeStore, err := openStore(ctx, prometheus.NewRegistry(), nil, true)
+	if err != nil {
+		return err
+	}
+	defer closeStore()
+
+	return backfillRatings(ctx, logger, dataStore)
+}
+
+// backfillRatings recomputes the desirability of every user of s.
+func backfillRatings(ctx context.Context, logger *slog.Logger, s store.Store) error {
+	backfiller, ok := s.(store.Backfiller)
+	if !ok {
+		return fmt.Errorf("the %s store cannot be backfilled", storeBackend())
+	}
+
+	start := time.Now()
+	replayed, err := rating.Backfill(ctx, backfiller)
+	if err != nil {
+		return err
+	}
+
+	logger.Info(
+		"backfilled desirability",
+		"swipes", replayed,
+		"took", time.Since(start),
+	)
+
+	return nil
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+	"tinydates/cache"
+	"tinydates/metrics"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// newCache creates the cache selected by CACHE_BACKEND, "memory" by default
+// or "redis" at REDIS_URL so that sessions and decks are shared by every
+// replica. The cache is instrumented by decoration and the returned function
+// releases the underlying connections.
+func newCache(
+	registry *prometheus.Registry,
+	logger *slog.Logger,
+	tp trace.TracerProvider,
+) (cache.Cache, func(), error) {
+	ttl := durationEnv("SESSION_TTL", 24*time.Hour)
+
+	switch backend := cacheBackend(); backend {
+	case "memory":
+		return metrics.NewInstrumentedCache(
+			cache.NewTinydatesInMemoryCache(ttl),
+			backend,
+			registry,
+		), func() {}, nil
+	case "redis":
+		addr := os.Getenv("REDIS_URL")
+		pool := &redis.Pool{
+			MaxIdle:     8,
+			IdleTimeout: time.Minute,
+			Dial: func() (redis.Conn, error) {
+				return redis.Dial("tcp", addr)
+			},
+		}
+
+		return metrics.NewInstrumentedCache(
+			cache.NewTinydatesRedisCache(pool, ttl, logger, tp),
+			backend,
+			registry,
+		), func() { pool.Close() }, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown cache backend %q", backend)
+	}
+}
+
+// cacheBackend returns the backend named by CACHE_BACKEND, "memory" when it
+// is unset.
+func cacheBackend() string {
+	if backend := os.Getenv("CACHE_BACKEND"); backend != "" {
+		return backend
+	}
+
+	return "memory"
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"tinydates/identity"
+)
+
+// newTaxonomy returns the genders and pronouns users may pick from, read from
+// the file at GENDER_TAXONOMY when it is set and identity.Default otherwise.
+// Genders already stored are not rewritten, so the file should keep the ids
+// in use.
+func newTaxonomy() (*identity.Taxonomy, error) {
+	path := os.Getenv("GENDER_TAXONOMY")
+	if path == "" {
+		return identity.Default(), nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open gender taxonomy: %w", err)
+	}
+	defer file.Close()
+
+	return identity.ReadTaxonomy(file)
+}
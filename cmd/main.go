@@ -2,27 +2,36 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
+	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 	"time"
 	"tinydates"
-	"tinydates/cache"
-	"tinydates/store"
+	"tinydates/about"
+	"tinydates/grpcapi"
+	"tinydates/identity"
+	"tinydates/logging"
+	"tinydates/photos"
+	"tinydates/ranking"
+	"tinydates/recommend"
+	"tinydates/tracing"
 
-	"github.com/golang-migrate/migrate/v4"
-	_ "github.com/golang-migrate/migrate/v4/database/postgres"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
-	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/joho/godotenv"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"go.opentelemetry.io/otel/trace"
 )
 
-// run starts the tinydates service
-func run() error {
+// run starts the tinydates service, or runs the migrate, seed,
+// backfill-ratings, train or evaluate subcommand named by the first of args.
+func run(args []string) error {
 	// setup; blocking error channel and parent context object
-	// note: omitting creation of loggers and instrumentation for simplicity
 	errChan := make(chan error)
 	ctx := contextWithSignal(context.Background())
 
@@ -32,46 +41,158 @@ func run() error {
 		os.Exit(1)
 	}
 
-	// database connection and initialisation
-	dbPool, err := pgxpool.New(ctx, os.Getenv("POSTGRES_URL"))
+	// structured logger shared by every component; also made the default so
+	// that libraries logging through slog end up in the same output
+	logger := logging.New(
+		os.Stdout,
+		os.Getenv("LOG_FORMAT"),
+		logging.ParseLevel(os.Getenv("LOG_LEVEL")),
+	)
+	slog.SetDefault(logger)
+
+	if len(args) > 0 {
+		switch args[0] {
+		case "migrate":
+			return runMigrate(ctx, logger, args[1:])
+		case "seed":
+			return runSeed(ctx, logger, args[1:])
+		case "backfill-ratings":
+			return runBackfillRatings(ctx, logger, args[1:])
+		case "train":
+			return runTrain(ctx, logger, args[1:])
+		case "evaluate":
+			return runEvaluate(ctx, logger, args[1:])
+		}
+	}
+
+	flags := flag.NewFlagSet("tinydates", flag.ExitOnError)
+	noMigrate := flags.Bool(
+		"no-migrate",
+		false,
+		"skip migrations on start up, when a separate job runs tinydates migrate",
+	)
+	flags.Parse(args)
+
+	// metrics registry exposed on /metrics; runtime and process metrics are
+	// included alongside those of the application
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+
+	// distributed tracing exported over OTLP when enabled; left nil otherwise
+	// so that no spans are created at all
+	var tracerProvider trace.TracerProvider
+	if os.Getenv("TRACING_ENABLED") == "true" {
+		exporter, err := tracing.NewOTLPExporter(ctx)
+		if err != nil {
+			logger.Error("unable to create trace exporter", "err", err)
+			os.Exit(1)
+		}
+
+		tp := tracing.NewProvider(exporter)
+		defer tp.Shutdown(context.Background())
+		tracing.SetGlobal(tp)
+		tracerProvider = tp
+	}
+
+	// database connection, initialisation and idempotent migrations for the
+	// configured backend; the store is instrumented by decoration
+	dataStore, closeStore, err := newStore(ctx, registry, tracerProvider, !*noMigrate)
+	if err != nil {
+		logger.Error("unable to create the store", "err", err)
+		os.Exit(1)
+	}
+	defer closeStore()
+
+	// create cache using connection pool, instrumented by decoration
+	dataCache, closeCache, err := newCache(registry, logger, tracerProvider)
 	if err != nil {
-		fmt.Printf("unable to connect to the database: %v\n", dbPool)
+		logger.Error("unable to create the cache", "err", err)
 		os.Exit(1)
 	}
-	defer dbPool.Close()
+	defer closeCache()
 
-	// create store using connection pool
-	postgresStore := store.NewTinydatesPgStore(dbPool)
+	// ranking strategies of discovery, their weights tunable at runtime
+	strategies, err := newStrategies(ctx, logger)
+	if err != nil {
+		logger.Error("unable to configure ranking", "err", err)
+		os.Exit(1)
+	}
+	// collaborative filtering, served from the model trained offline
+	err = strategies.Add(recommend.Name, newRecommender(ctx, logger).Strategy())
+	if err != nil {
+		logger.Error("unable to configure recommendations", "err", err)
+		os.Exit(1)
+	}
 
-	// run idempotent database migrations at start of application
-	m, err := migrate.New(os.Getenv("MIGRATION_URL"), os.Getenv("POSTGRES_URL"))
+	// genders and pronouns users may pick from
+	taxonomy, err := newTaxonomy()
 	if err != nil {
-		fmt.Printf("unable to connect to migration database: %v\n", err)
+		logger.Error("unable to configure genders", "err", err)
 		os.Exit(1)
 	}
-	if err = m.Up(); err != nil && err != migrate.ErrNoChange {
-		fmt.Printf("failed to run database migration: %v\n", err)
+
+	// prompts and interests users may pick from
+	catalog, err := newCatalog()
+	if err != nil {
+		logger.Error("unable to configure profile catalog", "err", err)
 		os.Exit(1)
 	}
 
-	// create cache using connection pool
-	inMemoryCache := cache.NewTinydatesInMemoryCache()
+	// where the photos users upload are kept, when they may upload any
+	blobs, photoFiles, err := newPhotos()
+	if err != nil {
+		logger.Error("unable to configure photos", "err", err)
+		os.Exit(1)
+	}
 
 	// Tinydates service creation; dependency injection of db, and cache
-	service := tinydates.New(postgresStore, inMemoryCache)
+	service := tinydates.NewInstrumentedService(
+		tinydates.New(
+			dataStore,
+			dataCache,
+			logger,
+			serviceOptions(strategies, taxonomy, catalog, blobs)...,
+		),
+		registry,
+	)
+	if tracerProvider != nil {
+		service = tinydates.NewTracedService(service, tracerProvider)
+	}
 
-	// handler creation; dependency injection of context and service
-	handler := tinydates.NewTinydatesHandler(ctx, service)
+	// handler creation; dependency injection of service and its collaborators
+	handler := tinydates.NewTinydatesHandler(
+		service,
+		tinydates.HandlerConfig{
+			Logger:         logger,
+			Metrics:        registry,
+			TracerProvider: tracerProvider,
+			// kept below the server write timeout so that the caller receives
+			// a timeout response rather than a dropped connection
+			DefaultTimeout: durationEnv("REQUEST_TIMEOUT", 4*time.Second),
+			Timeouts: map[string]time.Duration{
+				"/discover": durationEnv("DISCOVER_TIMEOUT", 3*time.Second),
+				"/swipe":    durationEnv("SWIPE_TIMEOUT", time.Second),
+			},
+			LegacySunset: dateEnv("LEGACY_SUNSET"),
+			Photos:       photoFiles,
+		},
+	)
 
 	// HTTP server creation with sane defaults for the type of service
 	server := &http.Server{
-		Addr: os.Getenv("PORT"),
-		Handler: handler,
-		IdleTimeout: 15 * time.Second,
-		ReadTimeout: 5 * time.Second,
+		Addr:         os.Getenv("PORT"),
+		Handler:      handler,
+		ErrorLog:     slog.NewLogLogger(logger.Handler(), slog.LevelError),
+		IdleTimeout:  15 * time.Second,
+		ReadTimeout:  5 * time.Second,
 		WriteTimeout: 5 * time.Second,
 	}
 
+	logger.Info("tinydates listening", "addr", server.Addr)
+
 	// start the server in a goroutine; graceful shutdown upon a major error
 	go func() {
 		if err := server.ListenAndServe(); err != nil {
@@ -79,6 +200,31 @@ func run() error {
 		}
 	}()
 
+	// gRPC server on its own port, when one is configured, serving the same
+	// service and sharing the sessions of the HTTP API
+	if addr := os.Getenv("GRPC_PORT"); addr != "" {
+		listener, err := net.Listen("tcp", addr)
+		if err != nil {
+			logger.Error("unable to listen for gRPC", "addr", addr, "err", err)
+			os.Exit(1)
+		}
+
+		grpcServer := grpcapi.NewServer(
+			service,
+			dataCache,
+			grpcapi.Config{Logger: logger},
+		)
+		defer grpcServer.Stop()
+
+		logger.Info("tinydates gRPC listening", "addr", addr)
+
+		go func() {
+			if err := grpcServer.Serve(listener); err != nil {
+				errChan <- err
+			}
+		}()
+	}
+
 	// listen for user termination (CTRL+C) signal in goroutine; ungraceful
 	// termination upon shutdown signal
 	go func() {
@@ -95,7 +241,7 @@ func run() error {
 // is called in the host operating system.
 func contextWithSignal(ctx context.Context) context.Context {
 	newCtx, cancel := context.WithCancel(ctx)
-	signals := make(chan os.Signal)
+	signals := make(chan os.Signal, 1)
 	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
 	go func() {
 		select {
@@ -106,9 +252,73 @@ func contextWithSignal(ctx context.Context) context.Context {
 	return newCtx
 }
 
+// serviceOptions configures the service with strategies, taxonomy and
+// catalog, serving discovery from decks when DISCOVERY_DECKS is true and taking photo
+// uploads when blobs is set.
+func serviceOptions(
+	strategies *ranking.Strategies,
+	taxonomy *identity.Taxonomy,
+	catalog *about.Catalog,
+	blobs photos.BlobStore,
+) []tinydates.Option {
+	opts := []tinydates.Option{
+		tinydates.WithStrategies(strategies),
+		tinydates.WithTaxonomy(taxonomy),
+		tinydates.WithCatalog(catalog),
+	}
+	if blobs != nil {
+		opts = append(opts, tinydates.WithPhotos(blobs, photoLimits()))
+	}
+
+	if os.Getenv("DISCOVERY_DECKS") == "true" {
+		cfg := tinydates.DefaultDeckConfig()
+		opts = append(opts, tinydates.WithDecks(tinydates.DeckConfig{
+			Size:     intEnv("DECK_SIZE", cfg.Size),
+			PageSize: intEnv("DECK_PAGE_SIZE", cfg.PageSize),
+			Refill:   intEnv("DECK_REFILL", cfg.Refill),
+			TTL:      durationEnv("DECK_TTL", cfg.TTL),
+		}))
+	}
+
+	return opts
+}
+
+// intEnv parses the integer held in the named environment variable,
+// returning fallback when it is unset or invalid.
+func intEnv(name string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(name))
+	if err != nil {
+		return fallback
+	}
+
+	return value
+}
+
+// durationEnv parses the duration held in the named environment variable,
+// returning fallback when it is unset or invalid.
+func durationEnv(name string, fallback time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(name))
+	if err != nil {
+		return fallback
+	}
+
+	return value
+}
+
+// dateEnv parses the date, such as 2027-01-01, held in the named environment
+// variable, returning the zero time when it is unset or invalid.
+func dateEnv(name string) time.Time {
+	value, err := time.Parse(time.DateOnly, os.Getenv(name))
+	if err != nil {
+		return time.Time{}
+	}
+
+	return value
+}
+
 // application entrypoint
 func main() {
-	if err := run(); err != nil {
+	if err := run(os.Args[1:]); err != nil {
 		fmt.Println(err)
 		os.Exit(1)
 	}
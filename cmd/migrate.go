@@ -0,0 +1,296 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"tinydates/config"
+	"tinydates/store"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const migrateUsage = `usage: tinydates migrate [flags] <command>
+
+Manages the database of the STORE_BACKEND using the migrations embedded in the
+binary.
+
+commands:
+  up [N]       apply every pending migration, or only the next N
+  down N       revert the last N applied migrations
+  goto V       migrate up or down to version V
+  force V      set the version to V without running any migration, used to
+               recover from a dirty database once it has been fixed by hand
+  status       show the current version and every migration
+  create NAME  write empty up and down files for a new migration, embedded
+               once the binary is rebuilt
+
+flags:
+`
+
+// migrationName restricts the names of created migrations to those already in
+// use, such as add_swipes.
+var migrationName = regexp.MustCompile(`^[a-z0-9]+(_[a-z0-9]+)*$`)
+
+// runMigrate runs the migrate subcommand described by migrateUsage.
+func runMigrate(ctx context.Context, logger *slog.Logger, args []string) error {
+	flags := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	dir := flags.String(
+		"dir",
+		"",
+		"directory create writes to (default config/<backend>/migration)",
+	)
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), migrateUsage)
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return errors.New("missing migrate command")
+	}
+
+	backend := storeBackend()
+	command, args := flags.Arg(0), flags.Args()[1:]
+
+	var run func(m *migrate.Migrate) error
+	switch command {
+	case "up":
+		if len(args) == 0 {
+			run = migrateUp
+			break
+		}
+		n, err := countArg(command, args)
+		if err != nil {
+			return err
+		}
+		run = func(m *migrate.Migrate) error { return m.Steps(n) }
+	case "down":
+		n, err := countArg(command, args)
+		if err != nil {
+			return err
+		}
+		run = func(m *migrate.Migrate) error { return m.Steps(-n) }
+	case "goto":
+		version, err := versionArg(command, args)
+		if err != nil {
+			return err
+		}
+		run = func(m *migrate.Migrate) error { return m.Migrate(uint(version)) }
+	case "force":
+		version, err := versionArg(command, args)
+		if err != nil {
+			return err
+		}
+		run = func(m *migrate.Migrate) error { return m.Force(version) }
+	case "status":
+		if len(args) != 0 {
+			return fmt.Errorf("usage: tinydates migrate status")
+		}
+		run = func(m *migrate.Migrate) error {
+			return printStatus(os.Stdout, migrationFiles(backend), m)
+		}
+	case "create":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: tinydates migrate create NAME")
+		}
+		if *dir == "" {
+			*dir = filepath.Join("config", backend, "migration")
+		}
+		return createMigration(os.Stdout, *dir, args[0])
+	default:
+		flags.Usage()
+		return fmt.Errorf("unknown migrate command %q", command)
+	}
+
+	return withMigrate(ctx, backend, func(m *migrate.Migrate) error {
+		m.Log = &migrateLogger{logger: logger}
+		return run(m)
+	})
+}
+
+// withMigrate connects to the database of backend and runs fn with its
+// embedded migrations.
+func withMigrate(
+	ctx context.Context,
+	backend string,
+	fn func(m *migrate.Migrate) error,
+) error {
+	switch backend {
+	case "postgres":
+		dbPool, err := pgxpool.New(ctx, os.Getenv("POSTGRES_URL"))
+		if err != nil {
+			return fmt.Errorf("unable to connect to the database: %w", err)
+		}
+		defer dbPool.Close()
+
+		return withPostgresMigrate(ctx, dbPool, fn)
+	case "sqlite":
+		db, err := store.OpenSqlite(os.Getenv("SQLITE_PATH"))
+		if err != nil {
+			return fmt.Errorf("unable to open the database: %w", err)
+		}
+		defer db.Close()
+
+		return withSqliteMigrate(db, fn)
+	default:
+		return fmt.Errorf("unknown store backend %q", backend)
+	}
+}
+
+// migrationFiles returns the migrations embedded for backend.
+func migrationFiles(backend string) fs.FS {
+	if backend == "sqlite" {
+		return config.SqliteMigrations()
+	}
+
+	return config.PostgresMigrations()
+}
+
+// countArg parses the single positive number of migrations given to command.
+func countArg(command string, args []string) (int, error) {
+	if len(args) != 1 {
+		return 0, fmt.Errorf("usage: tinydates migrate %s N", command)
+	}
+
+	n, err := strconv.Atoi(args[0])
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid number of migrations %q", args[0])
+	}
+
+	return n, nil
+}
+
+// versionArg parses the single migration version given to command.
+func versionArg(command string, args []string) (int, error) {
+	if len(args) != 1 {
+		return 0, fmt.Errorf("usage: tinydates migrate %s V", command)
+	}
+
+	version, err := strconv.Atoi(args[0])
+	if err != nil || version < 0 {
+		return 0, fmt.Errorf("invalid migration version %q", args[0])
+	}
+
+	return version, nil
+}
+
+// printStatus writes the current version of the database and whether each
+// migration in files has been applied.
+func printStatus(w io.Writer, files fs.FS, m *migrate.Migrate) error {
+	migrations, err := listMigrations(files)
+	if err != nil {
+		return err
+	}
+
+	current, dirty, err := m.Version()
+	switch {
+	case errors.Is(err, migrate.ErrNilVersion):
+		fmt.Fprintln(w, "version: none")
+	case err != nil:
+		return err
+	case dirty:
+		fmt.Fprintf(w, "version: %d (dirty, fix the database then force a version)\n", current)
+	default:
+		fmt.Fprintf(w, "version: %d\n", current)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, migration := range migrations {
+		state := "pending"
+		if err == nil && migration.Version <= current {
+			state = "applied"
+		}
+		fmt.Fprintf(tw, "%06d\t%s\t%s\n", migration.Version, migration.Identifier, state)
+	}
+
+	return tw.Flush()
+}
+
+// createMigration writes empty up and down files to dir for the migration
+// following the last one found there.
+func createMigration(w io.Writer, dir, name string) error {
+	if !migrationName.MatchString(name) {
+		return fmt.Errorf("invalid migration name %q, use lower_snake_case", name)
+	}
+
+	migrations, err := listMigrations(os.DirFS(dir))
+	if err != nil {
+		return err
+	}
+
+	version := uint(1)
+	if len(migrations) > 0 {
+		version = migrations[len(migrations)-1].Version + 1
+	}
+
+	for _, direction := range []string{"up", "down"} {
+		path := filepath.Join(dir, fmt.Sprintf("%06d_%s.%s.sql", version, name, direction))
+
+		// never overwrite an existing migration
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if err != nil {
+			return err
+		}
+		if err = file.Close(); err != nil {
+			return err
+		}
+
+		fmt.Fprintln(w, path)
+	}
+
+	return nil
+}
+
+// listMigrations returns the up migrations found in files by version.
+func listMigrations(files fs.FS) ([]*source.Migration, error) {
+	names, err := fs.Glob(files, "*.up.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	migrations := make([]*source.Migration, 0, len(names))
+	for _, name := range names {
+		migration, err := source.Parse(name)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration file %q: %w", name, err)
+		}
+		migrations = append(migrations, migration)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// migrateLogger reports the progress of migrate through the structured
+// logger.
+type migrateLogger struct {
+	logger *slog.Logger
+}
+
+func (l *migrateLogger) Printf(format string, v ...interface{}) {
+	l.logger.Info(strings.TrimSpace(fmt.Sprintf(format, v...)))
+}
+
+func (l *migrateLogger) Verbose() bool {
+	return false
+}
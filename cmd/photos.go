@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"tinydates/photos"
+)
+
+// newPhotos creates the blob store selected by PHOTO_STORE, "local" to keep
+// the photos under PHOTO_DIR, "photos" by default, and serve them at
+// PHOTO_BASE_URL, "/photos" by default, or "s3" to keep them in the S3_BUCKET
+// of an S3 compatible service such as MinIO. Photos are not enabled when
+// PHOTO_STORE is unset. The returned handler serves the local photos, it is
+// nil when these are served by the bucket.
+func newPhotos() (photos.BlobStore, http.Handler, error) {
+	switch backend := os.Getenv("PHOTO_STORE"); backend {
+	case "":
+		return nil, nil, nil
+	case "local":
+		baseURL := os.Getenv("PHOTO_BASE_URL")
+		if baseURL == "" {
+			baseURL = "/photos"
+		}
+
+		dir := os.Getenv("PHOTO_DIR")
+		if dir == "" {
+			dir = "photos"
+		}
+
+		files, err := photos.NewFileStore(dir, baseURL)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return files, files, nil
+	case "s3":
+		s3, err := photos.NewS3Store(photos.S3Config{
+			Endpoint:        os.Getenv("S3_ENDPOINT"),
+			Region:          os.Getenv("S3_REGION"),
+			Bucket:          os.Getenv("S3_BUCKET"),
+			AccessKeyID:     os.Getenv("S3_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("S3_SECRET_ACCESS_KEY"),
+			PublicURL:       os.Getenv("PHOTO_BASE_URL"),
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return s3, nil, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown photo store %q", backend)
+	}
+}
+
+// photoLimits returns photos.DefaultLimits, its largest upload replaced by
+// PHOTO_MAX_BYTES when set.
+func photoLimits() photos.Limits {
+	limits := photos.DefaultLimits()
+	limits.MaxBytes = int64(intEnv("PHOTO_MAX_BYTES", int(limits.MaxBytes)))
+
+	return limits
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"tinydates/ranking"
+)
+
+// newStrategies returns the strategies of discovery, weighted by the file at
+// RANKING_WEIGHTS when it is set. The file is read again on every SIGHUP so
+// that weights can be tuned without a restart, invalid weights are logged and
+// the previous ones kept.
+func newStrategies(ctx context.Context, logger *slog.Logger) (*ranking.Strategies, error) {
+	strategies := ranking.NewStrategies()
+
+	path := os.Getenv("RANKING_WEIGHTS")
+	if path == "" {
+		return strategies, nil
+	}
+
+	if err := loadWeights(strategies, path); err != nil {
+		return nil, err
+	}
+	logger.Info("loaded ranking weights", "path", path, "strategies", strategies.Names())
+
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+	go func() {
+		defer signal.Stop(reload)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-reload:
+				if err := loadWeights(strategies, path); err != nil {
+					logger.Error("unable to reload ranking weights", "path", path, "err", err)
+					continue
+				}
+				logger.Info("reloaded ranking weights", "path", path, "strategies", strategies.Names())
+			}
+		}
+	}()
+
+	return strategies, nil
+}
+
+// loadWeights replaces the weighted strategies by those in the file at path.
+func loadWeights(strategies *ranking.Strategies, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("unable to open ranking weights: %w", err)
+	}
+	defer file.Close()
+
+	weights, err := ranking.ReadWeights(file)
+	if err != nil {
+		return err
+	}
+
+	return strategies.SetWeights(weights)
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"text/tabwriter"
+	"time"
+	"tinydates/recommend"
+	"tinydates/store"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const trainUsage = `usage: tinydates train [flags]
+
+Trains the collaborative filtering model recommending profiles from the
+history of swipes of the STORE_BACKEND database, then writes it to -out. A
+server whose RECOMMENDATION_MODEL is the same file reloads it once written.
+
+flags:
+`
+
+const evaluateUsage = `usage: tinydates evaluate [flags]
+
+Evaluates collaborative filtering on the history of swipes of the
+STORE_BACKEND database: a model is trained on the first swipes, then the
+precision at -k of its recommendations for predicting the matches made in the
+last -holdout share of the swipes is reported, along with that of
+recommending the most liked profiles.
+
+flags:
+`
+
+// runTrain runs the train subcommand described by trainUsage.
+func runTrain(ctx context.Context, logger *slog.Logger, args []string) error {
+	flags := flag.NewFlagSet("train", flag.ContinueOnError)
+	out := flags.String("out", os.Getenv("RECOMMENDATION_MODEL"), "file the model is written to")
+	neighbours := flags.Int(
+		"neighbours",
+		recommend.DefaultNeighbours,
+		"number of neighbours kept for each liked profile",
+	)
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), trainUsage)
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *out == "" {
+		return errors.New("the file to write the model to is required, set -out")
+	}
+
+	history, closeStore, err := openHistory(ctx)
+	if err != nil {
+		return err
+	}
+	defer closeStore()
+
+	start := time.Now()
+	model, err := recommend.Train(ctx, history, *neighbours)
+	if err != nil {
+		return err
+	}
+	if err := model.Save(*out); err != nil {
+		return err
+	}
+
+	logger.Info(
+		"trained the recommendation model",
+		"out", *out,
+		"swipes", model.Swipes,
+		"users", len(model.Liked),
+		"took", time.Since(start),
+	)
+
+	return nil
+}
+
+// runEvaluate runs the evaluate subcommand described by evaluateUsage.
+func runEvaluate(ctx context.Context, logger *slog.Logger, args []string) error {
+	flags := flag.NewFlagSet("evaluate", flag.ContinueOnError)
+	holdout := flags.Float64("holdout", 0.2, "share of the last swipes held out")
+	k := flags.Int("k", 10, "number of recommendations looked at for each user")
+	neighbours := flags.Int(
+		"neighbours",
+		recommend.DefaultNeighbours,
+		"number of neighbours kept for each liked profile",
+	)
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), evaluateUsage)
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *holdout <= 0 || *holdout >= 1 {
+		return errors.New("the share held out must be between 0 and 1")
+	}
+	if *k < 1 {
+		return errors.New("at least one recommendation must be looked at")
+	}
+
+	history, closeStore, err := openHistory(ctx)
+	if err != nil {
+		return err
+	}
+	defer closeStore()
+
+	var swipes []store.NewSwipe
+	if err := history.ReplaySwipes(ctx, func(swipe store.NewSwipe) error {
+		swipes = append(swipes, swipe)
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	evaluation := recommend.Evaluate(swipes, *holdout, *k, *neighbours)
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "swipes trained on\t%d\n", evaluation.Trained)
+	fmt.Fprintf(tw, "swipes held out\t%d\n", evaluation.HeldOut)
+	fmt.Fprintf(tw, "users evaluated\t%d\n", evaluation.Users)
+	fmt.Fprintf(tw, "precision@%d\t%.4f\n", evaluation.K, evaluation.Precision)
+	fmt.Fprintf(tw, "precision@%d of the most liked\t%.4f\n", evaluation.K, evaluation.Baseline)
+	return tw.Flush()
+}
+
+// openHistory opens the store selected by STORE_BACKEND for its history of
+// swipes.
+func openHistory(ctx context.Context) (recommend.History, func(), error) {
+	// the store is left uninstrumented to keep its backfill methods
+	dataStore, closeStore, err := openStore(ctx, prometheus.NewRegistry(), nil, true)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	history, ok := dataStore.(store.Backfiller)
+	if !ok {
+		closeStore()
+		return nil, nil, fmt.Errorf("the %s store cannot replay swipes", storeBackend())
+	}
+
+	return history, closeStore, nil
+}
+
+// newRecommender returns the recommender serving the model at
+// RECOMMENDATION_MODEL, if any. The file is checked for a new model every
+// RECOMMENDATION_RELOAD_INTERVAL, so that one written by the train command is
+// served without a restart; until a model can be read discovery falls back to
+// distance.
+func newRecommender(ctx context.Context, logger *slog.Logger) *recommend.Recommender {
+	recommender := recommend.NewRecommender(nil)
+
+	path := os.Getenv("RECOMMENDATION_MODEL")
+	if path == "" {
+		return recommender
+	}
+
+	var loaded time.Time
+	reload := func() {
+		info, err := os.Stat(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			return
+		}
+		if err != nil {
+			logger.Error("unable to read the recommendation model", "path", path, "err", err)
+			return
+		}
+		if !info.ModTime().After(loaded) {
+			return
+		}
+
+		model, err := recommend.Load(path)
+		if err != nil {
+			logger.Error("unable to load the recommendation model", "path", path, "err", err)
+			return
+		}
+
+		recommender.Set(model)
+		loaded = info.ModTime()
+		logger.Info(
+			"loaded the recommendation model",
+			"path", path,
+			"trained_at", model.TrainedAt,
+			"swipes", model.Swipes,
+		)
+	}
+
+	reload()
+	if recommender.Model() == nil {
+		logger.Warn("no recommendation model yet, ranking by distance", "path", path)
+	}
+
+	go func() {
+		ticker := time.NewTicker(durationEnv("RECOMMENDATION_RELOAD_INTERVAL", time.Minute))
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				reload()
+			}
+		}
+	}()
+
+	return recommender
+}
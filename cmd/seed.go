@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"time"
+	"tinydates/seed"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const seedUsage = `usage: tinydates seed [flags]
+
+Fills the database of the STORE_BACKEND with generated users and swipes, bulk
+loaded with COPY on Postgres, then rates the desirability of every user. The
+same flags always generate the same data, seed an empty database as the
+generated emails are only unique within a run.
+
+flags:
+`
+
+// runSeed runs the seed subcommand described by seedUsage.
+func runSeed(ctx context.Context, logger *slog.Logger, args []string) error {
+	cfg := seed.DefaultConfig()
+
+	flags := flag.NewFlagSet("seed", flag.ContinueOnError)
+	flags.IntVar(&cfg.Users, "users", cfg.Users, "number of users")
+	flags.IntVar(&cfg.Swipes, "swipes", cfg.Swipes, "number of swipes")
+	flags.Float64Var(
+		&cfg.LikeRatio,
+		"like-ratio",
+		cfg.LikeRatio,
+		"share of favourable swipes",
+	)
+	flags.Float64Var(
+		&cfg.Reciprocity,
+		"reciprocity",
+		cfg.Reciprocity,
+		"chance that a favourable swipe is returned",
+	)
+	flags.IntVar(&cfg.Towns, "towns", cfg.Towns, "number of places users cluster around")
+	flags.IntVar(&cfg.Locations, "locations", cfg.Locations, "number of locations")
+	flags.StringVar(
+		&cfg.Password,
+		"password",
+		cfg.Password,
+		"password of every user, random for each user when empty",
+	)
+	flags.Int64Var(&cfg.Seed, "seed", cfg.Seed, "seed of the generated data")
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), seedUsage)
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	// the database is migrated so that a fresh one can be seeded at once, the
+	// store is left uninstrumented to keep its bulk loading methods
+	dataStore, closeStore, err := openStore(ctx, prometheus.NewRegistry(), nil, true)
+	if err != nil {
+		return err
+	}
+	defer closeStore()
+
+	start := time.Now()
+	if err := seed.Load(ctx, dataStore, seed.New(cfg)); err != nil {
+		return err
+	}
+
+	logger.Info(
+		"seeded the database",
+		"users", cfg.Users,
+		"swipes", cfg.Swipes,
+		"seed", cfg.Seed,
+		"took", time.Since(start),
+	)
+
+	// the swipes are loaded without being rated, they are rated at once
+	return backfillRatings(ctx, logger, dataStore)
+}
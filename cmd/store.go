@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+	"tinydates/metrics"
+	"tinydates/migration"
+	"tinydates/store"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// newStore creates the store selected by STORE_BACKEND, "postgres" by default
+// or "sqlite" for single binary deployments, after migrating its database
+// unless runMigrations is false. The store is instrumented by decoration and
+// the returned function releases the underlying connections.
+func newStore(
+	ctx context.Context,
+	registry *prometheus.Registry,
+	tp trace.TracerProvider,
+	runMigrations bool,
+) (store.Store, func(), error) {
+	dataStore, closer, err := openStore(ctx, registry, tp, runMigrations)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return metrics.NewInstrumentedStore(dataStore, registry), closer, nil
+}
+
+// openStore creates the store selected by STORE_BACKEND as newStore does,
+// without instrumenting it.
+func openStore(
+	ctx context.Context,
+	registry *prometheus.Registry,
+	tp trace.TracerProvider,
+	runMigrations bool,
+) (store.Store, func(), error) {
+	switch backend := storeBackend(); backend {
+	case "postgres":
+		return newPostgresStore(ctx, registry, tp, runMigrations)
+	case "sqlite":
+		return newSqliteStore(runMigrations)
+	default:
+		return nil, nil, fmt.Errorf("unknown store backend %q", backend)
+	}
+}
+
+// storeBackend returns the backend named by STORE_BACKEND, "postgres" when it
+// is unset.
+func storeBackend() string {
+	if backend := os.Getenv("STORE_BACKEND"); backend != "" {
+		return backend
+	}
+
+	return "postgres"
+}
+
+// newPostgresStore connects to POSTGRES_URL, tracing queries when tp is set.
+func newPostgresStore(
+	ctx context.Context,
+	registry *prometheus.Registry,
+	tp trace.TracerProvider,
+	runMigrations bool,
+) (store.Store, func(), error) {
+	dbConfig, err := pgxpool.ParseConfig(os.Getenv("POSTGRES_URL"))
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid database url: %w", err)
+	}
+	if tp != nil {
+		dbConfig.ConnConfig.Tracer = store.NewQueryTracer(tp)
+	}
+
+	dbPool, err := pgxpool.NewWithConfig(ctx, dbConfig)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to connect to the database: %w", err)
+	}
+	registry.MustRegister(metrics.NewPgxPoolCollector(dbPool))
+
+	// run idempotent database migrations, embedded in the binary, at start of
+	// application
+	if runMigrations {
+		if err = withPostgresMigrate(ctx, dbPool, migrateUp); err != nil {
+			dbPool.Close()
+			return nil, nil, fmt.Errorf("failed to run database migration: %w", err)
+		}
+	}
+
+	return store.NewTinydatesPgStore(dbPool), dbPool.Close, nil
+}
+
+// newSqliteStore opens, creating if needed, the database file at SQLITE_PATH
+// and applies the migrations embedded in the binary.
+func newSqliteStore(runMigrations bool) (store.Store, func(), error) {
+	db, err := store.OpenSqlite(os.Getenv("SQLITE_PATH"))
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to open the database: %w", err)
+	}
+
+	if runMigrations {
+		if err = withSqliteMigrate(db, migrateUp); err != nil {
+			db.Close()
+			return nil, nil, fmt.Errorf("failed to run database migration: %w", err)
+		}
+	}
+
+	return store.NewTinydatesSqliteStore(db), func() { db.Close() }, nil
+}
+
+// withPostgresMigrate runs fn with the embedded Postgres migrations while
+// holding the migration lock, so that replicas starting together, or a
+// migrate command, do not race. The lock is waited for MIGRATION_LOCK_TIMEOUT
+// at most.
+func withPostgresMigrate(
+	ctx context.Context,
+	dbPool *pgxpool.Pool,
+	fn func(m *migrate.Migrate) error,
+) error {
+	lockCtx, cancel := context.WithTimeout(
+		ctx,
+		durationEnv("MIGRATION_LOCK_TIMEOUT", time.Minute),
+	)
+	defer cancel()
+
+	unlock, err := migration.LockPostgres(lockCtx, dbPool)
+	if err != nil {
+		return fmt.Errorf("unable to take the migration lock: %w", err)
+	}
+	defer unlock()
+
+	m, err := migration.Postgres(dbPool)
+	if err != nil {
+		return fmt.Errorf("unable to read migrations: %w", err)
+	}
+	defer m.Close()
+
+	return fn(m)
+}
+
+// withSqliteMigrate runs fn with the embedded SQLite migrations. Only one
+// process serves a SQLite database so no lock is needed.
+//
+// Note: the migrate instance is left open as closing it also closes db.
+func withSqliteMigrate(db *sql.DB, fn func(m *migrate.Migrate) error) error {
+	m, err := migration.Sqlite(db)
+	if err != nil {
+		return fmt.Errorf("unable to read migrations: %w", err)
+	}
+
+	return fn(m)
+}
+
+// migrateUp applies every pending migration, having none is not an error.
+func migrateUp(m *migrate.Migrate) error {
+	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return err
+	}
+
+	return nil
+}
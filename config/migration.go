@@ -0,0 +1,35 @@
+package config
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed postgres/migration/*.sql
+var postgresMigrations embed.FS
+
+//go:embed sqlite/migration/*.sql
+var sqliteMigrations embed.FS
+
+// PostgresMigrations returns the Postgres migration files, embedded in the
+// binary so that the service and the tests apply the very same schema.
+func PostgresMigrations() fs.FS {
+	return sub(postgresMigrations, "postgres/migration")
+}
+
+// SqliteMigrations returns the SQLite migration files, embedded in the binary
+// so that a single executable can create its own database.
+func SqliteMigrations() fs.FS {
+	return sub(sqliteMigrations, "sqlite/migration")
+}
+
+// sub returns the directory holding the migration files of an embedded tree.
+func sub(migrations embed.FS, dir string) fs.FS {
+	migrationsDir, err := fs.Sub(migrations, dir)
+	if err != nil {
+		// the directory is embedded at compile time so this cannot happen
+		panic(err)
+	}
+
+	return migrationsDir
+}
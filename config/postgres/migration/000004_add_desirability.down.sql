@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS "users_desirability_idx";
+ALTER TABLE IF EXISTS "users" DROP COLUMN IF EXISTS "desirability";
@@ -0,0 +1,4 @@
+-- desirability is an Elo rating, every user starts at 1500; existing users
+-- are rated by running tinydates backfill-ratings
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "desirability" double precision NOT NULL DEFAULT 1500;
+CREATE INDEX IF NOT EXISTS "users_desirability_idx" ON "users" ("desirability" DESC, "id");
@@ -0,0 +1,2 @@
+-- when the user last swiped, null until they do
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "last_active" timestamptz;
@@ -0,0 +1,11 @@
+-- the discovery preferences of a user, a user without a row has none;
+-- dealbreakers name the preferences, genders, age or distance, that profiles
+-- must meet rather than only be ranked ahead for meeting
+CREATE TABLE IF NOT EXISTS "preferences" (
+    "user_id" bigint PRIMARY KEY,
+    "genders" varchar[] NOT NULL DEFAULT '{}',
+    "min_age" integer,
+    "max_age" integer,
+    "max_distance" integer,
+    "dealbreakers" varchar[] NOT NULL DEFAULT '{}'
+);
@@ -0,0 +1 @@
+ALTER TABLE IF EXISTS "preferences" DROP COLUMN IF EXISTS "show_outside_preferences";
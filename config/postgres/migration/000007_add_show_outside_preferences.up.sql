@@ -0,0 +1,2 @@
+-- whether a user is still discovered by the users their preferences exclude
+ALTER TABLE "preferences" ADD COLUMN IF NOT EXISTS "show_outside_preferences" boolean NOT NULL DEFAULT false;
@@ -0,0 +1,35 @@
+-- the pronouns of a user, empty until they pick them
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "pronouns" varchar NOT NULL DEFAULT '';
+
+-- genders were free text, they are normalised to the ids of the default
+-- taxonomy ignoring case, spaces, hyphens and underscores; anything else
+-- becomes other
+CREATE TEMPORARY TABLE IF NOT EXISTS "gender_spellings" (
+    "spelling" varchar PRIMARY KEY,
+    "id" varchar NOT NULL
+) ON COMMIT DROP;
+INSERT INTO "gender_spellings" VALUES
+    ('female', 'female'), ('woman', 'female'), ('f', 'female'), ('w', 'female'),
+    ('male', 'male'), ('man', 'male'), ('m', 'male'),
+    ('nonbinary', 'nonbinary'), ('enby', 'nonbinary'), ('nb', 'nonbinary')
+ON CONFLICT DO NOTHING;
+
+UPDATE "users" SET "gender" = coalesce(
+    (
+        SELECT "id" FROM "gender_spellings"
+        WHERE "spelling" = translate(lower(trim("users"."gender")), ' -_', '')
+    ),
+    'other'
+);
+
+UPDATE "preferences" SET "genders" = ARRAY(
+    SELECT DISTINCT coalesce(
+        (
+            SELECT "id" FROM "gender_spellings"
+            WHERE "spelling" = translate(lower(trim(g)), ' -_', '')
+        ),
+        'other'
+    )
+    FROM unnest("preferences"."genders") AS g
+)
+WHERE cardinality("genders") > 0;
@@ -0,0 +1,5 @@
+-- the age is fixed again to the age of each user today
+ALTER TABLE IF EXISTS "users" ADD COLUMN IF NOT EXISTS "age" integer;
+UPDATE "users" SET "age" = extract(year FROM age("birth_date"))::integer;
+ALTER TABLE IF EXISTS "users" ALTER COLUMN "age" SET NOT NULL;
+ALTER TABLE IF EXISTS "users" DROP COLUMN IF EXISTS "birth_date";
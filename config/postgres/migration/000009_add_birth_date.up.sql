@@ -0,0 +1,9 @@
+-- the age of a user is computed from their birth date when read, so that it
+-- stays correct over time; the ages stored before become birth dates making
+-- each user that age on the day of the migration. Ages under 18 were never
+-- valid and are clamped to 18, so that no user is made underage
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "birth_date" date;
+UPDATE "users" SET "birth_date" = current_date - make_interval(years => greatest("age", 18))
+WHERE "birth_date" IS NULL;
+ALTER TABLE "users" ALTER COLUMN "birth_date" SET NOT NULL;
+ALTER TABLE "users" DROP COLUMN IF EXISTS "age";
@@ -0,0 +1,12 @@
+-- the photos of a user, shown in the order of their position; the images are
+-- held by a blob store under key, and thumbnail_key for the square thumbnail
+CREATE TABLE IF NOT EXISTS "photos" (
+    "id" bigserial PRIMARY KEY,
+    "user_id" bigint NOT NULL,
+    "position" integer NOT NULL,
+    "key" varchar NOT NULL,
+    "thumbnail_key" varchar NOT NULL,
+    "width" integer NOT NULL,
+    "height" integer NOT NULL
+);
+CREATE INDEX IF NOT EXISTS "photos_user_id_idx" ON "photos" ("user_id", "position");
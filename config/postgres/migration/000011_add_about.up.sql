@@ -0,0 +1,17 @@
+-- what a user tells about themselves: a bio, empty until they write one, the
+-- prompts they answered in the order of their position and their interests,
+-- ids of the interest taxonomy indexed by interest to count those shared
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "bio" varchar NOT NULL DEFAULT '';
+CREATE TABLE IF NOT EXISTS "prompt_answers" (
+    "user_id" bigint NOT NULL,
+    "prompt" varchar NOT NULL,
+    "position" integer NOT NULL,
+    "answer" varchar NOT NULL,
+    PRIMARY KEY ("user_id", "prompt")
+);
+CREATE TABLE IF NOT EXISTS "interests" (
+    "user_id" bigint NOT NULL,
+    "interest" varchar NOT NULL,
+    PRIMARY KEY ("user_id", "interest")
+);
+CREATE INDEX IF NOT EXISTS "interests_interest_idx" ON "interests" ("interest", "user_id");
@@ -0,0 +1,10 @@
+-- idempotent table creation; AUTOINCREMENT never reuses ids, like bigserial
+CREATE TABLE IF NOT EXISTS "users" (
+    "id" INTEGER PRIMARY KEY AUTOINCREMENT,
+    "email" TEXT NOT NULL,
+    "password" TEXT NOT NULL,
+    "name" TEXT NOT NULL,
+    "gender" TEXT NOT NULL,
+    "age" INTEGER NOT NULL,
+    UNIQUE(id, email)
+);
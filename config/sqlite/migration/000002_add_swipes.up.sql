@@ -0,0 +1,7 @@
+-- for simplicity not dealing with foreign key constraints
+CREATE TABLE IF NOT EXISTS "swipes" (
+    "id" INTEGER PRIMARY KEY AUTOINCREMENT,
+    "swiper" INTEGER NOT NULL,
+    "swipee" INTEGER NOT NULL,
+    "decision" BOOLEAN NOT NULL
+);
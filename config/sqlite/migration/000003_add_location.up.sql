@@ -0,0 +1 @@
+ALTER TABLE "users" ADD COLUMN "location" INTEGER NOT NULL DEFAULT 0;
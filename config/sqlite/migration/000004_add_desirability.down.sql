@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS "users_desirability_idx";
+ALTER TABLE "users" DROP COLUMN "desirability";
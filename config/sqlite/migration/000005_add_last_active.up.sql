@@ -0,0 +1,2 @@
+-- when the user last swiped, null until they do
+ALTER TABLE "users" ADD COLUMN "last_active" TIMESTAMP;
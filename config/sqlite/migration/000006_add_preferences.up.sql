@@ -0,0 +1,12 @@
+-- the discovery preferences of a user, a user without a row has none;
+-- dealbreakers name the preferences, genders, age or distance, that profiles
+-- must meet rather than only be ranked ahead for meeting. Lists are held as
+-- JSON arrays
+CREATE TABLE IF NOT EXISTS "preferences" (
+    "user_id" INTEGER PRIMARY KEY,
+    "genders" TEXT NOT NULL DEFAULT '[]',
+    "min_age" INTEGER,
+    "max_age" INTEGER,
+    "max_distance" INTEGER,
+    "dealbreakers" TEXT NOT NULL DEFAULT '[]'
+);
@@ -0,0 +1 @@
+ALTER TABLE "preferences" DROP COLUMN "show_outside_preferences";
@@ -0,0 +1,2 @@
+-- whether a user is still discovered by the users their preferences exclude
+ALTER TABLE "preferences" ADD COLUMN "show_outside_preferences" BOOLEAN NOT NULL DEFAULT false;
@@ -0,0 +1,2 @@
+-- the genders normalised on the way up are kept, they were valid free text
+ALTER TABLE "users" DROP COLUMN "pronouns";
@@ -0,0 +1,33 @@
+-- the pronouns of a user, empty until they pick them
+ALTER TABLE "users" ADD COLUMN "pronouns" TEXT NOT NULL DEFAULT '';
+
+-- genders were free text, they are normalised to the ids of the default
+-- taxonomy ignoring case, spaces, hyphens and underscores; anything else
+-- becomes other
+CREATE TEMP TABLE "gender_spellings" ("spelling" TEXT PRIMARY KEY, "id" TEXT NOT NULL);
+INSERT INTO "gender_spellings" VALUES
+    ('female', 'female'), ('woman', 'female'), ('f', 'female'), ('w', 'female'),
+    ('male', 'male'), ('man', 'male'), ('m', 'male'),
+    ('nonbinary', 'nonbinary'), ('enby', 'nonbinary'), ('nb', 'nonbinary');
+
+UPDATE "users" SET "gender" = coalesce(
+    (
+        SELECT "id" FROM "gender_spellings"
+        WHERE "spelling" = replace(replace(replace(lower(trim("users"."gender")), ' ', ''), '-', ''), '_', '')
+    ),
+    'other'
+);
+
+UPDATE "preferences" SET "genders" = (
+    SELECT json_group_array(DISTINCT coalesce(
+        (
+            SELECT "id" FROM "gender_spellings"
+            WHERE "spelling" = replace(replace(replace(lower(trim(g.value)), ' ', ''), '-', ''), '_', '')
+        ),
+        'other'
+    ))
+    FROM json_each("preferences"."genders") AS g
+)
+WHERE json_array_length("genders") > 0;
+
+DROP TABLE "gender_spellings";
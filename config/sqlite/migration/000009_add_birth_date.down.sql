@@ -0,0 +1,28 @@
+-- the age is fixed again to the age of each user today, rebuilding the table
+-- as the birth date was added
+CREATE TABLE "users_age" (
+    "id" INTEGER PRIMARY KEY AUTOINCREMENT,
+    "email" TEXT NOT NULL,
+    "password" TEXT NOT NULL,
+    "name" TEXT NOT NULL,
+    "gender" TEXT NOT NULL,
+    "age" INTEGER NOT NULL,
+    "location" INTEGER NOT NULL DEFAULT 0,
+    "desirability" REAL NOT NULL DEFAULT 1500,
+    "last_active" TIMESTAMP,
+    "pronouns" TEXT NOT NULL DEFAULT '',
+    UNIQUE(id, email)
+);
+INSERT INTO "users_age" (
+    "id", "email", "password", "name", "gender", "age", "location",
+    "desirability", "last_active", "pronouns"
+)
+SELECT
+    "id", "email", "password", "name", "gender",
+    CAST(strftime('%Y', 'now') AS INTEGER) - CAST(strftime('%Y', "birth_date") AS INTEGER)
+        - (strftime('%m-%d', 'now') < strftime('%m-%d', "birth_date")),
+    "location", "desirability", "last_active", "pronouns"
+FROM "users";
+DROP TABLE "users";
+ALTER TABLE "users_age" RENAME TO "users";
+CREATE INDEX IF NOT EXISTS "users_desirability_idx" ON "users" ("desirability" DESC, "id");
@@ -0,0 +1,30 @@
+-- the age of a user is computed from their birth date, a YYYY-MM-DD date,
+-- when read so that it stays correct over time; the ages stored before become
+-- birth dates making each user that age on the day of the migration. Ages under
+-- 18 were never valid and are clamped to 18, so that no user is made underage.
+-- SQLite cannot add a column without a default as NOT NULL, so the table is
+-- rebuilt
+CREATE TABLE "users_birth_date" (
+    "id" INTEGER PRIMARY KEY AUTOINCREMENT,
+    "email" TEXT NOT NULL,
+    "password" TEXT NOT NULL,
+    "name" TEXT NOT NULL,
+    "gender" TEXT NOT NULL,
+    "birth_date" TEXT NOT NULL,
+    "location" INTEGER NOT NULL DEFAULT 0,
+    "desirability" REAL NOT NULL DEFAULT 1500,
+    "last_active" TIMESTAMP,
+    "pronouns" TEXT NOT NULL DEFAULT '',
+    UNIQUE(id, email)
+);
+INSERT INTO "users_birth_date" (
+    "id", "email", "password", "name", "gender", "birth_date", "location",
+    "desirability", "last_active", "pronouns"
+)
+SELECT
+    "id", "email", "password", "name", "gender", date('now', '-' || max("age", 18) || ' years'),
+    "location", "desirability", "last_active", "pronouns"
+FROM "users";
+DROP TABLE "users";
+ALTER TABLE "users_birth_date" RENAME TO "users";
+CREATE INDEX IF NOT EXISTS "users_desirability_idx" ON "users" ("desirability" DESC, "id");
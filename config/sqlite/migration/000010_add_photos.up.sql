@@ -0,0 +1,12 @@
+-- the photos of a user, shown in the order of their position; the images are
+-- held by a blob store under key, and thumbnail_key for the square thumbnail
+CREATE TABLE IF NOT EXISTS "photos" (
+    "id" INTEGER PRIMARY KEY AUTOINCREMENT,
+    "user_id" INTEGER NOT NULL,
+    "position" INTEGER NOT NULL,
+    "key" TEXT NOT NULL,
+    "thumbnail_key" TEXT NOT NULL,
+    "width" INTEGER NOT NULL,
+    "height" INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS "photos_user_id_idx" ON "photos" ("user_id", "position");
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS "interests";
+DROP TABLE IF EXISTS "prompt_answers";
+ALTER TABLE "users" DROP COLUMN "bio";
@@ -0,0 +1,17 @@
+-- what a user tells about themselves: a bio, empty until they write one, the
+-- prompts they answered in the order of their position and their interests,
+-- ids of the interest taxonomy indexed by interest to count those shared
+ALTER TABLE "users" ADD COLUMN "bio" TEXT NOT NULL DEFAULT '';
+CREATE TABLE IF NOT EXISTS "prompt_answers" (
+    "user_id" INTEGER NOT NULL,
+    "prompt" TEXT NOT NULL,
+    "position" INTEGER NOT NULL,
+    "answer" TEXT NOT NULL,
+    PRIMARY KEY ("user_id", "prompt")
+);
+CREATE TABLE IF NOT EXISTS "interests" (
+    "user_id" INTEGER NOT NULL,
+    "interest" TEXT NOT NULL,
+    PRIMARY KEY ("user_id", "interest")
+);
+CREATE INDEX IF NOT EXISTS "interests_interest_idx" ON "interests" ("interest", "user_id");
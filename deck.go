@@ -0,0 +1,289 @@
+package tinydates
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+	"tinydates/cache"
+	"tinydates/ranking"
+	"tinydates/store"
+)
+
+// refillTimeout bounds a refill of a deck, which outlives the request that
+// started it.
+const refillTimeout = 30 * time.Second
+
+// DeckConfig configures the decks discovery is served from. The profiles
+// ranked for a user are precomputed into a deck held in the cache, then
+// served a page at a time, each page following the last, until they run low.
+type DeckConfig struct {
+	// Size is the most profiles held by a deck, besides those already
+	// served.
+	Size int
+
+	// PageSize is the number of profiles served when no limit is asked for.
+	PageSize int
+
+	// Refill is the number of profiles left in a deck below which it is
+	// refilled in the background.
+	Refill int
+
+	// TTL is how long a deck is kept after it was built, last served from or
+	// swiped on, new profiles only appear in a deck once it is rebuilt.
+	TTL time.Duration
+}
+
+// DefaultDeckConfig returns the configuration used for the decks when no
+// other is supplied.
+func DefaultDeckConfig() DeckConfig {
+	return DeckConfig{
+		Size:     500,
+		PageSize: 20,
+		Refill:   50,
+		TTL:      time.Hour,
+	}
+}
+
+// WithDecks serves discovery from decks held in the cache rather than
+// ranking every profile on every call. Strategies revisiting swiped profiles
+// are still ranked afresh.
+func WithDecks(cfg DeckConfig) Option {
+	return func(td *tinydates) {
+		td.decks = &decks{DeckConfig: cfg, refilling: make(map[int]bool)}
+	}
+}
+
+// decks tracks the decks being refilled, so that only one refill runs at a
+// time for each user.
+type decks struct {
+	DeckConfig
+
+	mu        sync.Mutex
+	refilling map[int]bool
+}
+
+// begin reports whether a refill of the deck of the user with the supplied
+// id may start, end must be called once it is done.
+func (d *decks) begin(id int) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.refilling[id] {
+		return false
+	}
+
+	d.refilling[id] = true
+	return true
+}
+
+func (d *decks) end(id int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	delete(d.refilling, id)
+}
+
+// deckKey identifies a deck by what it was built for: the strategy and the
+// version of its ranker, the filters and preferences applied and the location
+// of the user. A deck is rebuilt when any of them changes, as when the user
+// moves, sets other preferences or the weights or model are reloaded.
+func deckKey(strategyName string, strategy ranking.Strategy, d discovery, location int) string {
+	if strategyName == "" {
+		strategyName = ranking.Distance
+	}
+	if versioned, ok := strategy.Ranker.(ranking.Versioned); ok {
+		strategyName = fmt.Sprintf("%s@%s", strategyName, versioned.Version())
+	}
+
+	return fmt.Sprintf(
+		"%s;location=%d;required{%s};preferred{%s}",
+		strategyName,
+		location,
+		d.required,
+		d.preferred,
+	)
+}
+
+// discoverFromDeck serves the page of the deck of viewer following the last
+// one served, building the deck when there is none for this discovery or
+// every profile of it was served, and refilling it in the background when it
+// runs low.
+func (td tinydates) discoverFromDeck(
+	ctx context.Context,
+	viewer store.PotentialMatch,
+	strategyName string,
+	strategy ranking.Strategy,
+	d discovery,
+	limit int,
+) (DiscoverResponse, error) {
+	key := deckKey(strategyName, strategy, d, viewer.Location)
+
+	deck, ok, err := td.cache.Deck(ctx, viewer.Id)
+	if err != nil {
+		// an unavailable cache only costs the precomputation
+		td.logger.WarnContext(ctx, "failed to get deck", "id", viewer.Id, "err", err)
+	}
+
+	// once every profile was served the deck starts over, with those not
+	// swiped since ranked among the others again
+	if !ok || deck.Key != key || deck.Served >= len(deck.Ids) {
+		deck, err = td.buildDeck(ctx, viewer, strategyName, strategy, d, key, nil)
+		if err != nil {
+			return DiscoverResponse{}, err
+		}
+	}
+
+	if limit == 0 {
+		limit = td.decks.PageSize
+	}
+	page := deck.Ids[deck.Served:min(deck.Served+limit, len(deck.Ids))]
+	served := deck.Ids[:deck.Served+len(page)]
+
+	if len(page) > 0 {
+		err := td.cache.Served(ctx, viewer.Id, page[len(page)-1], td.decks.TTL)
+		if err != nil {
+			// the page is served again by the next call
+			td.logger.WarnContext(ctx, "failed to record page served", "id", viewer.Id, "err", err)
+		}
+	}
+	if len(deck.Ids)-len(served) < td.decks.Refill && !deck.Complete {
+		td.refillDeck(ctx, viewer, strategyName, strategy, d, key, served)
+	}
+
+	profiles, err := td.store.GetProfiles(ctx, viewer.Id, page)
+	if err != nil {
+		td.logger.ErrorContext(ctx, "failed to get profiles", "id", viewer.Id, "err", err)
+		return DiscoverResponse{}, storeError(err, ErrInternalService)
+	}
+
+	// the profiles are read in no particular order, they are served in the
+	// order of the deck
+	position := make(map[int]int, len(page))
+	for i, id := range page {
+		position[id] = i
+	}
+	sort.Slice(profiles, func(i, j int) bool {
+		return position[profiles[i].Id] < position[profiles[j].Id]
+	})
+
+	candidates := ranking.Extract(viewer, profiles, time.Now())
+
+	return DiscoverResponse{
+		Results: discovered(candidates, td.discoveredPhotos(ctx, candidates)),
+	}, nil
+}
+
+// buildDeck ranks the profiles for viewer into a new deck, stored in the
+// cache for the pages to come. The profiles already served, those of served
+// and any served since from the deck being refilled, are kept at its front,
+// served, rather than ranked again.
+func (td tinydates) buildDeck(
+	ctx context.Context,
+	viewer store.PotentialMatch,
+	strategyName string,
+	strategy ranking.Strategy,
+	d discovery,
+	key string,
+	served []int,
+) (cache.Deck, error) {
+	candidates, err := td.rank(ctx, viewer, strategyName, strategy, d)
+	if err != nil {
+		return cache.Deck{}, err
+	}
+
+	if len(served) > 0 {
+		// the pages served while ranking are not to be served again, nor
+		// the deck to go back to before them
+		served = td.servedSince(ctx, viewer.Id, key, served)
+		candidates = slices.DeleteFunc(candidates, func(candidate ranking.Candidate) bool {
+			return slices.Contains(served, candidate.Id)
+		})
+	}
+
+	deck := cache.Deck{
+		Key:      key,
+		Ids:      make([]int, 0, len(served)+min(len(candidates), td.decks.Size)),
+		Served:   len(served),
+		Complete: len(candidates) <= td.decks.Size,
+	}
+	deck.Ids = append(deck.Ids, served...)
+	for _, candidate := range candidates[:min(len(candidates), td.decks.Size)] {
+		deck.Ids = append(deck.Ids, candidate.Id)
+	}
+
+	// the deck is still served this time when it cannot be stored
+	if err := td.cache.SetDeck(ctx, viewer.Id, deck, td.decks.TTL); err != nil {
+		td.logger.WarnContext(ctx, "failed to set deck", "id", viewer.Id, "err", err)
+	}
+
+	return deck, nil
+}
+
+// servedSince returns served followed by the profiles of the deck of the user
+// with the supplied id served after them, when the deck is still the one
+// keyed by key.
+func (td tinydates) servedSince(ctx context.Context, id int, key string, served []int) []int {
+	deck, ok, err := td.cache.Deck(ctx, id)
+	if err != nil {
+		td.logger.WarnContext(ctx, "failed to get deck", "id", id, "err", err)
+		return served
+	}
+	if !ok || deck.Key != key {
+		return served
+	}
+
+	since := slices.Clone(served)
+	for _, profile := range deck.Ids[:deck.Served] {
+		if !slices.Contains(served, profile) {
+			since = append(since, profile)
+		}
+	}
+
+	return since
+}
+
+// refillDeck rebuilds the deck of viewer in the background after the
+// profiles already served, unless it is already being refilled.
+func (td tinydates) refillDeck(
+	ctx context.Context,
+	viewer store.PotentialMatch,
+	strategyName string,
+	strategy ranking.Strategy,
+	d discovery,
+	key string,
+	served []int,
+) {
+	if !td.decks.begin(viewer.Id) {
+		return
+	}
+
+	// the refill outlives the request, keeping its values such as the
+	// request id for logging
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), refillTimeout)
+
+	go func() {
+		defer cancel()
+		defer td.decks.end(viewer.Id)
+
+		if _, err := td.buildDeck(ctx, viewer, strategyName, strategy, d, key, served); err != nil {
+			td.logger.WarnContext(ctx, "failed to refill deck", "id", viewer.Id, "err", err)
+		}
+	}()
+}
+
+// forgetSwipee removes swipee from the deck of swiper, dropping the deck
+// altogether should that fail.
+func (td tinydates) forgetSwipee(ctx context.Context, swiper int, swipee int) {
+	err := td.cache.Swiped(ctx, swiper, swipee, td.decks.TTL)
+	if err == nil {
+		return
+	}
+
+	td.logger.WarnContext(ctx, "failed to remove swipee from deck", "id", swiper, "err", err)
+	if err := td.cache.DropDeck(ctx, swiper); err != nil {
+		td.logger.ErrorContext(ctx, "failed to drop deck", "id", swiper, "err", err)
+	}
+}
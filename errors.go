@@ -0,0 +1,315 @@
+package tinydates
+
+import (
+	"errors"
+	"net/http"
+)
+
+// Error is the domain error returned by the service. It carries a stable
+// machine readable code, the HTTP status it maps onto and a message that is
+// safe to show to the user; the underlying cause is kept for logging only.
+type Error struct {
+	Code    string
+	Status  int
+	Message string
+	Err     error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is a domain error with the same code, so that a
+// wrapped copy still matches the sentinel it was created from.
+func (e *Error) Is(target error) bool {
+	var t *Error
+	if !errors.As(target, &t) {
+		return false
+	}
+
+	return t.Code == e.Code
+}
+
+// Wrap returns a copy of e with err attached as its cause.
+func (e *Error) Wrap(err error) *Error {
+	wrapped := *e
+	wrapped.Err = err
+	return &wrapped
+}
+
+var (
+	// ErrCreateUser is returned when there is an error creating a new user
+	ErrCreateUser = &Error{
+		Code:    "create_user_failed",
+		Status:  http.StatusInternalServerError,
+		Message: "error creating new user",
+	}
+
+	// ErrInvalidPassword is returned when supplied and found passwords differs
+	// or no user exists with the supplied email; the two are not told apart
+	ErrInvalidPassword = &Error{
+		Code:    "invalid_credentials",
+		Status:  http.StatusUnauthorized,
+		Message: "error invalid email or password",
+	}
+
+	// ErrUnauthorized is returned when the request is not authorised
+	ErrUnauthorized = &Error{
+		Code:    "unauthorized",
+		Status:  http.StatusUnauthorized,
+		Message: "error unauthorized access",
+	}
+
+	// ErrInternalService is returned when something major has gone wrong
+	ErrInternalService = &Error{
+		Code:    "internal_error",
+		Status:  http.StatusInternalServerError,
+		Message: "error internal service failure",
+	}
+
+	// ErrInvalidRequest is returned when the request could not be understood,
+	// such as a malformed body or header
+	ErrInvalidRequest = &Error{
+		Code:    "invalid_request",
+		Status:  http.StatusBadRequest,
+		Message: "error malformed request",
+	}
+
+	// ErrRouteNotFound is returned when no route matches the request
+	ErrRouteNotFound = &Error{
+		Code:    "not_found",
+		Status:  http.StatusNotFound,
+		Message: "error resource not found",
+	}
+
+	// ErrMinOrMaxAgeMissing is returned when a request is invalid
+	//
+	// Deprecated: age ranges may be open ended, it is no longer returned.
+	ErrMinOrMaxAgeMissing = &Error{
+		Code:    "age_range_incomplete",
+		Status:  http.StatusBadRequest,
+		Message: "error min age or max age not supplied",
+	}
+
+	// ErrMinOrMaxAgeInvalid is returned when the supplied min or max age is not
+	// a positive integer
+	ErrMinOrMaxAgeInvalid = &Error{
+		Code:    "age_range_invalid",
+		Status:  http.StatusBadRequest,
+		Message: "error min age or max age can only be a positive integer",
+	}
+
+	// ErrorMinOrMaxFormat is returned when min age is not less than max age
+	ErrorMinOrMaxFormat = &Error{
+		Code:    "age_range_order",
+		Status:  http.StatusBadRequest,
+		Message: "error min age must be less than max age",
+	}
+
+	// ErrUnknownSort is returned when discovery is asked for a sort strategy
+	// that does not exist
+	ErrUnknownSort = &Error{
+		Code:    "sort_unknown",
+		Status:  http.StatusBadRequest,
+		Message: "error unknown sort",
+	}
+
+	// ErrLimitInvalid is returned when discovery is asked for a number of
+	// profiles that is not a positive integer
+	ErrLimitInvalid = &Error{
+		Code:    "limit_invalid",
+		Status:  http.StatusBadRequest,
+		Message: "error limit can only be a positive integer",
+	}
+
+	// ErrMaxDistanceInvalid is returned when discovery is asked for a max
+	// distance that is not a positive integer
+	ErrMaxDistanceInvalid = &Error{
+		Code:    "distance_invalid",
+		Status:  http.StatusBadRequest,
+		Message: "error max distance can only be a positive integer",
+	}
+
+	// ErrUnknownGender is returned when discovery, the preferences or the
+	// identity of a user name a gender missing from the taxonomy
+	ErrUnknownGender = &Error{
+		Code:    "gender_unknown",
+		Status:  http.StatusBadRequest,
+		Message: "error unknown gender",
+	}
+
+	// ErrUnknownPronouns is returned when the identity of a user names
+	// pronouns missing from the taxonomy
+	ErrUnknownPronouns = &Error{
+		Code:    "pronouns_unknown",
+		Status:  http.StatusBadRequest,
+		Message: "error unknown pronouns",
+	}
+
+	// ErrPreferencesInvalid is returned when the supplied preferences are out
+	// of range, such as a min age above the max age, or name an unknown
+	// dealbreaker
+	ErrPreferencesInvalid = &Error{
+		Code:    "preferences_invalid",
+		Status:  http.StatusBadRequest,
+		Message: "error invalid preferences",
+	}
+
+	// ErrAboutInvalid is returned when what a user tells about themselves is
+	// over the limits, such as too long a bio or too many interests, or
+	// answers a prompt twice
+	ErrAboutInvalid = &Error{
+		Code:    "about_invalid",
+		Status:  http.StatusBadRequest,
+		Message: "error invalid profile",
+	}
+
+	// ErrUnknownPrompt is returned when a user answers a prompt missing from
+	// the catalog
+	ErrUnknownPrompt = &Error{
+		Code:    "prompt_unknown",
+		Status:  http.StatusBadRequest,
+		Message: "error unknown prompt",
+	}
+
+	// ErrUnknownInterest is returned when a user picks an interest missing
+	// from the catalog
+	ErrUnknownInterest = &Error{
+		Code:    "interest_unknown",
+		Status:  http.StatusBadRequest,
+		Message: "error unknown interest",
+	}
+
+	// ErrPhotosUnavailable is returned by the photo endpoints when photos are
+	// not enabled
+	ErrPhotosUnavailable = &Error{
+		Code:    "photos_unavailable",
+		Status:  http.StatusServiceUnavailable,
+		Message: "error photos are unavailable",
+	}
+
+	// ErrPhotoTooLarge is returned when an uploaded photo is over the size
+	// limit
+	ErrPhotoTooLarge = &Error{
+		Code:    "photo_too_large",
+		Status:  http.StatusRequestEntityTooLarge,
+		Message: "error photo too large",
+	}
+
+	// ErrPhotoUnsupported is returned when an uploaded photo is not a JPEG,
+	// PNG or GIF image, whatever it claims to be
+	ErrPhotoUnsupported = &Error{
+		Code:    "photo_type_unsupported",
+		Status:  http.StatusUnsupportedMediaType,
+		Message: "error photo type unsupported",
+	}
+
+	// ErrPhotoInvalid is returned when an uploaded photo cannot be decoded or
+	// its dimensions are out of range
+	ErrPhotoInvalid = &Error{
+		Code:    "photo_invalid",
+		Status:  http.StatusBadRequest,
+		Message: "error invalid photo",
+	}
+
+	// ErrPhotoLimit is returned when a user with the most photos allowed
+	// uploads another
+	ErrPhotoLimit = &Error{
+		Code:    "photo_limit_reached",
+		Status:  http.StatusConflict,
+		Message: "error too many photos",
+	}
+
+	// ErrPhotoOrderInvalid is returned when the photos of a user are
+	// reordered without listing every one of them exactly once
+	ErrPhotoOrderInvalid = &Error{
+		Code:    "photo_order_invalid",
+		Status:  http.StatusBadRequest,
+		Message: "error photo order must list every photo once",
+	}
+
+	// ErrPhotoNotFound is returned when the user has no photo with the
+	// supplied id
+	ErrPhotoNotFound = &Error{
+		Code:    "photo_not_found",
+		Status:  http.StatusNotFound,
+		Message: "error photo not found",
+	}
+
+	// ErrUserNotFound is returned when there is no user with the supplied id
+	ErrUserNotFound = &Error{
+		Code:    "user_not_found",
+		Status:  http.StatusNotFound,
+		Message: "error user not found",
+	}
+
+	// ErrTimeout is returned when the request deadline passed before the data
+	// store could answer
+	ErrTimeout = &Error{
+		Code:    "timeout",
+		Status:  http.StatusGatewayTimeout,
+		Message: "error request timed out",
+	}
+
+	// ErrCanceled is returned when the request was cancelled, usually by the
+	// client disconnecting, before the data store could answer
+	ErrCanceled = &Error{
+		Code:    "canceled",
+		Status:  http.StatusServiceUnavailable,
+		Message: "error request cancelled",
+	}
+)
+
+// errorsByCode indexes every sentinel error by its code.
+var errorsByCode = func() map[string]*Error {
+	byCode := make(map[string]*Error)
+	for _, err := range []*Error{
+		ErrCreateUser,
+		ErrInvalidPassword,
+		ErrUnauthorized,
+		ErrInternalService,
+		ErrInvalidRequest,
+		ErrRouteNotFound,
+		ErrMinOrMaxAgeMissing,
+		ErrMinOrMaxAgeInvalid,
+		ErrorMinOrMaxFormat,
+		ErrUnknownSort,
+		ErrLimitInvalid,
+		ErrMaxDistanceInvalid,
+		ErrUnknownGender,
+		ErrUnknownPronouns,
+		ErrPreferencesInvalid,
+		ErrAboutInvalid,
+		ErrUnknownPrompt,
+		ErrUnknownInterest,
+		ErrPhotosUnavailable,
+		ErrPhotoTooLarge,
+		ErrPhotoUnsupported,
+		ErrPhotoInvalid,
+		ErrPhotoLimit,
+		ErrPhotoOrderInvalid,
+		ErrPhotoNotFound,
+		ErrUserNotFound,
+		ErrTimeout,
+		ErrCanceled,
+	} {
+		byCode[err.Code] = err
+	}
+
+	return byCode
+}()
+
+// ErrorByCode returns the sentinel error with the supplied code, as found in
+// a problem details response, and whether there is one.
+func ErrorByCode(code string) (*Error, bool) {
+	err, ok := errorsByCode[code]
+	return err, ok
+}
@@ -0,0 +1,147 @@
+package grpcapi
+
+import (
+	"context"
+	"log/slog"
+	"time"
+	"tinydates"
+	"tinydates/logging"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// requestIDKey is the metadata key a request id is read from, and echoed back
+// on, mirroring the X-Request-Id header of the HTTP API.
+const requestIDKey = "x-request-id"
+
+// authorizationKey is the metadata key holding the session token.
+const authorizationKey = "authorization"
+
+// requestID interceptor puts the request id supplied by the caller, or a new
+// one, in the context so that every log line of the call carries it.
+func requestID() grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req any,
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (any, error) {
+		id := incoming(ctx, requestIDKey)
+		if id == "" || len(id) > 64 {
+			id = logging.NewRequestID()
+		}
+
+		grpc.SetHeader(ctx, metadata.Pairs(requestIDKey, id))
+		return handler(logging.WithRequestID(ctx, id), req)
+	}
+}
+
+// accessLog interceptor writes a structured log line for every call once it
+// has been handled; server errors are logged at error level.
+func accessLog(logger *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req any,
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (any, error) {
+		start := time.Now()
+
+		resp, err := handler(ctx, req)
+
+		code := status.Code(err)
+		level := slog.LevelInfo
+		switch code {
+		case codes.Internal, codes.Unknown, codes.DataLoss, codes.Unavailable:
+			level = slog.LevelError
+		}
+
+		logger.LogAttrs(
+			ctx,
+			level,
+			"call handled",
+			slog.String("method", info.FullMethod),
+			slog.String("code", code.String()),
+			slog.Duration("latency", time.Since(start)),
+		)
+
+		return resp, err
+	}
+}
+
+// statusErrors interceptor is the single place where errors are turned into
+// gRPC statuses, as problemDetails is for the HTTP API. Errors that are not
+// domain errors are treated as internal failures and their details are never
+// shown to the caller.
+func statusErrors(logger *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req any,
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (any, error) {
+		resp, err := handler(ctx, req)
+		if err == nil {
+			return resp, nil
+		}
+
+		if _, ok := status.FromError(err); ok {
+			return nil, err
+		}
+
+		st := Status(err)
+		if st.Code() == codes.Internal {
+			logger.ErrorContext(ctx, "call failed", "method", info.FullMethod, "err", err)
+		}
+
+		return nil, st.Err()
+	}
+}
+
+// authenticate interceptor rejects calls to the methods requiring a session
+// unless their authorization metadata holds a token auth accepts. The token
+// is kept in the context for the service, which checks it again as it does
+// for the HTTP API.
+func authenticate(
+	auth Authorizer,
+	methods map[string]bool,
+) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req any,
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (any, error) {
+		if !methods[info.FullMethod] {
+			return handler(ctx, req)
+		}
+
+		token := incoming(ctx, authorizationKey)
+		if token == "" || !auth.Authorized(ctx, token) {
+			return nil, tinydates.ErrUnauthorized
+		}
+
+		return handler(context.WithValue(ctx, tokenKey{}, token), req)
+	}
+}
+
+// tokenKey is the context key of the session token of the call.
+type tokenKey struct{}
+
+// token returns the session token authenticate accepted for the call.
+func token(ctx context.Context) string {
+	token, _ := ctx.Value(tokenKey{}).(string)
+	return token
+}
+
+// incoming returns the first value of the named incoming metadata.
+func incoming(ctx context.Context, key string) string {
+	if values := metadata.ValueFromIncomingContext(ctx, key); len(values) > 0 {
+		return values[0]
+	}
+
+	return ""
+}
@@ -0,0 +1,203 @@
+// Package grpcapi serves the tinydates service over gRPC, alongside the HTTP
+// API, with the services defined in proto/tinydates/v1.
+package grpcapi
+
+//go:generate protoc -I ../proto --go_out=. --go_opt=module=tinydates/grpcapi --go-grpc_out=. --go-grpc_opt=module=tinydates/grpcapi tinydates/v1/tinydates.proto
+
+import (
+	"context"
+	"log/slog"
+	"tinydates"
+	"tinydates/grpcapi/tinydatesv1"
+	"tinydates/logging"
+	"tinydates/ranking"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+)
+
+// Authorizer reports whether a session token is valid, cache.Cache satisfies
+// it so that gRPC callers share the sessions started over HTTP.
+type Authorizer interface {
+	Authorized(ctx context.Context, token string) bool
+}
+
+// Config holds the optional collaborators of the gRPC server, the zero value
+// is a valid configuration.
+type Config struct {
+	// Logger receives the access log and server errors, defaults to discard.
+	Logger *slog.Logger
+}
+
+// NewServer creates the gRPC server for svc with the health and reflection
+// services registered next to the tinydates ones. Calls to the discovery and
+// swipe services must carry the token returned by Login as their
+// "authorization" metadata, it is checked against auth before the service is
+// called.
+func NewServer(
+	svc tinydates.Service,
+	auth Authorizer,
+	cfg Config,
+) *grpc.Server {
+	logger := cfg.Logger
+	if logger == nil {
+		logger = logging.Discard()
+	}
+
+	server := grpc.NewServer(grpc.ChainUnaryInterceptor(
+		requestID(),
+		accessLog(logger),
+		statusErrors(logger),
+		authenticate(auth, authenticated),
+	))
+
+	api := &services{svc: svc}
+	tinydatesv1.RegisterUserServiceServer(server, api)
+	tinydatesv1.RegisterAuthServiceServer(server, api)
+	tinydatesv1.RegisterDiscoveryServiceServer(server, api)
+	tinydatesv1.RegisterSwipeServiceServer(server, api)
+
+	// every service is reported as serving for as long as the server runs,
+	// the empty name stands for the server as a whole
+	healthServer := health.NewServer()
+	for name := range server.GetServiceInfo() {
+		healthServer.SetServingStatus(name, grpc_health_v1.HealthCheckResponse_SERVING)
+	}
+	grpc_health_v1.RegisterHealthServer(server, healthServer)
+
+	reflection.Register(server)
+
+	return server
+}
+
+// authenticated are the methods requiring a session.
+var authenticated = map[string]bool{
+	tinydatesv1.DiscoveryService_Discover_FullMethodName: true,
+	tinydatesv1.SwipeService_Swipe_FullMethodName:        true,
+}
+
+// services implements every tinydates gRPC service on top of the tinydates
+// service, converting between the protobuf messages and the service types.
+type services struct {
+	tinydatesv1.UnimplementedUserServiceServer
+	tinydatesv1.UnimplementedAuthServiceServer
+	tinydatesv1.UnimplementedDiscoveryServiceServer
+	tinydatesv1.UnimplementedSwipeServiceServer
+
+	svc tinydates.Service
+}
+
+func (s *services) CreateUser(
+	ctx context.Context,
+	req *tinydatesv1.CreateUserRequest,
+) (*tinydatesv1.CreateUserResponse, error) {
+	user, err := s.svc.CreateUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tinydatesv1.CreateUserResponse{
+		User: &tinydatesv1.User{
+			Id:       int64(user.Id),
+			Email:    user.Email,
+			Password: user.Password,
+			Name:     user.Name,
+			Gender:   user.Gender,
+			Age:      int32(user.Age),
+			Location: int32(user.Location),
+
+			BirthDate: user.BirthDate,
+		},
+	}, nil
+}
+
+func (s *services) Login(
+	ctx context.Context,
+	req *tinydatesv1.LoginRequest,
+) (*tinydatesv1.LoginResponse, error) {
+	response, err := s.svc.Login(ctx, tinydates.LoginRequest{
+		Email:    req.GetEmail(),
+		Password: req.GetPassword(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &tinydatesv1.LoginResponse{Token: response.Token}, nil
+}
+
+func (s *services) Discover(
+	ctx context.Context,
+	req *tinydatesv1.DiscoverRequest,
+) (*tinydatesv1.DiscoverResponse, error) {
+	// order_by_popularity predates sort, it is honoured when no sort is given
+	strategy := req.GetSort()
+	if req.GetOrderByPopularity() && strategy == "" {
+		strategy = ranking.Popularity
+	}
+
+	discoverReq := tinydates.DiscoverRequest{
+		Sort:  strategy,
+		Limit: int(req.GetLimit()),
+	}
+	// a range is always supplied whole over gRPC, the stored preferences
+	// apply otherwise
+	if age := req.GetAge(); age != nil {
+		minAge, maxAge := int(age.GetMin()), int(age.GetMax())
+		discoverReq.MinAge, discoverReq.MaxAge = &minAge, &maxAge
+	}
+
+	response, err := s.svc.Discover(ctx, token(ctx), discoverReq)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*tinydatesv1.DiscoveredUser, 0, len(response.Results))
+	for _, user := range response.Results {
+		photos := make([]*tinydatesv1.Photo, 0, len(user.Photos))
+		for _, photo := range user.Photos {
+			photos = append(photos, &tinydatesv1.Photo{
+				Id:           int64(photo.Id),
+				Url:          photo.Url,
+				ThumbnailUrl: photo.ThumbnailUrl,
+				Width:        int32(photo.Width),
+				Height:       int32(photo.Height),
+			})
+		}
+
+		results = append(results, &tinydatesv1.DiscoveredUser{
+			Id:              int64(user.Id),
+			Name:            user.Name,
+			Gender:          user.Gender,
+			Age:             int32(user.Age),
+			DistanceFromMe:  int32(user.DistanceFromMe),
+			Popularity:      int32(user.Popularity),
+			Pronouns:        user.Pronouns,
+			Photos:          photos,
+			SharedInterests: int32(user.SharedInterests),
+		})
+	}
+
+	return &tinydatesv1.DiscoverResponse{Results: results}, nil
+}
+
+func (s *services) Swipe(
+	ctx context.Context,
+	req *tinydatesv1.SwipeRequest,
+) (*tinydatesv1.SwipeResponse, error) {
+	response, err := s.svc.Swipe(ctx, token(ctx), tinydates.SwipeRequest{
+		SwiperId: int(req.GetSwiperId()),
+		SwipeeId: int(req.GetSwipeeId()),
+		Decision: req.GetDecision(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &tinydatesv1.SwipeResponse{
+		Matched: response.Matched,
+		MatchId: int64(response.MatchId),
+	}, nil
+}
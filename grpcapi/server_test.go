@@ -0,0 +1,193 @@
+package grpcapi_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"tinydates"
+	"tinydates/cache"
+	"tinydates/grpcapi"
+	"tinydates/grpcapi/tinydatesv1"
+	"tinydates/store"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// newConn serves the tinydates service, backed by an empty in memory store
+// and cache, over an in memory connection and returns a client connection to
+// it.
+func newConn(t *testing.T) *grpc.ClientConn {
+	t.Helper()
+
+	sessions := cache.NewTinydatesInMemoryCache(0)
+	svc := tinydates.New(store.NewTinydatesInMemoryStore(), sessions, nil)
+
+	listener := bufconn.Listen(1 << 20)
+	server := grpcapi.NewServer(svc, sessions, grpcapi.Config{})
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient(
+		"passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return conn
+}
+
+func TestCallsAreServedOverGRPC(t *testing.T) {
+	ctx := context.Background()
+	conn := newConn(t)
+
+	users := tinydatesv1.NewUserServiceClient(conn)
+	me, err := users.CreateUser(ctx, &tinydatesv1.CreateUserRequest{})
+	require.NoError(t, err)
+	them, err := users.CreateUser(ctx, &tinydatesv1.CreateUserRequest{})
+	require.NoError(t, err)
+
+	login, err := tinydatesv1.NewAuthServiceClient(conn).Login(
+		ctx,
+		&tinydatesv1.LoginRequest{
+			Email:    me.GetUser().GetEmail(),
+			Password: me.GetUser().GetPassword(),
+		},
+	)
+	require.NoError(t, err)
+	require.NotEmpty(t, login.GetToken())
+
+	authorized := metadata.AppendToOutgoingContext(ctx, "authorization", login.GetToken())
+
+	discovered, err := tinydatesv1.NewDiscoveryServiceClient(conn).Discover(
+		authorized,
+		&tinydatesv1.DiscoverRequest{},
+	)
+	require.NoError(t, err)
+	require.Len(t, discovered.GetResults(), 1)
+	require.Equal(t, them.GetUser().GetId(), discovered.GetResults()[0].GetId())
+
+	swiped, err := tinydatesv1.NewSwipeServiceClient(conn).Swipe(
+		authorized,
+		&tinydatesv1.SwipeRequest{
+			SwiperId: me.GetUser().GetId(),
+			SwipeeId: them.GetUser().GetId(),
+			Decision: true,
+		},
+	)
+	require.NoError(t, err)
+	require.False(t, swiped.GetMatched())
+}
+
+func TestSessionIsRequired(t *testing.T) {
+	ctx := context.Background()
+	conn := newConn(t)
+
+	testCases := []struct {
+		name string
+		ctx  context.Context
+	}{
+		{"no token", ctx},
+		{"unknown token", metadata.AppendToOutgoingContext(ctx, "authorization", "stale")},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := tinydatesv1.NewDiscoveryServiceClient(conn).Discover(
+				tc.ctx,
+				&tinydatesv1.DiscoverRequest{},
+			)
+			require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+			code, ok := grpcapi.ErrorCode(err)
+			require.True(t, ok)
+			require.Equal(t, tinydates.ErrUnauthorized.Code, code)
+		})
+	}
+}
+
+func TestDomainErrorsMapOntoStatusCodes(t *testing.T) {
+	testCases := []struct {
+		err    error
+		code   codes.Code
+		reason string
+	}{
+		{tinydates.ErrInvalidPassword, codes.Unauthenticated, "invalid_credentials"},
+		{tinydates.ErrMinOrMaxAgeInvalid, codes.InvalidArgument, "age_range_invalid"},
+		{tinydates.ErrRouteNotFound, codes.NotFound, "not_found"},
+		{tinydates.ErrTimeout.Wrap(context.DeadlineExceeded), codes.DeadlineExceeded, "timeout"},
+		{tinydates.ErrCanceled.Wrap(context.Canceled), codes.Canceled, "canceled"},
+		{tinydates.ErrCreateUser, codes.Internal, "create_user_failed"},
+		{errors.New("connection refused by 10.0.0.1"), codes.Internal, "internal_error"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.reason, func(t *testing.T) {
+			err := grpcapi.Status(tc.err).Err()
+
+			require.Equal(t, tc.code, status.Code(err))
+			require.NotContains(t, err.Error(), "10.0.0.1")
+
+			reason, ok := grpcapi.ErrorCode(err)
+			require.True(t, ok)
+			require.Equal(t, tc.reason, reason)
+		})
+	}
+}
+
+func TestHealthAndReflectionAreServed(t *testing.T) {
+	ctx := context.Background()
+	conn := newConn(t)
+
+	health := grpc_health_v1.NewHealthClient(conn)
+	for _, service := range []string{"", "tinydates.v1.DiscoveryService"} {
+		resp, err := health.Check(ctx, &grpc_health_v1.HealthCheckRequest{Service: service})
+		require.NoError(t, err)
+		require.Equal(t, grpc_health_v1.HealthCheckResponse_SERVING, resp.GetStatus())
+	}
+
+	stream, err := grpc_reflection_v1.NewServerReflectionClient(conn).ServerReflectionInfo(ctx)
+	require.NoError(t, err)
+	require.NoError(t, stream.Send(&grpc_reflection_v1.ServerReflectionRequest{
+		MessageRequest: &grpc_reflection_v1.ServerReflectionRequest_ListServices{},
+	}))
+	resp, err := stream.Recv()
+	require.NoError(t, err)
+
+	var services []string
+	for _, service := range resp.GetListServicesResponse().GetService() {
+		services = append(services, service.GetName())
+	}
+	require.Subset(t, services, []string{
+		"tinydates.v1.UserService",
+		"tinydates.v1.AuthService",
+		"tinydates.v1.DiscoveryService",
+		"tinydates.v1.SwipeService",
+	})
+}
+
+func TestRequestIDIsEchoed(t *testing.T) {
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-request-id", "abc")
+	conn := newConn(t)
+
+	var header metadata.MD
+	_, err := tinydatesv1.NewUserServiceClient(conn).CreateUser(
+		ctx,
+		&tinydatesv1.CreateUserRequest{},
+		grpc.Header(&header),
+	)
+	require.NoError(t, err)
+	require.Equal(t, []string{"abc"}, header.Get("x-request-id"))
+}
@@ -0,0 +1,78 @@
+package grpcapi
+
+import (
+	"errors"
+	"net/http"
+	"tinydates"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// errorDomain is the domain of the ErrorInfo detail attached to statuses.
+const errorDomain = "tinydates"
+
+// Status converts err into the gRPC status answered to the caller. Domain
+// errors keep their message and carry their code as the reason of an
+// ErrorInfo detail, so callers can tell them apart as HTTP callers do with the
+// code of a problem details body; anything else is an internal failure.
+func Status(err error) *status.Status {
+	var domainErr *tinydates.Error
+	if !errors.As(err, &domainErr) {
+		domainErr = tinydates.ErrInternalService.Wrap(err)
+	}
+
+	st := status.New(code(domainErr), domainErr.Message)
+	if detailed, err := st.WithDetails(&errdetails.ErrorInfo{
+		Reason: domainErr.Code,
+		Domain: errorDomain,
+	}); err == nil {
+		st = detailed
+	}
+
+	return st
+}
+
+// code maps a domain error onto the gRPC code closest to its HTTP status.
+func code(err *tinydates.Error) codes.Code {
+	if errors.Is(err, tinydates.ErrCanceled) {
+		return codes.Canceled
+	}
+
+	switch err.Status {
+	case http.StatusBadRequest:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusConflict:
+		return codes.AlreadyExists
+	case http.StatusGatewayTimeout:
+		return codes.DeadlineExceeded
+	case http.StatusServiceUnavailable:
+		return codes.Unavailable
+	default:
+		return codes.Internal
+	}
+}
+
+// ErrorCode returns the tinydates error code carried by the status of err,
+// and whether there is one.
+func ErrorCode(err error) (string, bool) {
+	st, ok := status.FromError(err)
+	if !ok {
+		return "", false
+	}
+
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok && info.Domain == errorDomain {
+			return info.Reason, true
+		}
+	}
+
+	return "", false
+}
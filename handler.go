@@ -2,176 +2,564 @@ package tinydates
 
 import (
 	"context"
+	"errors"
+	"log/slog"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
+	"tinydates/logging"
+	"tinydates/metrics"
+	"tinydates/openapi"
+	"tinydates/ranking"
+	"tinydates/tracing"
 
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"go.opentelemetry.io/otel/trace"
 )
 
-func NewTinydatesHandler(ctx context.Context, svc Service) http.Handler {
-	handler := gin.Default()
+// requestIDHeader is the header a request id is read from, and echoed back
+// on, so that calls can be correlated across services and the logs.
+const requestIDHeader = "X-Request-Id"
 
-	handler.Use(contentTypeJSON())
+// maxUploadBytes bounds the body of a photo upload, well above the size limit
+// of the photos themselves which the service applies.
+const maxUploadBytes = 32 << 20
 
-	handler.GET("/user/create", func(c *gin.Context) {
-		user, err := svc.CreateUser(ctx)
-		if err != nil {
-			// an internal server errror must have occured
-			c.JSON(
-				http.StatusInternalServerError,
-				GenericErrResponse{Err: err.Error()},
-			)
-		}
+// HandlerConfig holds the optional collaborators of the HTTP handler, the zero
+// value is a valid configuration.
+type HandlerConfig struct {
+	// Logger receives the access log and handler errors, defaults to discard.
+	Logger *slog.Logger
 
-		c.JSON(http.StatusCreated, user)
-	})
+	// Metrics, when set, records RED metrics for every route and is exposed
+	// on the /metrics endpoint.
+	Metrics *prometheus.Registry
 
-	handler.POST("/login", func(c *gin.Context) {
-		var request LoginRequest
+	// TracerProvider, when set, starts a server span for every request
+	// continuing any W3C trace context supplied by the caller.
+	TracerProvider trace.TracerProvider
 
-		// deserialize JSON POST request into the LoginRequest struct, if
-		// serialization fails return a `GenericErrResponse` to the caller with
-		// the appropriate status code for bad request
-		if err := c.ShouldBindJSON(&request); err != nil {
-			c.JSON(http.StatusBadRequest, GenericErrResponse{Err: err.Error()})
-			return
+	// Timeouts bounds how long a request may take on each route, keyed by
+	// the route path without its version such as "/discover", so that every
+	// version of a route shares the same timeout. Routes without an entry use
+	// DefaultTimeout, no deadline is set when that is zero as well.
+	Timeouts map[string]time.Duration
+
+	// DefaultTimeout applies to routes without an entry in Timeouts.
+	DefaultTimeout time.Duration
+
+	// LegacySunset, when set, is announced in the Sunset header of the
+	// deprecated unversioned routes as the date they stop being served.
+	LegacySunset time.Time
+
+	// Photos, when set, serves the images of the photos on /photos/{key},
+	// as photos.FileStore does for the blobs it holds.
+	Photos http.Handler
+}
+
+// NewTinydatesHandler creates the HTTP handler for svc. Every call into the
+// service is made with the context of the request being handled so that
+// cancellation, deadlines and trace spans flow through to the store.
+func NewTinydatesHandler(svc Service, cfg HandlerConfig) http.Handler {
+	logger := cfg.Logger
+	if logger == nil {
+		logger = logging.Discard()
+	}
+
+	handler := gin.New()
+
+	if cfg.TracerProvider != nil {
+		// first so that the span covers every other middleware and the
+		// request id and access log can be correlated with the trace
+		handler.Use(otelgin.Middleware(
+			tracing.ServiceName,
+			otelgin.WithTracerProvider(cfg.TracerProvider),
+			otelgin.WithPropagators(tracing.Propagator),
+		))
+	}
+
+	handler.Use(gin.Recovery(), requestID(), accessLog(logger))
+
+	if cfg.Metrics != nil {
+		// registered ahead of the middleware below so that scrapes are not
+		// measured and keep their text content type
+		handler.GET("/metrics", gin.WrapH(metrics.Handler(cfg.Metrics)))
+		handler.Use(metrics.Middleware(cfg.Metrics))
+	}
+
+	if cfg.Photos != nil {
+		// registered ahead of the middleware below so that the images keep
+		// their own content type
+		photos := http.StripPrefix("/photos", cfg.Photos)
+		handler.GET("/photos/*key", gin.WrapH(photos))
+	}
+
+	handler.Use(requestTimeout(cfg.Timeouts, cfg.DefaultTimeout))
+	handler.Use(contentTypeJSON(), problemDetails(logger))
+	handler.Use(validateRequest(openapi.Spec()))
+
+	handler.GET("/openapi.json", func(c *gin.Context) {
+		c.Data(http.StatusOK, "application/json", openapi.JSON())
+	})
+
+	// every version of the API is served under its own prefix, the routes
+	// predating versioning remain as deprecated aliases of v1 too
+	versions := apiVersions(svc)
+	for version, routes := range versions {
+		group := handler.Group("/" + version)
+		for _, r := range routes {
+			group.Handle(r.method, r.path, r.handle)
 		}
+	}
 
-		// submit a login request to the service, for simplicity any errors
-		// found from this point is treated like an invalid login attempt
-		loginResponse, err := svc.Login(ctx, request)
-		switch err {
-		case nil:
-		case ErrUnauthorized:
-			c.JSON(
-				http.StatusUnauthorized,
-				GenericErrResponse{Err: err.Error()},
-			)
-			return
-		default:
-			c.JSON(
-				http.StatusInternalServerError,
-				GenericErrResponse{Err: err.Error()},
-			)
-			return
+	legacy := handler.Group("", deprecated(cfg.LegacySunset))
+	for _, r := range versions["v1"] {
+		if legacyPaths[r.path] {
+			legacy.Handle(r.method, r.path, r.handle)
 		}
+	}
 
-		c.JSON(http.StatusCreated, loginResponse)
+	handler.NoRoute(func(c *gin.Context) {
+		c.Error(ErrRouteNotFound)
 	})
 
-	handler.GET("/discover", func(c *gin.Context) {
-		idString := c.GetHeader("Id")
-		token := c.GetHeader("Authorization")
-		minAge, minAgeSupplied := c.GetQuery("minAge")
-		maxAge, maxAgeSupplied := c.GetQuery("maxAge")
-		byPopularity, byPopularitySupplied := c.GetQuery("orderByPopularity")
-
-		id, err := strconv.Atoi(idString)
-		if err != nil {
-			c.JSON(
-				http.StatusBadRequest,
-				GenericErrResponse{Err: err.Error()},
-			)
+	return handler
+}
+
+// requestTimeout middleware sets a deadline on the request context according
+// to the route being served; the service and store observe it through the
+// context they are handed.
+func requestTimeout(
+	timeouts map[string]time.Duration,
+	fallback time.Duration,
+) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		timeout, ok := timeouts[unversioned(c.FullPath())]
+		if !ok {
+			timeout = fallback
+		}
+		if timeout <= 0 {
+			c.Next()
 			return
 		}
 
-		orderByPopularity := false
-		if byPopularitySupplied {
-			orderByPopularity, err = strconv.ParseBool(byPopularity)
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// route is an endpoint of one version of the API.
+type route struct {
+	method string
+	path   string
+	handle gin.HandlerFunc
+}
+
+// apiVersions returns the routes of every version of the API, keyed by the
+// prefix they are served under. A new version starts from the routes of the
+// previous one and replaces those whose contract changes, such as a new
+// response shape, so that the previous version keeps working unchanged.
+func apiVersions(svc Service) map[string][]route {
+	return map[string][]route{
+		"v1": v1Routes(svc),
+	}
+}
+
+// v1Routes are the routes of the first version of the API.
+func v1Routes(svc Service) []route {
+	return []route{
+		{http.MethodGet, "/user/create", func(c *gin.Context) {
+			user, err := svc.CreateUser(c.Request.Context())
+			if err != nil {
+				c.Error(err)
+				return
+			}
+
+			c.JSON(http.StatusCreated, user)
+		}},
+
+		{http.MethodPost, "/login", func(c *gin.Context) {
+			var request LoginRequest
+
+			// deserialize JSON POST request into the LoginRequest struct, if
+			// serialization fails the error middleware answers the caller with
+			// the appropriate status code for bad request
+			if err := c.ShouldBindJSON(&request); err != nil {
+				c.Error(ErrInvalidRequest.Wrap(err))
+				return
+			}
+
+			loginResponse, err := svc.Login(c.Request.Context(), request)
+			if err != nil {
+				c.Error(err)
+				return
+			}
+
+			c.JSON(http.StatusCreated, loginResponse)
+		}},
+
+		{http.MethodGet, "/discover", func(c *gin.Context) {
+			token := c.GetHeader("Authorization")
+			byPopularity, byPopularitySupplied := c.GetQuery("orderByPopularity")
+
+			// orderByPopularity predates sort, it is honoured when no sort is
+			// given
+			strategy := c.Query("sort")
+			if byPopularitySupplied {
+				orderByPopularity, err := strconv.ParseBool(byPopularity)
+				if err != nil {
+					c.Error(ErrInvalidRequest.Wrap(err))
+					return
+				}
+				if orderByPopularity && strategy == "" {
+					strategy = ranking.Popularity
+				}
+			}
+
+			req := DiscoverRequest{
+				Genders: c.QueryArray("gender"),
+				Sort:    strategy,
+			}
+
+			var err error
+			if limitString, ok := c.GetQuery("limit"); ok {
+				if req.Limit, err = strconv.Atoi(limitString); err != nil {
+					c.Error(ErrLimitInvalid.Wrap(err))
+					return
+				}
+			}
+
+			// an age range may be open ended, either end is optional
+			if req.MinAge, err = queryInt(c, "minAge"); err != nil {
+				c.Error(ErrMinOrMaxAgeInvalid.Wrap(err))
+				return
+			}
+			if req.MaxAge, err = queryInt(c, "maxAge"); err != nil {
+				c.Error(ErrMinOrMaxAgeInvalid.Wrap(err))
+				return
+			}
+			if req.MaxDistance, err = queryInt(c, "maxDistance"); err != nil {
+				c.Error(ErrMaxDistanceInvalid.Wrap(err))
+				return
+			}
+
+			users, err := svc.Discover(c.Request.Context(), token, req)
+			if err != nil {
+				c.Error(err)
+				return
+			}
+
+			c.JSON(http.StatusOK, users)
+		}},
+
+		{http.MethodPost, "/swipe", func(c *gin.Context) {
+			var request SwipeRequest
+
+			if err := c.ShouldBindJSON(&request); err != nil {
+				c.Error(ErrInvalidRequest.Wrap(err))
+				return
+			}
+
+			token := c.GetHeader("Authorization")
+
+			response, err := svc.Swipe(c.Request.Context(), token, request)
+			if err != nil {
+				c.Error(err)
+				return
+			}
+
+			c.JSON(http.StatusOK, response)
+		}},
+
+		{http.MethodGet, "/me/preferences", func(c *gin.Context) {
+			token := c.GetHeader("Authorization")
+
+			preferences, err := svc.GetPreferences(c.Request.Context(), token)
+			if err != nil {
+				c.Error(err)
+				return
+			}
+
+			c.JSON(http.StatusOK, preferences)
+		}},
+
+		{http.MethodPut, "/me/preferences", func(c *gin.Context) {
+			var request Preferences
+			if err := c.ShouldBindJSON(&request); err != nil {
+				c.Error(ErrInvalidRequest.Wrap(err))
+				return
+			}
+
+			token := c.GetHeader("Authorization")
+
+			preferences, err := svc.SetPreferences(c.Request.Context(), token, request)
+			if err != nil {
+				c.Error(err)
+				return
+			}
+
+			c.JSON(http.StatusOK, preferences)
+		}},
+
+		{http.MethodGet, "/me/identity", func(c *gin.Context) {
+			token := c.GetHeader("Authorization")
+
+			identity, err := svc.GetIdentity(c.Request.Context(), token)
+			if err != nil {
+				c.Error(err)
+				return
+			}
+
+			c.JSON(http.StatusOK, identity)
+		}},
+
+		{http.MethodPut, "/me/identity", func(c *gin.Context) {
+			var request Identity
+			if err := c.ShouldBindJSON(&request); err != nil {
+				c.Error(ErrInvalidRequest.Wrap(err))
+				return
+			}
+
+			token := c.GetHeader("Authorization")
+
+			identity, err := svc.SetIdentity(c.Request.Context(), token, request)
 			if err != nil {
-				c.JSON(
-					http.StatusBadRequest,
-					GenericErrResponse{Err: err.Error()},
-				)
+				c.Error(err)
 				return
 			}
 
+			c.JSON(http.StatusOK, identity)
+		}},
+
+		{http.MethodGet, "/me/about", func(c *gin.Context) {
+			token := c.GetHeader("Authorization")
+
+			about, err := svc.GetAbout(c.Request.Context(), token)
+			if err != nil {
+				c.Error(err)
+				return
+			}
+
+			c.JSON(http.StatusOK, about)
+		}},
+
+		{http.MethodPut, "/me/about", func(c *gin.Context) {
+			var request About
+			if err := c.ShouldBindJSON(&request); err != nil {
+				c.Error(ErrInvalidRequest.Wrap(err))
+				return
+			}
+
+			token := c.GetHeader("Authorization")
+
+			about, err := svc.SetAbout(c.Request.Context(), token, request)
+			if err != nil {
+				c.Error(err)
+				return
+			}
+
+			c.JSON(http.StatusOK, about)
+		}},
+
+		{http.MethodGet, "/me/photos", func(c *gin.Context) {
+			token := c.GetHeader("Authorization")
+
+			photos, err := svc.GetPhotos(c.Request.Context(), token)
+			if err != nil {
+				c.Error(err)
+				return
+			}
+
+			c.JSON(http.StatusOK, photos)
+		}},
+
+		{http.MethodPost, "/me/photos", func(c *gin.Context) {
+			c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxUploadBytes)
+			header, err := c.FormFile("photo")
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				c.Error(ErrPhotoTooLarge.Wrap(err))
+				return
+			}
+			if err != nil {
+				c.Error(ErrInvalidRequest.Wrap(err))
+				return
+			}
+
+			upload, err := header.Open()
+			if err != nil {
+				c.Error(ErrInvalidRequest.Wrap(err))
+				return
+			}
+			defer upload.Close()
+
+			token := c.GetHeader("Authorization")
+
+			photo, err := svc.AddPhoto(c.Request.Context(), token, upload)
+			if err != nil {
+				c.Error(err)
+				return
+			}
+
+			c.JSON(http.StatusCreated, photo)
+		}},
+
+		{http.MethodPut, "/me/photos/order", func(c *gin.Context) {
+			var request PhotoOrder
+			if err := c.ShouldBindJSON(&request); err != nil {
+				c.Error(ErrInvalidRequest.Wrap(err))
+				return
+			}
+
+			token := c.GetHeader("Authorization")
+
+			photos, err := svc.ReorderPhotos(c.Request.Context(), token, request.Ids)
+			if err != nil {
+				c.Error(err)
+				return
+			}
+
+			c.JSON(http.StatusOK, photos)
+		}},
+
+		{http.MethodDelete, "/me/photos/:photoId", func(c *gin.Context) {
+			photoId, err := strconv.Atoi(c.Param("photoId"))
+			if err != nil {
+				c.Error(ErrPhotoNotFound.Wrap(err))
+				return
+			}
+
+			token := c.GetHeader("Authorization")
+
+			if err := svc.DeletePhoto(c.Request.Context(), token, photoId); err != nil {
+				c.Error(err)
+				return
+			}
+
+			c.Status(http.StatusNoContent)
+		}},
+
+		// the taxonomy is public, it is needed before signing in
+		{http.MethodGet, "/genders", func(c *gin.Context) {
+			c.JSON(http.StatusOK, svc.Taxonomy(c.Request.Context()))
+		}},
+
+		// as is the catalog, the prompts and interests to sign up with
+		{http.MethodGet, "/interests", func(c *gin.Context) {
+			c.JSON(http.StatusOK, svc.Catalog(c.Request.Context()))
+		}},
+	}
+}
+
+// legacyPaths are the routes that predate versioning, only they are served
+// unversioned as well.
+var legacyPaths = map[string]bool{
+	"/user/create": true,
+	"/login":       true,
+	"/discover":    true,
+	"/swipe":       true,
+}
+
+// queryInt parses the integer query parameter name, nil when it is not
+// supplied.
+func queryInt(c *gin.Context, name string) (*int, error) {
+	value, ok := c.GetQuery(name)
+	if !ok {
+		return nil, nil
+	}
+
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		return nil, err
+	}
+
+	return &i, nil
+}
+
+// deprecated middleware marks the responses of the unversioned routes with the
+// Deprecation header, pointing callers at the v1 route replacing them and,
+// when sunset is set, announcing when they go away with the RFC 8594 Sunset
+// header.
+func deprecated(sunset time.Time) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Deprecation", "true")
+		c.Header("Link", "</v1"+c.FullPath()+`>; rel="successor-version"`)
+		if !sunset.IsZero() {
+			c.Header("Sunset", sunset.UTC().Format(http.TimeFormat))
 		}
+		c.Next()
+	}
+}
 
-		users, err := svc.Discover(
-			ctx,
-			id,
-			token,
-			minAge,
-			minAgeSupplied,
-			maxAge,
-			maxAgeSupplied,
-			orderByPopularity,
-		)
-		switch err {
-		case nil:
-		case ErrUnauthorized:
-			c.JSON(
-				http.StatusUnauthorized,
-				GenericErrResponse{Err: err.Error()},
-			)
-			return
-		case ErrUnauthorized:
-			c.JSON(
-				http.StatusUnauthorized,
-				GenericErrResponse{Err: err.Error()},
-			)
-			return
-		case ErrMinOrMaxAgeMissing:
-			c.JSON(
-				http.StatusBadRequest,
-				GenericErrResponse{Err: err.Error()},
-			)
-			return
-		case ErrMinOrMaxAgeInvalid:
-			c.JSON(
-				http.StatusBadRequest,
-				GenericErrResponse{Err: err.Error()},
-			)
-			return
-		case ErrorMinOrMaxFormat:
-			c.JSON(
-				http.StatusBadRequest,
-				GenericErrResponse{Err: err.Error()},
-			)
-			return
-		default:
-			c.JSON(
-				http.StatusInternalServerError,
-				GenericErrResponse{Err: err.Error()},
-			)
-			return
+// unversioned strips the version prefix, such as "/v1", from a route path.
+func unversioned(route string) string {
+	for version := range versionPrefixes {
+		if path, ok := strings.CutPrefix(route, version+"/"); ok {
+			return "/" + path
 		}
+	}
 
-		c.JSON(http.StatusOK, users)
-	})
+	return route
+}
 
-	handler.POST("/swipe", func(c *gin.Context) {
-		var request SwipeRequest
+// versionPrefixes holds the prefix of every version of the API.
+var versionPrefixes = func() map[string]bool {
+	prefixes := make(map[string]bool)
+	for version := range apiVersions(nil) {
+		prefixes["/"+version] = true
+	}
+
+	return prefixes
+}()
 
-		if err := c.ShouldBindJSON(&request); err != nil {
-			c.JSON(http.StatusBadRequest, GenericErrResponse{Err: err.Error()})
+// validateRequest middleware rejects requests that do not match the operation
+// of the OpenAPI document for their route before they reach the handler. The
+// error raised is named by the x-error-code of the invalid parameter, or
+// ErrInvalidRequest.
+func validateRequest(doc *openapi.Document) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		operation, ok := doc.Operation(c.Request.Method, openAPIPath(c.FullPath()))
+		if !ok {
+			c.Next()
 			return
 		}
 
-		token := c.GetHeader("Authorization")
+		if err := doc.ValidateRequest(operation, c.Request); err != nil {
+			domainErr := ErrInvalidRequest
 
-		response, err := svc.Swipe(ctx, token, request)
-		switch err {
-		case nil:
-		case ErrUnauthorized:
-			c.JSON(
-				http.StatusUnauthorized,
-				GenericErrResponse{Err: err.Error()},
-			)
-			return
-		default:
-			c.JSON(
-				http.StatusInternalServerError,
-				GenericErrResponse{Err: err.Error()},
-			)
+			var validationErr *openapi.ValidationError
+			if errors.As(err, &validationErr) {
+				if codeErr, ok := ErrorByCode(validationErr.Code); ok {
+					domainErr = codeErr
+				}
+			}
+
+			c.Error(domainErr.Wrap(err))
+			c.Abort()
 			return
 		}
 
-		c.JSON(http.StatusOK, response)
-	})
+		c.Next()
+	}
+}
 
-	return handler
+// openAPIPath rewrites the gin route path parameters, such as ":id", the
+// OpenAPI way, "{id}".
+func openAPIPath(route string) string {
+	segments := strings.Split(route, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			segments[i] = "{" + segment[1:] + "}"
+		}
+	}
+
+	return strings.Join(segments, "/")
 }
 
 // contentTypeJSON middleware sets the response header to application/json for
@@ -182,3 +570,49 @@ func contentTypeJSON() gin.HandlerFunc {
 		ctx.Next()
 	}
 }
+
+// requestID middleware assigns every request an id, reusing the one supplied
+// by the caller when present, and stores it in the request context.
+func requestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(requestIDHeader)
+		if id == "" || len(id) > 64 {
+			id = logging.NewRequestID()
+		}
+
+		c.Header(requestIDHeader, id)
+		c.Request = c.Request.WithContext(
+			logging.WithRequestID(c.Request.Context(), id),
+		)
+		c.Next()
+	}
+}
+
+// accessLog middleware writes a structured log line for every request once it
+// has been handled; server errors are logged at error level.
+func accessLog(logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		if status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+
+		logger.LogAttrs(
+			c.Request.Context(),
+			level,
+			"request handled",
+			slog.String("method", c.Request.Method),
+			slog.String("route", c.FullPath()),
+			slog.String("path", c.Request.URL.Path),
+			slog.Int("status", status),
+			slog.Duration("latency", time.Since(start)),
+			slog.String("client_ip", c.ClientIP()),
+			slog.String("errors", c.Errors.String()),
+		)
+	}
+}
@@ -0,0 +1,437 @@
+package tinydates
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"tinydates/openapi"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+)
+
+// stubService answers every call successfully, capturing the context it was
+// handed so tests can inspect what flowed through from the handler. When
+// block is set Discover waits for its context to be done instead, and when
+// err is set Swipe fails with it.
+type stubService struct {
+	ctx   context.Context
+	block bool
+	err   error
+}
+
+func (s *stubService) CreateUser(ctx context.Context) (User, error) {
+	s.ctx = ctx
+	return User{Id: 1}, nil
+}
+
+func (s *stubService) Login(
+	ctx context.Context,
+	req LoginRequest,
+) (LoginResponse, error) {
+	s.ctx = ctx
+	return LoginResponse{Token: "token"}, nil
+}
+
+func (s *stubService) Discover(
+	ctx context.Context,
+	token string,
+	req DiscoverRequest,
+) (DiscoverResponse, error) {
+	s.ctx = ctx
+	if s.block {
+		<-ctx.Done()
+		return DiscoverResponse{}, ErrTimeout
+	}
+	return DiscoverResponse{}, nil
+}
+
+func (s *stubService) GetPreferences(
+	ctx context.Context,
+	token string,
+) (Preferences, error) {
+	s.ctx = ctx
+	return Preferences{}, nil
+}
+
+func (s *stubService) SetPreferences(
+	ctx context.Context,
+	token string,
+	preferences Preferences,
+) (Preferences, error) {
+	s.ctx = ctx
+	return preferences, nil
+}
+
+func (s *stubService) Taxonomy(ctx context.Context) Taxonomy {
+	s.ctx = ctx
+	return Taxonomy{}
+}
+
+func (s *stubService) GetIdentity(
+	ctx context.Context,
+	token string,
+) (Identity, error) {
+	s.ctx = ctx
+	return Identity{}, nil
+}
+
+func (s *stubService) SetIdentity(
+	ctx context.Context,
+	token string,
+	identity Identity,
+) (Identity, error) {
+	s.ctx = ctx
+	return identity, nil
+}
+
+func (s *stubService) GetPhotos(
+	ctx context.Context,
+	token string,
+) ([]Photo, error) {
+	s.ctx = ctx
+	return []Photo{}, nil
+}
+
+func (s *stubService) AddPhoto(
+	ctx context.Context,
+	token string,
+	upload io.Reader,
+) (Photo, error) {
+	s.ctx = ctx
+	return Photo{Id: 1}, nil
+}
+
+func (s *stubService) ReorderPhotos(
+	ctx context.Context,
+	token string,
+	photoIds []int,
+) ([]Photo, error) {
+	s.ctx = ctx
+	return []Photo{}, nil
+}
+
+func (s *stubService) DeletePhoto(
+	ctx context.Context,
+	token string,
+	photoId int,
+) error {
+	s.ctx = ctx
+	return nil
+}
+
+func (s *stubService) Catalog(ctx context.Context) Catalog {
+	s.ctx = ctx
+	return Catalog{}
+}
+
+func (s *stubService) GetAbout(
+	ctx context.Context,
+	token string,
+) (About, error) {
+	s.ctx = ctx
+	return About{}, nil
+}
+
+func (s *stubService) SetAbout(
+	ctx context.Context,
+	token string,
+	a About,
+) (About, error) {
+	s.ctx = ctx
+	return a, nil
+}
+
+func (s *stubService) Swipe(
+	ctx context.Context,
+	token string,
+	req SwipeRequest,
+) (SwipeResponse, error) {
+	s.ctx = ctx
+	return SwipeResponse{}, s.err
+}
+
+func TestRouteTimeoutCancelsServiceContext(t *testing.T) {
+	stub := &stubService{block: true}
+	handler := NewTinydatesHandler(stub, HandlerConfig{
+		DefaultTimeout: time.Minute,
+		Timeouts:       map[string]time.Duration{"/discover": 10 * time.Millisecond},
+	})
+
+	// the timeout of a route applies to every version of it
+	for _, path := range []string{"/discover", "/v1/discover"} {
+		req := httptest.NewRequest("GET", path, nil)
+		rec := httptest.NewRecorder()
+
+		start := time.Now()
+		handler.ServeHTTP(rec, req)
+
+		// the per route timeout, not the default, bounded the service call
+		require.Less(t, time.Since(start), time.Minute)
+		require.Equal(t, 504, rec.Code)
+		require.ErrorIs(t, stub.ctx.Err(), context.DeadlineExceeded)
+	}
+}
+
+func TestClientDisconnectCancelsServiceContext(t *testing.T) {
+	stub := &stubService{block: true}
+	handler := NewTinydatesHandler(stub, HandlerConfig{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest("GET", "/discover", nil).WithContext(ctx)
+	rec := httptest.NewRecorder()
+
+	// simulates the client going away while the request is being served
+	time.AfterFunc(10*time.Millisecond, cancel)
+	handler.ServeHTTP(rec, req)
+
+	require.ErrorIs(t, stub.ctx.Err(), context.Canceled)
+}
+
+func TestErrorsAreRenderedAsProblemDetails(t *testing.T) {
+	testCases := []struct {
+		name   string
+		err    error
+		body   string
+		status int
+		code   string
+	}{
+		{
+			"domain error",
+			ErrUnauthorized,
+			`{"swiperId": 1, "swipeeId": 2, "decision": true}`,
+			401,
+			"unauthorized",
+		},
+		{
+			"wrapped domain error",
+			ErrTimeout.Wrap(errors.New("store operation timed out")),
+			`{"swiperId": 1, "swipeeId": 2, "decision": true}`,
+			504,
+			"timeout",
+		},
+		{
+			"unknown error hides its cause",
+			errors.New("connection refused by 10.0.0.1"),
+			`{"swiperId": 1, "swipeeId": 2, "decision": true}`,
+			500,
+			"internal_error",
+		},
+		{
+			"malformed body",
+			nil,
+			`{"swiperId": "one"}`,
+			400,
+			"invalid_request",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := NewTinydatesHandler(&stubService{err: tc.err}, HandlerConfig{})
+
+			req := httptest.NewRequest("POST", "/swipe", strings.NewReader(tc.body))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			var problem ProblemDetails
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&problem))
+			require.Equal(t, tc.status, rec.Code)
+			require.Equal(t, "application/problem+json", rec.Header().Get("Content-Type"))
+			require.Equal(t, tc.status, problem.Status)
+			require.Equal(t, tc.code, problem.Code)
+			require.Equal(t, "/swipe", problem.Instance)
+			require.NotEmpty(t, problem.RequestId)
+			require.NotContains(t, problem.Detail, "10.0.0.1")
+		})
+	}
+}
+
+func TestDomainErrorsMatchTheirSentinel(t *testing.T) {
+	cause := errors.New("no rows")
+	err := error(ErrInternalService.Wrap(cause))
+
+	require.ErrorIs(t, err, ErrInternalService)
+	require.ErrorIs(t, err, cause)
+	require.NotErrorIs(t, err, ErrUnauthorized)
+
+	var domainErr *Error
+	require.ErrorAs(t, err, &domainErr)
+	require.Equal(t, "internal_error", domainErr.Code)
+}
+
+func TestErrorByCode(t *testing.T) {
+	err, ok := ErrorByCode(ErrUnauthorized.Code)
+	require.True(t, ok)
+	require.Same(t, ErrUnauthorized, err)
+
+	_, ok = ErrorByCode("unknown")
+	require.False(t, ok)
+}
+
+func TestRoutesMatchTheOpenAPIDocument(t *testing.T) {
+	handler := NewTinydatesHandler(&stubService{}, HandlerConfig{
+		Metrics: prometheus.NewRegistry(),
+		Photos:  http.NotFoundHandler(),
+	})
+
+	served := make(map[string]bool)
+	for _, route := range handler.(*gin.Engine).Routes() {
+		served[route.Method+" "+openAPIPath(route.Path)] = true
+	}
+
+	documented := make(map[string]bool)
+	for path, item := range openapi.Spec().Paths {
+		for method := range item.Operations() {
+			documented[method+" "+path] = true
+		}
+	}
+
+	require.Equal(t, documented, served)
+}
+
+func TestOpenAPIDocumentIsServed(t *testing.T) {
+	handler := NewTinydatesHandler(&stubService{}, HandlerConfig{})
+
+	req := httptest.NewRequest("GET", "/openapi.json", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	require.Equal(t, 200, rec.Code)
+	require.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	require.JSONEq(t, string(openapi.JSON()), rec.Body.String())
+}
+
+func TestRequestsAreValidatedAgainstTheOpenAPIDocument(t *testing.T) {
+	testCases := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		code   string
+	}{
+		{
+			"query parameter with its own error code",
+			"GET",
+			"/discover?minAge=twenty&maxAge=30",
+			"",
+			"age_range_invalid",
+		},
+		{
+			"open ended age range of the wrong type",
+			"GET",
+			"/v1/discover?maxDistance=far",
+			"",
+			"distance_invalid",
+		},
+		{
+			"query parameter of the wrong type",
+			"GET",
+			"/discover?orderByPopularity=often",
+			"",
+			"invalid_request",
+		},
+		{
+			"missing body",
+			"POST",
+			"/login",
+			"",
+			"invalid_request",
+		},
+		{
+			"missing required property",
+			"POST",
+			"/swipe",
+			`{"swiperId": 1, "swipeeId": 2}`,
+			"invalid_request",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			stub := &stubService{}
+			handler := NewTinydatesHandler(stub, HandlerConfig{})
+
+			req := httptest.NewRequest(tc.method, tc.target, strings.NewReader(tc.body))
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			var problem ProblemDetails
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&problem))
+			require.Equal(t, 400, rec.Code)
+			require.Equal(t, tc.code, problem.Code)
+			// the request never reached the service
+			require.Nil(t, stub.ctx)
+		})
+	}
+}
+
+func TestEveryVersionHonoursItsContract(t *testing.T) {
+	sunset := time.Date(2027, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name       string
+		prefix     string
+		deprecated bool
+	}{
+		{"v1", "/v1", false},
+		{"unversioned aliases of v1", "", true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := NewTinydatesHandler(&stubService{}, HandlerConfig{
+				LegacySunset: sunset,
+			})
+
+			serve := func(method, path, body string, status int, out any) {
+				t.Helper()
+
+				req := httptest.NewRequest(method, tc.prefix+path, strings.NewReader(body))
+				req.Header.Set("Content-Type", "application/json")
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, status, rec.Code, rec.Body.String())
+				require.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+				require.NoError(t, json.NewDecoder(rec.Body).Decode(out))
+
+				if tc.deprecated {
+					require.Equal(t, "true", rec.Header().Get("Deprecation"))
+					require.Equal(t, "Fri, 01 Jan 2027 00:00:00 GMT", rec.Header().Get("Sunset"))
+					require.Equal(
+						t,
+						"</v1"+path+`>; rel="successor-version"`,
+						rec.Header().Get("Link"),
+					)
+				} else {
+					require.Empty(t, rec.Header().Get("Deprecation"))
+					require.Empty(t, rec.Header().Get("Sunset"))
+				}
+			}
+
+			var user User
+			serve("GET", "/user/create", "", 201, &user)
+			require.Equal(t, 1, user.Id)
+
+			var login LoginResponse
+			serve("POST", "/login", `{"email": "a@mail.com", "password": "pw"}`, 201, &login)
+			require.Equal(t, "token", login.Token)
+
+			var discover DiscoverResponse
+			serve("GET", "/discover", "", 200, &discover)
+
+			var swipe SwipeResponse
+			serve("POST", "/swipe", `{"swiperId": 1, "swipeeId": 2, "decision": true}`, 200, &swipe)
+			require.False(t, swipe.Matched)
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package tinydates
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"tinydates/identity"
+	"tinydates/store"
+)
+
+// WithTaxonomy sets the genders and pronouns users may pick from,
+// identity.Default by default.
+func WithTaxonomy(taxonomy *identity.Taxonomy) Option {
+	return func(td *tinydates) {
+		td.taxonomy = taxonomy
+	}
+}
+
+func (td tinydates) Taxonomy(ctx context.Context) Taxonomy {
+	taxonomy := Taxonomy{
+		Genders:  make([]Gender, 0, len(td.taxonomy.Genders)),
+		Pronouns: append([]string{}, td.taxonomy.Pronouns...),
+	}
+	for _, gender := range td.taxonomy.Genders {
+		taxonomy.Genders = append(taxonomy.Genders, Gender{
+			Id:    gender.Id,
+			Label: gender.Label,
+		})
+	}
+
+	return taxonomy
+}
+
+func (td tinydates) GetIdentity(
+	ctx context.Context,
+	token string,
+) (Identity, error) {
+	id, err := td.caller(ctx, token)
+	if err != nil {
+		return Identity{}, err
+	}
+
+	profile, err := td.store.GetProfile(ctx, id)
+	if errors.Is(err, store.ErrNotFound) {
+		return Identity{}, ErrUserNotFound.Wrap(err)
+	}
+	if err != nil {
+		td.logger.ErrorContext(ctx, "failed to get profile", "id", id, "err", err)
+		return Identity{}, storeError(err, ErrInternalService)
+	}
+
+	return Identity{Gender: profile.Gender, Pronouns: profile.Pronouns}, nil
+}
+
+func (td tinydates) SetIdentity(
+	ctx context.Context,
+	token string,
+	identity Identity,
+) (Identity, error) {
+	id, err := td.caller(ctx, token)
+	if err != nil {
+		return Identity{}, err
+	}
+
+	gender, ok := td.taxonomy.Gender(identity.Gender)
+	if !ok {
+		return Identity{}, ErrUnknownGender.Wrap(fmt.Errorf("gender %q", identity.Gender))
+	}
+
+	// pronouns are optional, an empty string clears them
+	var pronouns string
+	if identity.Pronouns != "" {
+		if pronouns, ok = td.taxonomy.Pronoun(identity.Pronouns); !ok {
+			return Identity{}, ErrUnknownPronouns.Wrap(
+				fmt.Errorf("pronouns %q", identity.Pronouns),
+			)
+		}
+	}
+
+	err = td.store.SetIdentity(ctx, id, gender, pronouns)
+	if errors.Is(err, store.ErrNotFound) {
+		return Identity{}, ErrUserNotFound.Wrap(err)
+	}
+	if err != nil {
+		td.logger.ErrorContext(ctx, "failed to set identity", "id", id, "err", err)
+		return Identity{}, storeError(err, ErrInternalService)
+	}
+
+	return Identity{Gender: gender, Pronouns: pronouns}, nil
+}
+
+// genders returns the ids of the genders spelled names, without duplicates,
+// failing on the first gender missing from the taxonomy.
+func (td tinydates) genders(names []string) ([]string, error) {
+	ids := make([]string, 0, len(names))
+	for _, name := range names {
+		id, ok := td.taxonomy.Gender(name)
+		if !ok {
+			return nil, ErrUnknownGender.Wrap(fmt.Errorf("gender %q", name))
+		}
+		ids = append(ids, id)
+	}
+
+	return dedupe(ids), nil
+}
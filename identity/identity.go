@@ -0,0 +1,131 @@
+// Package identity is the taxonomy of the genders and pronouns users describe
+// themselves with. The genders are stored by id, any of their aliases is
+// accepted in their place so that "Woman" or "f" is stored as "female".
+package identity
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"tinydates/spelling"
+)
+
+// Gender is a gender of the taxonomy.
+type Gender struct {
+	// Id is the stable value stored and returned for the gender.
+	Id string `json:"id"`
+
+	// Label is the name shown to users.
+	Label string `json:"label"`
+
+	// Aliases are the other spellings accepted for the gender.
+	Aliases []string `json:"aliases,omitempty"`
+}
+
+// Taxonomy holds the genders and pronouns users may pick from.
+type Taxonomy struct {
+	Genders  []Gender `json:"genders"`
+	Pronouns []string `json:"pronouns"`
+
+	// genders and pronouns index the canonical value by its spellings
+	genders  spelling.Index
+	pronouns spelling.Index
+}
+
+// Default returns the taxonomy used when no other is configured. Its ids are
+// those the existing users are normalised to by the migrations.
+func Default() *Taxonomy {
+	t, err := New(
+		[]Gender{
+			{Id: "female", Label: "Woman", Aliases: []string{"woman", "f", "w"}},
+			{Id: "male", Label: "Man", Aliases: []string{"man", "m"}},
+			{Id: "nonbinary", Label: "Non-binary", Aliases: []string{"enby", "nb"}},
+			{Id: "other", Label: "Other"},
+		},
+		[]string{"she/her", "he/him", "they/them", "she/they", "he/they", "xe/xem"},
+	)
+	if err != nil {
+		panic(err)
+	}
+
+	return t
+}
+
+// New returns the taxonomy of genders and pronouns, failing when a gender has
+// no id or two of them share a spelling.
+func New(genders []Gender, pronouns []string) (*Taxonomy, error) {
+	if len(genders) == 0 {
+		return nil, errors.New("no genders")
+	}
+
+	t := &Taxonomy{
+		Genders:  genders,
+		Pronouns: pronouns,
+		genders:  spelling.New(),
+		pronouns: spelling.New(),
+	}
+
+	for _, gender := range genders {
+		if spelling.Normalise(gender.Id) == "" {
+			return nil, errors.New("gender without id")
+		}
+		if id, ok := t.genders.Lookup(gender.Id); ok && id == gender.Id {
+			return nil, fmt.Errorf("gender %q listed twice", gender.Id)
+		}
+		for _, name := range append([]string{gender.Id}, gender.Aliases...) {
+			if err := t.genders.Add(name, gender.Id); err != nil {
+				return nil, fmt.Errorf("gender %q: %w", gender.Id, err)
+			}
+		}
+	}
+
+	for _, p := range pronouns {
+		if err := t.pronouns.Add(p, p); err != nil {
+			return nil, fmt.Errorf("pronouns %q: %w", p, err)
+		}
+	}
+
+	return t, nil
+}
+
+// ReadTaxonomy reads a taxonomy from a JSON object such as {"genders":
+// [{"id": "female", "label": "Woman", "aliases": ["woman"]}], "pronouns":
+// ["she/her"]}.
+func ReadTaxonomy(r io.Reader) (*Taxonomy, error) {
+	var t Taxonomy
+
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&t); err != nil {
+		return nil, fmt.Errorf("invalid gender taxonomy: %w", err)
+	}
+
+	taxonomy, err := New(t.Genders, t.Pronouns)
+	if err != nil {
+		return nil, fmt.Errorf("invalid gender taxonomy: %w", err)
+	}
+
+	return taxonomy, nil
+}
+
+// Gender returns the id of the gender spelled name, false when there is none.
+func (t *Taxonomy) Gender(name string) (string, bool) {
+	return t.genders.Lookup(name)
+}
+
+// Pronoun returns the pronouns spelled name as listed by the taxonomy, false
+// when they are not.
+func (t *Taxonomy) Pronoun(name string) (string, bool) {
+	return t.pronouns.Lookup(name)
+}
+
+// Ids returns the ids of the genders, in the order of the taxonomy.
+func (t *Taxonomy) Ids() []string {
+	ids := make([]string, 0, len(t.Genders))
+	for _, gender := range t.Genders {
+		ids = append(ids, gender.Id)
+	}
+
+	return ids
+}
@@ -0,0 +1,63 @@
+package identity_test
+
+import (
+	"strings"
+	"testing"
+	"tinydates/identity"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpellingsOfAGender(t *testing.T) {
+	taxonomy := identity.Default()
+
+	for name, want := range map[string]string{
+		"female":     "female",
+		" Woman ":    "female",
+		"F":          "female",
+		"non-binary": "nonbinary",
+		"Non Binary": "nonbinary",
+		"enby":       "nonbinary",
+		"male":       "male",
+	} {
+		id, ok := taxonomy.Gender(name)
+		require.True(t, ok, name)
+		require.Equal(t, want, id, name)
+	}
+
+	_, ok := taxonomy.Gender("unknown")
+	require.False(t, ok)
+
+	pronouns, ok := taxonomy.Pronoun("They / Them")
+	require.True(t, ok)
+	require.Equal(t, "they/them", pronouns)
+
+	require.Equal(t, []string{"female", "male", "nonbinary", "other"}, taxonomy.Ids())
+}
+
+func TestReadTaxonomy(t *testing.T) {
+	taxonomy, err := identity.ReadTaxonomy(strings.NewReader(`{
+		"genders": [
+			{"id": "woman", "label": "Woman", "aliases": ["female"]},
+			{"id": "man", "label": "Man"}
+		],
+		"pronouns": ["she/her"]
+	}`))
+	require.NoError(t, err)
+
+	id, ok := taxonomy.Gender("Female")
+	require.True(t, ok)
+	require.Equal(t, "woman", id)
+
+	for _, invalid := range []string{
+		`not a taxonomy`,
+		`{"genders": []}`,
+		`{"genders": [{"id": ""}]}`,
+		`{"genders": [{"id": "man"}, {"id": "man"}]}`,
+		`{"genders": [{"id": "man"}, {"id": "male", "aliases": ["man"]}]}`,
+		`{"genders": [{"id": "man"}], "colours": []}`,
+	} {
+		_, err := identity.ReadTaxonomy(strings.NewReader(invalid))
+		require.Error(t, err, invalid)
+	}
+}
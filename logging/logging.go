@@ -0,0 +1,121 @@
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Redacted replaces the value of any attribute whose key is considered
+// sensitive before it is written out.
+const Redacted = "[REDACTED]"
+
+// sensitiveKeys are the attribute keys, compared case insensitively, whose
+// values must never reach the logs regardless of where they are logged from.
+var sensitiveKeys = map[string]struct{}{
+	"password":      {},
+	"token":         {},
+	"authorization": {},
+	"secret":        {},
+}
+
+// New creates a structured logger writing to w. The format is either "text" or
+// "json", anything else falls back to json as that is what the log collector
+// expects. Every record is enriched with the request id found in its context
+// and sensitive attributes are redacted.
+func New(w io.Writer, format string, level slog.Level) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: level, ReplaceAttr: redact}
+
+	var handler slog.Handler
+	switch strings.ToLower(format) {
+	case "text":
+		handler = slog.NewTextHandler(w, opts)
+	default:
+		handler = slog.NewJSONHandler(w, opts)
+	}
+
+	return slog.New(contextHandler{handler})
+}
+
+// Discard returns a logger that drops everything written to it, useful as a
+// default when no logger has been supplied.
+func Discard() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+// ParseLevel converts a level name such as "debug" or "warn" into its slog
+// level, defaulting to info when the name is not recognised.
+func ParseLevel(name string) slog.Level {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(name)); err != nil {
+		return slog.LevelInfo
+	}
+
+	return level
+}
+
+// redact is the slog.HandlerOptions.ReplaceAttr hook that masks the values of
+// sensitive attributes, including those nested inside groups.
+func redact(groups []string, attr slog.Attr) slog.Attr {
+	if _, sensitive := sensitiveKeys[strings.ToLower(attr.Key)]; sensitive {
+		return slog.String(attr.Key, Redacted)
+	}
+
+	return attr
+}
+
+type requestIDKey struct{}
+
+// WithRequestID returns a copy of ctx carrying the supplied request id.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the request id stored in ctx, or an empty string if there
+// is none.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// NewRequestID generates a random 128 bit request id encoded as hex.
+func NewRequestID() string {
+	b := make([]byte, 16)
+	// crypto/rand never returns an error on the supported platforms
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// contextHandler decorates a slog.Handler adding the request id, and trace
+// and span ids when tracing, held in the context of each record so callers
+// only need to use the *Context methods.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		record.AddAttrs(slog.String("request_id", id))
+	}
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		record.AddAttrs(
+			slog.String("trace_id", spanContext.TraceID().String()),
+			slog.String("span_id", spanContext.SpanID().String()),
+		)
+	}
+
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
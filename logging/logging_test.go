@@ -0,0 +1,58 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSensitiveFieldsAreRedacted(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, "json", slog.LevelInfo)
+
+	logger.Info(
+		"login",
+		"email", "someone@mail.com",
+		"Password", "hunter2",
+		slog.Group("headers", "Authorization", "secret-token"),
+		"token", "abc",
+	)
+
+	require.NotContains(t, buf.String(), "hunter2")
+	require.NotContains(t, buf.String(), "secret-token")
+	require.NotContains(t, buf.String(), `"abc"`)
+
+	var record map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	require.Equal(t, "someone@mail.com", record["email"])
+	require.Equal(t, Redacted, record["Password"])
+	require.Equal(t, Redacted, record["token"])
+}
+
+func TestRequestIDIsAddedFromContext(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, "json", slog.LevelInfo).With("component", "test")
+
+	ctx := WithRequestID(context.Background(), "req-123")
+	logger.InfoContext(ctx, "handled")
+
+	var record map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	require.Equal(t, "req-123", record["request_id"])
+	require.Equal(t, "test", record["component"])
+
+	// records without a request id in their context are left untouched
+	buf.Reset()
+	logger.Info("no request")
+	require.NotContains(t, buf.String(), "request_id")
+}
+
+func TestParseLevel(t *testing.T) {
+	require.Equal(t, slog.LevelDebug, ParseLevel("debug"))
+	require.Equal(t, slog.LevelWarn, ParseLevel("WARN"))
+	require.Equal(t, slog.LevelInfo, ParseLevel("nonsense"))
+}
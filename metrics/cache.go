@@ -0,0 +1,183 @@
+package metrics
+
+import (
+	"context"
+	"time"
+	"tinydates/cache"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// instrumentedCache decorates a cache.Cache recording lookups as hits or
+// misses along with the latency of every operation.
+type instrumentedCache struct {
+	next     cache.Cache
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+}
+
+// NewInstrumentedCache wraps next, labelling its metrics with the supplied
+// backend name (e.g. "redis" or "memory") so that several caches can be
+// registered with the same reg.
+func NewInstrumentedCache(
+	next cache.Cache,
+	backend string,
+	reg prometheus.Registerer,
+) cache.Cache {
+	factory := promauto.With(
+		prometheus.WrapRegistererWith(prometheus.Labels{"backend": backend}, reg),
+	)
+
+	return &instrumentedCache{
+		next: next,
+		requests: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Subsystem: "cache",
+				Name:      "requests_total",
+				Help:      "Total number of cache operations by result.",
+			},
+			[]string{"operation", "result"},
+		),
+		duration: factory.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: namespace,
+				Subsystem: "cache",
+				Name:      "operation_duration_seconds",
+				Help:      "Duration of cache operations.",
+				Buckets:   []float64{.0001, .0005, .001, .005, .01, .05, .1},
+			},
+			[]string{"operation"},
+		),
+	}
+}
+
+func (c *instrumentedCache) observe(op, result string, start time.Time) {
+	c.duration.WithLabelValues(op).Observe(since(start))
+	c.requests.WithLabelValues(op, result).Inc()
+}
+
+func (c *instrumentedCache) StartSession(
+	ctx context.Context,
+	token string,
+	id int,
+) error {
+	start := time.Now()
+	err := c.next.StartSession(ctx, token, id)
+	c.observe("start_session", result(err), start)
+	return err
+}
+
+func (c *instrumentedCache) Authorized(
+	ctx context.Context,
+	token string,
+) bool {
+	start := time.Now()
+	authorized := c.next.Authorized(ctx, token)
+
+	outcome := "miss"
+	if authorized {
+		outcome = "hit"
+	}
+	c.observe("authorized", outcome, start)
+
+	return authorized
+}
+
+func (c *instrumentedCache) Session(
+	ctx context.Context,
+	token string,
+) (int, bool) {
+	start := time.Now()
+	id, ok := c.next.Session(ctx, token)
+
+	outcome := "miss"
+	if ok {
+		outcome = "hit"
+	}
+	c.observe("session", outcome, start)
+
+	return id, ok
+}
+
+func (c *instrumentedCache) EndSession(
+	ctx context.Context,
+	token string,
+) error {
+	start := time.Now()
+	err := c.next.EndSession(ctx, token)
+	c.observe("end_session", result(err), start)
+	return err
+}
+
+func (c *instrumentedCache) SetDeck(
+	ctx context.Context,
+	id int,
+	deck cache.Deck,
+	ttl time.Duration,
+) error {
+	start := time.Now()
+	err := c.next.SetDeck(ctx, id, deck, ttl)
+	c.observe("set_deck", result(err), start)
+	return err
+}
+
+func (c *instrumentedCache) Deck(
+	ctx context.Context,
+	id int,
+) (cache.Deck, bool, error) {
+	start := time.Now()
+	deck, ok, err := c.next.Deck(ctx, id)
+
+	outcome := "miss"
+	switch {
+	case err != nil:
+		outcome = "error"
+	case ok:
+		outcome = "hit"
+	}
+	c.observe("deck", outcome, start)
+
+	return deck, ok, err
+}
+
+func (c *instrumentedCache) Served(
+	ctx context.Context,
+	id int,
+	last int,
+	ttl time.Duration,
+) error {
+	start := time.Now()
+	err := c.next.Served(ctx, id, last, ttl)
+	c.observe("served", result(err), start)
+	return err
+}
+
+func (c *instrumentedCache) Swiped(
+	ctx context.Context,
+	id int,
+	swipee int,
+	ttl time.Duration,
+) error {
+	start := time.Now()
+	err := c.next.Swiped(ctx, id, swipee, ttl)
+	c.observe("swiped", result(err), start)
+	return err
+}
+
+func (c *instrumentedCache) DropDeck(ctx context.Context, id int) error {
+	start := time.Now()
+	err := c.next.DropDeck(ctx, id)
+	c.observe("drop_deck", result(err), start)
+	return err
+}
+
+// result converts the error of a write operation into its metric label.
+func result(err error) string {
+	if err != nil {
+		return "error"
+	}
+
+	return "ok"
+}
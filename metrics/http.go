@@ -0,0 +1,87 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "tinydates"
+
+// Middleware records the rate, errors and duration (RED) of every request
+// handled by a gin route. Routes are labelled by their registered path, rather
+// than the raw URL, to keep the label cardinality bounded.
+func Middleware(reg prometheus.Registerer) gin.HandlerFunc {
+	factory := promauto.With(reg)
+
+	requests := factory.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "requests_total",
+			Help:      "Total number of HTTP requests by route and status code.",
+		},
+		[]string{"method", "route", "code"},
+	)
+
+	errors := factory.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "request_errors_total",
+			Help:      "Total number of HTTP requests answered with a 5xx status.",
+		},
+		[]string{"method", "route"},
+	)
+
+	duration := factory.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "request_duration_seconds",
+			Help:      "Duration of HTTP requests by route.",
+			Buckets:   prometheus.DefBuckets,
+		},
+		[]string{"method", "route"},
+	)
+
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			// unmatched routes are grouped together, otherwise every unknown
+			// path would create its own time series
+			route = "unmatched"
+		}
+
+		status := c.Writer.Status()
+		method := c.Request.Method
+
+		requests.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+		duration.WithLabelValues(method, route).Observe(
+			time.Since(start).Seconds(),
+		)
+		if status >= http.StatusInternalServerError {
+			errors.WithLabelValues(method, route).Inc()
+		}
+	}
+}
+
+// Handler exposes the metrics gathered by g in the Prometheus text format.
+func Handler(g prometheus.Gatherer) http.Handler {
+	return promhttp.HandlerFor(g, promhttp.HandlerOpts{})
+}
+
+// since returns the seconds elapsed from start, used by the decorators below
+// to observe operation latency.
+func since(start time.Time) float64 {
+	return time.Since(start).Seconds()
+}
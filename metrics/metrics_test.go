@@ -0,0 +1,85 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"tinydates/cache"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMiddlewareRecordsRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	reg := prometheus.NewRegistry()
+
+	engine := gin.New()
+	engine.Use(Middleware(reg))
+	engine.GET("/users/:id", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	engine.GET("/broken", func(c *gin.Context) {
+		c.Status(http.StatusInternalServerError)
+	})
+
+	for _, path := range []string{"/users/1", "/users/2", "/broken", "/nope"} {
+		rec := httptest.NewRecorder()
+		engine.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+	}
+
+	expected := `
+# HELP tinydates_http_requests_total Total number of HTTP requests by route and status code.
+# TYPE tinydates_http_requests_total counter
+tinydates_http_requests_total{code="200",method="GET",route="/users/:id"} 2
+tinydates_http_requests_total{code="404",method="GET",route="unmatched"} 1
+tinydates_http_requests_total{code="500",method="GET",route="/broken"} 1
+# HELP tinydates_http_request_errors_total Total number of HTTP requests answered with a 5xx status.
+# TYPE tinydates_http_request_errors_total counter
+tinydates_http_request_errors_total{method="GET",route="/broken"} 1
+`
+	require.NoError(t, testutil.GatherAndCompare(
+		reg,
+		strings.NewReader(expected),
+		"tinydates_http_requests_total",
+		"tinydates_http_request_errors_total",
+	))
+}
+
+func TestInstrumentedCacheHitsAndMisses(t *testing.T) {
+	ctx := context.Background()
+	reg := prometheus.NewRegistry()
+
+	// two backends registered against the same registry must not collide
+	memory := NewInstrumentedCache(cache.NewTinydatesInMemoryCache(0), "memory", reg)
+	other := NewInstrumentedCache(cache.NewTinydatesInMemoryCache(0), "other", reg)
+
+	require.NoError(t, memory.StartSession(ctx, "token", 1))
+	require.True(t, memory.Authorized(ctx, "token"))
+	require.False(t, memory.Authorized(ctx, "unknown"))
+	require.False(t, other.Authorized(ctx, "token"))
+
+	expected := `
+# HELP tinydates_cache_requests_total Total number of cache operations by result.
+# TYPE tinydates_cache_requests_total counter
+tinydates_cache_requests_total{backend="memory",operation="authorized",result="hit"} 1
+tinydates_cache_requests_total{backend="memory",operation="authorized",result="miss"} 1
+tinydates_cache_requests_total{backend="memory",operation="start_session",result="ok"} 1
+tinydates_cache_requests_total{backend="other",operation="authorized",result="miss"} 1
+`
+	require.NoError(t, testutil.GatherAndCompare(
+		reg,
+		strings.NewReader(expected),
+		"tinydates_cache_requests_total",
+	))
+	// one latency series per backend and operation
+	require.Equal(
+		t,
+		3,
+		testutil.CollectAndCount(reg, "tinydates_cache_operation_duration_seconds"),
+	)
+}
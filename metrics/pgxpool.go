@@ -0,0 +1,117 @@
+package metrics
+
+import (
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// pgxPoolCollector exports the statistics of a pgx connection pool, these are
+// read from the pool on every scrape rather than tracked separately.
+type pgxPoolCollector struct {
+	pool *pgxpool.Pool
+
+	acquireCount         *prometheus.Desc
+	acquireDuration      *prometheus.Desc
+	acquiredConns        *prometheus.Desc
+	canceledAcquireCount *prometheus.Desc
+	constructingConns    *prometheus.Desc
+	emptyAcquireCount    *prometheus.Desc
+	idleConns            *prometheus.Desc
+	maxConns             *prometheus.Desc
+	totalConns           *prometheus.Desc
+	newConnsCount        *prometheus.Desc
+	maxLifetimeDestroy   *prometheus.Desc
+	maxIdleDestroy       *prometheus.Desc
+}
+
+// NewPgxPoolCollector returns a collector for the statistics of pool.
+func NewPgxPoolCollector(pool *pgxpool.Pool) prometheus.Collector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "pgxpool", name),
+			help,
+			nil,
+			nil,
+		)
+	}
+
+	return &pgxPoolCollector{
+		pool: pool,
+		acquireCount: desc(
+			"acquire_count_total",
+			"Cumulative count of successful acquires from the pool.",
+		),
+		acquireDuration: desc(
+			"acquire_duration_seconds_total",
+			"Total duration of all successful acquires from the pool.",
+		),
+		acquiredConns: desc(
+			"acquired_conns",
+			"Number of currently acquired connections in the pool.",
+		),
+		canceledAcquireCount: desc(
+			"canceled_acquire_count_total",
+			"Cumulative count of acquires cancelled by a context.",
+		),
+		constructingConns: desc(
+			"constructing_conns",
+			"Number of connections currently being constructed.",
+		),
+		emptyAcquireCount: desc(
+			"empty_acquire_count_total",
+			"Cumulative count of acquires that waited for a connection.",
+		),
+		idleConns: desc(
+			"idle_conns",
+			"Number of currently idle connections in the pool.",
+		),
+		maxConns: desc(
+			"max_conns",
+			"Maximum size of the pool.",
+		),
+		totalConns: desc(
+			"total_conns",
+			"Total number of connections currently in the pool.",
+		),
+		newConnsCount: desc(
+			"new_conns_count_total",
+			"Cumulative count of new connections opened.",
+		),
+		maxLifetimeDestroy: desc(
+			"max_lifetime_destroy_count_total",
+			"Cumulative count of connections destroyed for exceeding their lifetime.",
+		),
+		maxIdleDestroy: desc(
+			"max_idle_destroy_count_total",
+			"Cumulative count of connections destroyed for being idle too long.",
+		),
+	}
+}
+
+func (c *pgxPoolCollector) Describe(ch chan<- *prometheus.Desc) {
+	prometheus.DescribeByCollect(c, ch)
+}
+
+func (c *pgxPoolCollector) Collect(ch chan<- prometheus.Metric) {
+	stat := c.pool.Stat()
+
+	counter := func(desc *prometheus.Desc, value float64) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.CounterValue, value)
+	}
+	gauge := func(desc *prometheus.Desc, value float64) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, value)
+	}
+
+	counter(c.acquireCount, float64(stat.AcquireCount()))
+	counter(c.acquireDuration, stat.AcquireDuration().Seconds())
+	gauge(c.acquiredConns, float64(stat.AcquiredConns()))
+	counter(c.canceledAcquireCount, float64(stat.CanceledAcquireCount()))
+	gauge(c.constructingConns, float64(stat.ConstructingConns()))
+	counter(c.emptyAcquireCount, float64(stat.EmptyAcquireCount()))
+	gauge(c.idleConns, float64(stat.IdleConns()))
+	gauge(c.maxConns, float64(stat.MaxConns()))
+	gauge(c.totalConns, float64(stat.TotalConns()))
+	counter(c.newConnsCount, float64(stat.NewConnsCount()))
+	counter(c.maxLifetimeDestroy, float64(stat.MaxLifetimeDestroyCount()))
+	counter(c.maxIdleDestroy, float64(stat.MaxIdleDestroyCount()))
+}
@@ -0,0 +1,300 @@
+package metrics
+
+import (
+	"context"
+	"time"
+	"tinydates/store"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// instrumentedStore decorates a store.Store recording the latency and errors
+// of every operation; the wrapped store is left untouched.
+type instrumentedStore struct {
+	next     store.Store
+	duration *prometheus.HistogramVec
+	errors   *prometheus.CounterVec
+}
+
+// NewInstrumentedStore wraps next so that each call is measured and the
+// resulting metrics registered with reg.
+func NewInstrumentedStore(
+	next store.Store,
+	reg prometheus.Registerer,
+) store.Store {
+	factory := promauto.With(reg)
+
+	return &instrumentedStore{
+		next: next,
+		duration: factory.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: namespace,
+				Subsystem: "store",
+				Name:      "operation_duration_seconds",
+				Help:      "Duration of store operations.",
+				Buckets:   prometheus.DefBuckets,
+			},
+			[]string{"operation"},
+		),
+		errors: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Subsystem: "store",
+				Name:      "errors_total",
+				Help:      "Total number of store operations returning an error.",
+			},
+			[]string{"operation"},
+		),
+	}
+}
+
+func (s *instrumentedStore) observe(op string, start time.Time, err error) {
+	s.duration.WithLabelValues(op).Observe(since(start))
+	if err != nil {
+		s.errors.WithLabelValues(op).Inc()
+	}
+}
+
+func (s *instrumentedStore) StoreNewUser(
+	ctx context.Context,
+	email, password, name, gender string,
+	birthDate time.Time,
+	location int,
+) (int, error) {
+	start := time.Now()
+	id, err := s.next.StoreNewUser(
+		ctx,
+		email,
+		password,
+		name,
+		gender,
+		birthDate,
+		location,
+	)
+	s.observe("store_new_user", start, err)
+	return id, err
+}
+
+func (s *instrumentedStore) GetPassword(
+	ctx context.Context,
+	email string,
+) (int, string, error) {
+	start := time.Now()
+	id, password, err := s.next.GetPassword(ctx, email)
+	s.observe("get_password", start, err)
+	return id, password, err
+}
+
+func (s *instrumentedStore) Discover(
+	ctx context.Context,
+	id int,
+	filters store.Filters,
+) ([]store.PotentialMatch, error) {
+	start := time.Now()
+	potentials, err := s.next.Discover(ctx, id, filters)
+	s.observe("discover", start, err)
+	return potentials, err
+}
+
+func (s *instrumentedStore) DiscoverByPopularity(
+	ctx context.Context,
+	id int,
+	filters store.Filters,
+) ([]store.PotentialMatch, error) {
+	start := time.Now()
+	potentials, err := s.next.DiscoverByPopularity(ctx, id, filters)
+	s.observe("discover_by_popularity", start, err)
+	return potentials, err
+}
+
+func (s *instrumentedStore) DiscoverBySharedInterests(
+	ctx context.Context,
+	id int,
+	filters store.Filters,
+) ([]store.PotentialMatch, error) {
+	start := time.Now()
+	potentials, err := s.next.DiscoverBySharedInterests(ctx, id, filters)
+	s.observe("discover_by_shared_interests", start, err)
+	return potentials, err
+}
+
+func (s *instrumentedStore) Swipe(
+	ctx context.Context,
+	swiperId, swipeeId int,
+	decision bool,
+) (int, error) {
+	start := time.Now()
+	id, err := s.next.Swipe(ctx, swiperId, swipeeId, decision)
+	s.observe("swipe", start, err)
+	return id, err
+}
+
+func (s *instrumentedStore) IsMatch(
+	ctx context.Context,
+	swipeeId, swiperId int,
+) (bool, error) {
+	start := time.Now()
+	match, err := s.next.IsMatch(ctx, swipeeId, swiperId)
+	s.observe("is_match", start, err)
+	return match, err
+}
+
+func (s *instrumentedStore) GetLocation(
+	ctx context.Context,
+	id int,
+) (int, error) {
+	start := time.Now()
+	location, err := s.next.GetLocation(ctx, id)
+	s.observe("get_location", start, err)
+	return location, err
+}
+
+func (s *instrumentedStore) GetProfile(
+	ctx context.Context,
+	id int,
+) (store.PotentialMatch, error) {
+	start := time.Now()
+	profile, err := s.next.GetProfile(ctx, id)
+	s.observe("get_profile", start, err)
+	return profile, err
+}
+
+func (s *instrumentedStore) GetProfiles(
+	ctx context.Context,
+	viewer int,
+	ids []int,
+) ([]store.PotentialMatch, error) {
+	start := time.Now()
+	profiles, err := s.next.GetProfiles(ctx, viewer, ids)
+	s.observe("get_profiles", start, err)
+	return profiles, err
+}
+
+func (s *instrumentedStore) GetDesirability(
+	ctx context.Context,
+	id int,
+) (float64, error) {
+	start := time.Now()
+	desirability, err := s.next.GetDesirability(ctx, id)
+	s.observe("get_desirability", start, err)
+	return desirability, err
+}
+
+func (s *instrumentedStore) AdjustDesirability(
+	ctx context.Context,
+	id int,
+	delta float64,
+) error {
+	start := time.Now()
+	err := s.next.AdjustDesirability(ctx, id, delta)
+	s.observe("adjust_desirability", start, err)
+	return err
+}
+
+func (s *instrumentedStore) RateDesirability(
+	ctx context.Context,
+	swiper, swipee int,
+	rate func(swiper, swipee float64) float64,
+) error {
+	start := time.Now()
+	err := s.next.RateDesirability(ctx, swiper, swipee, rate)
+	s.observe("rate_desirability", start, err)
+	return err
+}
+
+func (s *instrumentedStore) GetPreferences(
+	ctx context.Context,
+	id int,
+) (store.Preferences, error) {
+	start := time.Now()
+	preferences, err := s.next.GetPreferences(ctx, id)
+	s.observe("get_preferences", start, err)
+	return preferences, err
+}
+
+func (s *instrumentedStore) SetPreferences(
+	ctx context.Context,
+	id int,
+	preferences store.Preferences,
+) error {
+	start := time.Now()
+	err := s.next.SetPreferences(ctx, id, preferences)
+	s.observe("set_preferences", start, err)
+	return err
+}
+
+func (s *instrumentedStore) SetIdentity(
+	ctx context.Context,
+	id int,
+	gender, pronouns string,
+) error {
+	start := time.Now()
+	err := s.next.SetIdentity(ctx, id, gender, pronouns)
+	s.observe("set_identity", start, err)
+	return err
+}
+
+func (s *instrumentedStore) GetAbout(
+	ctx context.Context,
+	id int,
+) (store.About, error) {
+	start := time.Now()
+	about, err := s.next.GetAbout(ctx, id)
+	s.observe("get_about", start, err)
+	return about, err
+}
+
+func (s *instrumentedStore) SetAbout(
+	ctx context.Context,
+	id int,
+	about store.About,
+) error {
+	start := time.Now()
+	err := s.next.SetAbout(ctx, id, about)
+	s.observe("set_about", start, err)
+	return err
+}
+
+func (s *instrumentedStore) GetPhotos(
+	ctx context.Context,
+	ids []int,
+) (map[int][]store.Photo, error) {
+	start := time.Now()
+	photos, err := s.next.GetPhotos(ctx, ids)
+	s.observe("get_photos", start, err)
+	return photos, err
+}
+
+func (s *instrumentedStore) AddPhoto(
+	ctx context.Context,
+	id int,
+	photo store.Photo,
+	limit int,
+) (store.Photo, error) {
+	start := time.Now()
+	photo, err := s.next.AddPhoto(ctx, id, photo, limit)
+	s.observe("add_photo", start, err)
+	return photo, err
+}
+
+func (s *instrumentedStore) ReorderPhotos(
+	ctx context.Context,
+	id int,
+	photoIds []int,
+) error {
+	start := time.Now()
+	err := s.next.ReorderPhotos(ctx, id, photoIds)
+	s.observe("reorder_photos", start, err)
+	return err
+}
+
+func (s *instrumentedStore) DeletePhoto(
+	ctx context.Context,
+	id, photoId int,
+) (store.Photo, error) {
+	start := time.Now()
+	photo, err := s.next.DeletePhoto(ctx, id, photoId)
+	s.observe("delete_photo", start, err)
+	return photo, err
+}
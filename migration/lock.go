@@ -0,0 +1,32 @@
+package migration
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// lockId is the key of the Postgres advisory lock serialising migrations, the
+// ASCII of "tinydate" so that it does not clash with locks taken by others.
+const lockId int64 = 0x74696e7964617465
+
+// LockPostgres takes the session advisory lock serialising migrations between
+// every process sharing the database, such as replicas starting together,
+// waiting for it until ctx is done. The returned function releases the lock.
+func LockPostgres(ctx context.Context, pool *pgxpool.Pool) (func(), error) {
+	conn, err := pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := conn.Exec(ctx, "SELECT pg_advisory_lock($1)", lockId); err != nil {
+		conn.Release()
+		return nil, err
+	}
+
+	return func() {
+		// a lost connection releases the lock on its own
+		_, _ = conn.Exec(context.Background(), "SELECT pg_advisory_unlock($1)", lockId)
+		conn.Release()
+	}, nil
+}
@@ -0,0 +1,54 @@
+package migration
+
+import (
+	"database/sql"
+	"tinydates/config"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/database/sqlite"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+// Postgres returns a migrate instance applying the embedded Postgres
+// migrations through pool. Each migration file is sent as a single simple
+// query, which Postgres runs as one implicit transaction, so a failing
+// migration leaves no partial schema behind.
+//
+// Note: closing the returned instance does not close pool.
+func Postgres(pool *pgxpool.Pool) (*migrate.Migrate, error) {
+	source, err := iofs.New(config.PostgresMigrations(), ".")
+	if err != nil {
+		return nil, err
+	}
+
+	driver, err := postgres.WithInstance(
+		stdlib.OpenDBFromPool(pool),
+		&postgres.Config{},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return migrate.NewWithInstance("iofs", source, "postgres", driver)
+}
+
+// Sqlite returns a migrate instance applying the embedded SQLite migrations
+// to db. Each migration is run inside its own transaction by the driver.
+//
+// Note: closing the returned instance also closes db.
+func Sqlite(db *sql.DB) (*migrate.Migrate, error) {
+	source, err := iofs.New(config.SqliteMigrations(), ".")
+	if err != nil {
+		return nil, err
+	}
+
+	driver, err := sqlite.WithInstance(db, &sqlite.Config{})
+	if err != nil {
+		return nil, err
+	}
+
+	return migrate.NewWithInstance("iofs", source, "sqlite", driver)
+}
@@ -0,0 +1,291 @@
+package migration_test
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"tinydates/config"
+	"tinydates/migration"
+	"tinydates/store"
+	"tinydates/store/storetest"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrationsArePaired(t *testing.T) {
+	for backend, migrations := range map[string]fs.FS{
+		"postgres": config.PostgresMigrations(),
+		"sqlite":   config.SqliteMigrations(),
+	} {
+		t.Run(backend, func(t *testing.T) {
+			ups, err := fs.Glob(migrations, "*.up.sql")
+			require.NoError(t, err)
+			require.NotEmpty(t, ups)
+			downs, err := fs.Glob(migrations, "*.down.sql")
+			require.NoError(t, err)
+			require.Len(t, downs, len(ups))
+
+			for _, up := range ups {
+				down := strings.TrimSuffix(up, ".up.sql") + ".down.sql"
+				_, err := fs.Stat(migrations, down)
+				require.NoError(t, err, "%s has no down migration", up)
+			}
+		})
+	}
+}
+
+func TestSqliteMigrationsReverse(t *testing.T) {
+	db, err := store.OpenSqlite(filepath.Join(t.TempDir(), "tinydates.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	m, err := migration.Sqlite(db)
+	require.NoError(t, err)
+
+	requireReversible(t, m, func() []string {
+		rows, err := db.Query(`
+			SELECT m.name || '.' || p.name || ' ' || p.type || ' ' ||
+				p."notnull" || ' ' || coalesce(p.dflt_value, '') || ' ' || p.pk
+			FROM sqlite_master m JOIN pragma_table_info(m.name) p
+			WHERE m.type = 'table'
+				AND m.name != 'schema_migrations'
+				AND m.name NOT LIKE 'sqlite_%'
+			ORDER BY m.name, p.name
+		`)
+		require.NoError(t, err)
+		defer rows.Close()
+
+		return scanColumns(t, rows)
+	})
+}
+
+func TestPostgresMigrationsReverse(t *testing.T) {
+	db := storetest.Postgres(t)
+
+	m, err := migration.Postgres(db)
+	require.NoError(t, err)
+	t.Cleanup(func() { m.Close() })
+
+	requireReversible(t, m, func() []string {
+		rows, err := db.Query(context.Background(), `
+			SELECT table_name || '.' || column_name || ' ' || data_type || ' ' ||
+				is_nullable || ' ' || coalesce(column_default, '')
+			FROM information_schema.columns
+			WHERE table_schema = 'public' AND table_name != 'schema_migrations'
+			ORDER BY table_name, column_name
+		`)
+		require.NoError(t, err)
+		defer rows.Close()
+
+		return scanColumns(t, rows)
+	})
+}
+
+// requireReversible applies the migrations of m one at a time, checking that
+// each up migration changes the schema and that its down migration restores
+// the schema found before it.
+func requireReversible(t *testing.T, m *migrate.Migrate, schema func() []string) {
+	t.Helper()
+
+	for {
+		before := schema()
+
+		err := m.Steps(1)
+		if errors.Is(err, os.ErrNotExist) {
+			// every migration has been applied
+			return
+		}
+		require.NoError(t, err)
+		version, _, err := m.Version()
+		require.NoError(t, err)
+		require.NotEqual(t, before, schema(), "migration %d changes nothing", version)
+
+		require.NoError(t, m.Steps(-1))
+		require.Equal(t, before, schema(), "migration %d is not reversed", version)
+
+		require.NoError(t, m.Steps(1))
+	}
+}
+
+// scanColumns reads the single string column of every row.
+func scanColumns(t *testing.T, rows interface {
+	Next() bool
+	Scan(dest ...any) error
+	Err() error
+}) []string {
+	t.Helper()
+
+	var columns []string
+	for rows.Next() {
+		var column string
+		require.NoError(t, rows.Scan(&column))
+		columns = append(columns, column)
+	}
+	require.NoError(t, rows.Err())
+
+	return columns
+}
+
+func TestPostgresLock(t *testing.T) {
+	db := storetest.Postgres(t)
+
+	unlock, err := migration.LockPostgres(context.Background(), db)
+	require.NoError(t, err)
+
+	// a second migrator waits for the lock until it gives up
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, err = migration.LockPostgres(ctx, db)
+	require.Error(t, err)
+
+	unlock()
+	unlockAgain, err := migration.LockPostgres(context.Background(), db)
+	require.NoError(t, err)
+	unlockAgain()
+}
+
+func TestSqliteMigrationsNormaliseGenders(t *testing.T) {
+	db, err := store.OpenSqlite(filepath.Join(t.TempDir(), "tinydates.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	m, err := migration.Sqlite(db)
+	require.NoError(t, err)
+
+	// genders were free text before pronouns were added
+	require.NoError(t, m.Migrate(7))
+	_, err = db.Exec(`
+		INSERT INTO users (id, email, password, name, gender, age, location) VALUES
+			(1, 'a', 'p', 'a', ' Woman', 30, 0),
+			(2, 'b', 'p', 'b', 'M', 30, 0),
+			(3, 'c', 'p', 'c', 'non-binary', 30, 0),
+			(4, 'd', 'p', 'd', 'unspecified', 30, 0);
+		INSERT INTO preferences (user_id, genders) VALUES
+			(1, '["Male", "man", "Non Binary"]'),
+			(2, '[]');
+	`)
+	require.NoError(t, err)
+
+	require.NoError(t, m.Up())
+
+	rows, err := db.Query(`SELECT gender FROM users ORDER BY id`)
+	require.NoError(t, err)
+	defer rows.Close()
+	require.Equal(t, []string{"female", "male", "nonbinary", "other"}, scanColumns(t, rows))
+
+	rows, err = db.Query(`SELECT genders FROM preferences ORDER BY user_id`)
+	require.NoError(t, err)
+	defer rows.Close()
+	require.Equal(t, []string{`["male","nonbinary"]`, `[]`}, scanColumns(t, rows))
+}
+
+func TestSqliteMigrationsConvertAges(t *testing.T) {
+	db, err := store.OpenSqlite(filepath.Join(t.TempDir(), "tinydates.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	m, err := migration.Sqlite(db)
+	require.NoError(t, err)
+
+	// ages were stored before birth dates, and were not checked
+	require.NoError(t, m.Migrate(8))
+	_, err = db.Exec(`
+		INSERT INTO users (id, email, password, name, gender, age, location) VALUES
+			(1, 'a', 'p', 'a', 'female', 30, 0),
+			(2, 'b', 'p', 'b', 'male', 15, 0),
+			(3, 'c', 'p', 'c', 'male', 0, 0)
+	`)
+	require.NoError(t, err)
+
+	require.NoError(t, m.Up())
+
+	rows, err := db.Query(`SELECT birth_date FROM users ORDER BY id`)
+	require.NoError(t, err)
+	defer rows.Close()
+	requireAges(t, []int{30, 18, 18}, scanColumns(t, rows))
+
+	sqlite := store.NewTinydatesSqliteStore(db)
+	for id, age := range map[int]int{1: 30, 2: 18, 3: 18} {
+		profile, err := sqlite.GetProfile(context.Background(), id)
+		require.NoError(t, err)
+		require.Equal(t, age, profile.Age)
+	}
+
+	// the age is fixed again on the way down, and kept on the way up again
+	require.NoError(t, m.Migrate(8))
+	rows, err = db.Query(`SELECT age FROM users ORDER BY id`)
+	require.NoError(t, err)
+	defer rows.Close()
+	require.Equal(t, []string{"30", "18", "18"}, scanColumns(t, rows))
+
+	require.NoError(t, m.Up())
+	rows, err = db.Query(`SELECT birth_date FROM users ORDER BY id`)
+	require.NoError(t, err)
+	defer rows.Close()
+	requireAges(t, []int{30, 18, 18}, scanColumns(t, rows))
+}
+
+func TestPostgresMigrationsConvertAges(t *testing.T) {
+	db := storetest.Postgres(t)
+	ctx := context.Background()
+
+	m, err := migration.Postgres(db)
+	require.NoError(t, err)
+	t.Cleanup(func() { m.Close() })
+
+	// ages were stored before birth dates, and were not checked
+	require.NoError(t, m.Migrate(8))
+	_, err = db.Exec(ctx, `
+		INSERT INTO users (id, email, password, name, gender, age, location) VALUES
+			(1, 'a', 'p', 'a', 'female', 30, 0),
+			(2, 'b', 'p', 'b', 'male', 15, 0),
+			(3, 'c', 'p', 'c', 'male', 0, 0)
+	`)
+	require.NoError(t, err)
+
+	require.NoError(t, m.Up())
+
+	rows, err := db.Query(ctx, `SELECT to_char(birth_date, 'YYYY-MM-DD') FROM users ORDER BY id`)
+	require.NoError(t, err)
+	defer rows.Close()
+	requireAges(t, []int{30, 18, 18}, scanColumns(t, rows))
+
+	pg := store.NewTinydatesPgStore(db)
+	for id, age := range map[int]int{1: 30, 2: 18, 3: 18} {
+		profile, err := pg.GetProfile(ctx, id)
+		require.NoError(t, err)
+		require.Equal(t, age, profile.Age)
+	}
+
+	// the age is fixed again on the way down, and kept on the way up again
+	require.NoError(t, m.Migrate(8))
+	rows, err = db.Query(ctx, `SELECT age::text FROM users ORDER BY id`)
+	require.NoError(t, err)
+	defer rows.Close()
+	require.Equal(t, []string{"30", "18", "18"}, scanColumns(t, rows))
+
+	require.NoError(t, m.Up())
+	rows, err = db.Query(ctx, `SELECT to_char(birth_date, 'YYYY-MM-DD') FROM users ORDER BY id`)
+	require.NoError(t, err)
+	defer rows.Close()
+	requireAges(t, []int{30, 18, 18}, scanColumns(t, rows))
+}
+
+// requireAges checks that the birth dates, as YYYY-MM-DD dates, make users
+// the given ages today.
+func requireAges(t *testing.T, ages []int, birthDates []string) {
+	t.Helper()
+
+	require.Len(t, birthDates, len(ages))
+	for i, birthDate := range birthDates {
+		born, err := time.Parse(time.DateOnly, birthDate)
+		require.NoError(t, err)
+		require.Equal(t, ages[i], store.Age(born, time.Now().UTC()), "birth date %s", birthDate)
+	}
+}
@@ -0,0 +1,323 @@
+// Package openapi holds the OpenAPI 3 document describing the HTTP API and
+// validates requests against it. Only the parts of the specification used by
+// the document are modelled: header and query parameters, JSON request bodies
+// and schemas made of objects, arrays and scalar types.
+package openapi
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+//go:embed openapi.json
+var document []byte
+
+// JSON returns the OpenAPI document as served to callers.
+func JSON() []byte {
+	return document
+}
+
+// Document is the decoded OpenAPI document.
+type Document struct {
+	OpenAPI    string               `json:"openapi"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components struct {
+		Schemas map[string]*Schema `json:"schemas"`
+	} `json:"components"`
+}
+
+// PathItem holds the operations of a path by method.
+type PathItem struct {
+	Get    *Operation `json:"get"`
+	Post   *Operation `json:"post"`
+	Put    *Operation `json:"put"`
+	Patch  *Operation `json:"patch"`
+	Delete *Operation `json:"delete"`
+}
+
+// Operations returns the operations of the path keyed by HTTP method.
+func (item *PathItem) Operations() map[string]*Operation {
+	operations := make(map[string]*Operation)
+	for method, operation := range map[string]*Operation{
+		http.MethodGet:    item.Get,
+		http.MethodPost:   item.Post,
+		http.MethodPut:    item.Put,
+		http.MethodPatch:  item.Patch,
+		http.MethodDelete: item.Delete,
+	} {
+		if operation != nil {
+			operations[method] = operation
+		}
+	}
+
+	return operations
+}
+
+// Operation is a single route of the API.
+type Operation struct {
+	OperationId string       `json:"operationId"`
+	Parameters  []Parameter  `json:"parameters"`
+	RequestBody *RequestBody `json:"requestBody"`
+}
+
+// Parameter is a header or query parameter of an operation. ErrorCode, the
+// x-error-code extension, is the error code reported when the parameter is
+// invalid, the caller's default applies when it is empty.
+type Parameter struct {
+	Name      string  `json:"name"`
+	In        string  `json:"in"`
+	Required  bool    `json:"required"`
+	Schema    *Schema `json:"schema"`
+	ErrorCode string  `json:"x-error-code"`
+}
+
+// RequestBody is the body expected by an operation.
+type RequestBody struct {
+	Required bool `json:"required"`
+	Content  map[string]struct {
+		Schema *Schema `json:"schema"`
+	} `json:"content"`
+}
+
+// Schema describes a JSON value.
+type Schema struct {
+	Ref        string             `json:"$ref"`
+	Type       string             `json:"type"`
+	Nullable   bool               `json:"nullable"`
+	Required   []string           `json:"required"`
+	Properties map[string]*Schema `json:"properties"`
+	Items      *Schema            `json:"items"`
+}
+
+// ValidationError reports the part of a request that does not match the
+// document, Name is empty for the body. Code is the x-error-code of the
+// parameter at fault, if any.
+type ValidationError struct {
+	In   string
+	Name string
+	Code string
+	Err  error
+}
+
+func (e *ValidationError) Error() string {
+	if e.Name == "" {
+		return fmt.Sprintf("invalid %s: %v", e.In, e.Err)
+	}
+
+	return fmt.Sprintf("invalid %s %q: %v", e.In, e.Name, e.Err)
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// Spec returns the decoded document.
+func Spec() *Document {
+	var doc Document
+	if err := json.Unmarshal(document, &doc); err != nil {
+		// the document is embedded at compile time and covered by the tests
+		panic(err)
+	}
+
+	return &doc
+}
+
+// Operation returns the operation served for method on path, written the
+// OpenAPI way such as "/users/{id}", and whether there is one.
+func (doc *Document) Operation(method, path string) (*Operation, bool) {
+	item, ok := doc.Paths[path]
+	if !ok {
+		return nil, false
+	}
+
+	operation, ok := item.Operations()[method]
+	return operation, ok
+}
+
+// ValidateRequest checks the parameters and body of r against operation.
+// The body is read and replaced so that it can still be read by the handler.
+func (doc *Document) ValidateRequest(operation *Operation, r *http.Request) error {
+	for _, param := range operation.Parameters {
+		var (
+			value   string
+			present bool
+		)
+		switch param.In {
+		case "header":
+			values := r.Header.Values(param.Name)
+			value, present = strings.Join(values, ","), len(values) > 0
+		case "query":
+			value, present = r.URL.Query().Get(param.Name), r.URL.Query().Has(param.Name)
+		default:
+			continue
+		}
+
+		if err := doc.validateParameter(param, value, present); err != nil {
+			return &ValidationError{
+				In:   param.In,
+				Name: param.Name,
+				Code: param.ErrorCode,
+				Err:  err,
+			}
+		}
+	}
+
+	if operation.RequestBody == nil {
+		return nil
+	}
+
+	if err := doc.validateBody(operation.RequestBody, r); err != nil {
+		return &ValidationError{In: "body", Err: err}
+	}
+
+	return nil
+}
+
+func (doc *Document) validateParameter(param Parameter, value string, present bool) error {
+	if !present {
+		if param.Required {
+			return errors.New("missing")
+		}
+		return nil
+	}
+
+	if param.Schema == nil {
+		return nil
+	}
+
+	var err error
+	switch doc.resolve(param.Schema).Type {
+	case "integer":
+		_, err = strconv.Atoi(value)
+	case "boolean":
+		_, err = strconv.ParseBool(value)
+	}
+
+	return err
+}
+
+func (doc *Document) validateBody(body *RequestBody, r *http.Request) error {
+	content, ok := body.Content["application/json"]
+	if !ok {
+		return nil
+	}
+
+	raw, err := io.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+	r.Body = io.NopCloser(bytes.NewReader(raw))
+
+	if len(bytes.TrimSpace(raw)) == 0 {
+		if body.Required {
+			return errors.New("missing")
+		}
+		return nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return err
+	}
+
+	return doc.validateValue(content.Schema, value, "")
+}
+
+// validateValue checks a decoded JSON value against schema, path locates the
+// value within the body for error messages.
+func (doc *Document) validateValue(schema *Schema, value any, path string) error {
+	if schema == nil {
+		return nil
+	}
+	schema = doc.resolve(schema)
+
+	if value == nil {
+		if schema.Nullable {
+			return nil
+		}
+		return fmt.Errorf("%s must not be null", describe(path))
+	}
+
+	switch schema.Type {
+	case "object":
+		object, ok := value.(map[string]any)
+		if !ok {
+			return fmt.Errorf("%s must be an object", describe(path))
+		}
+		for _, name := range schema.Required {
+			if _, ok := object[name]; !ok {
+				return fmt.Errorf("%s is required", describe(path+"."+name))
+			}
+		}
+		for name, property := range schema.Properties {
+			if field, ok := object[name]; ok {
+				if err := doc.validateValue(property, field, path+"."+name); err != nil {
+					return err
+				}
+			}
+		}
+	case "array":
+		items, ok := value.([]any)
+		if !ok {
+			return fmt.Errorf("%s must be an array", describe(path))
+		}
+		for i, item := range items {
+			if err := doc.validateValue(schema.Items, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	case "integer":
+		number, ok := value.(json.Number)
+		if _, err := number.Int64(); !ok || err != nil {
+			return fmt.Errorf("%s must be an integer", describe(path))
+		}
+	case "number":
+		if _, ok := value.(json.Number); !ok {
+			return fmt.Errorf("%s must be a number", describe(path))
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("%s must be a boolean", describe(path))
+		}
+	case "string":
+		if _, ok := value.(string); !ok {
+			return fmt.Errorf("%s must be a string", describe(path))
+		}
+	}
+
+	return nil
+}
+
+// resolve follows a reference to a component schema.
+func (doc *Document) resolve(schema *Schema) *Schema {
+	const prefix = "#/components/schemas/"
+
+	for schema.Ref != "" {
+		resolved, ok := doc.Components.Schemas[strings.TrimPrefix(schema.Ref, prefix)]
+		if !ok {
+			return &Schema{}
+		}
+		schema = resolved
+	}
+
+	return schema
+}
+
+// describe names the value at path, the body itself when it is empty.
+func describe(path string) string {
+	if path == "" {
+		return "body"
+	}
+
+	return strings.TrimPrefix(path, ".")
+}
@@ -0,0 +1,77 @@
+package openapi_test
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"tinydates/openapi"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEveryOperationIsNamed(t *testing.T) {
+	doc := openapi.Spec()
+	require.Equal(t, "3.0.3", doc.OpenAPI)
+
+	for path, item := range doc.Paths {
+		for method, operation := range item.Operations() {
+			require.NotEmpty(t, operation.OperationId, "%s %s", method, path)
+		}
+	}
+}
+
+func TestValidateRequestBody(t *testing.T) {
+	doc := openapi.Spec()
+	operation, ok := doc.Operation("POST", "/swipe")
+	require.True(t, ok)
+
+	for body, valid := range map[string]bool{
+		`{"swiperId": 1, "swipeeId": 2, "decision": false}`:         true,
+		`{"swiperId": 1, "swipeeId": 2, "decision": true, "x": []}`: true,
+		`{"swiperId": 1, "swipeeId": 2}`:                            false,
+		`{"swiperId": 1.5, "swipeeId": 2, "decision": true}`:        false,
+		`{"swiperId": "1", "swipeeId": 2, "decision": true}`:        false,
+		`{"swiperId": 1, "swipeeId": 2, "decision": "yes"}`:         false,
+		`[1, 2]`: false,
+		`{"swip`: false,
+		``:       false,
+	} {
+		req := httptest.NewRequest("POST", "/swipe", strings.NewReader(body))
+		err := doc.ValidateRequest(operation, req)
+		if valid {
+			require.NoError(t, err, body)
+		} else {
+			require.Error(t, err, body)
+		}
+	}
+}
+
+func TestValidatedBodyCanBeReadAgain(t *testing.T) {
+	doc := openapi.Spec()
+	operation, ok := doc.Operation("POST", "/login")
+	require.True(t, ok)
+
+	body := `{"email": "a@mail.com", "password": "password"}`
+	req := httptest.NewRequest("POST", "/login", strings.NewReader(body))
+	require.NoError(t, doc.ValidateRequest(operation, req))
+
+	raw, err := io.ReadAll(req.Body)
+	require.NoError(t, err)
+	require.Equal(t, body, string(raw))
+}
+
+func TestValidationErrorCarriesTheParameterErrorCode(t *testing.T) {
+	doc := openapi.Spec()
+	operation, ok := doc.Operation("GET", "/discover")
+	require.True(t, ok)
+
+	req := httptest.NewRequest("GET", "/discover?minAge=old", nil)
+	req.Header.Set("Id", "1")
+
+	var validationErr *openapi.ValidationError
+	require.ErrorAs(t, doc.ValidateRequest(operation, req), &validationErr)
+	require.Equal(t, "query", validationErr.In)
+	require.Equal(t, "minAge", validationErr.Name)
+	require.Equal(t, "age_range_invalid", validationErr.Code)
+}
@@ -0,0 +1,290 @@
+package tinydates
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"tinydates/photos"
+	"tinydates/ranking"
+	"tinydates/store"
+)
+
+// maxPhotos is the most photos a user may have.
+const maxPhotos = 6
+
+// WithPhotos lets users upload photos, processed within limits and stored in
+// blobs; the photo endpoints answer ErrPhotosUnavailable without it.
+func WithPhotos(blobs photos.BlobStore, limits photos.Limits) Option {
+	return func(td *tinydates) {
+		td.blobs = blobs
+		td.photoLimits = limits
+	}
+}
+
+func (td tinydates) GetPhotos(
+	ctx context.Context,
+	token string,
+) ([]Photo, error) {
+	id, err := td.caller(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	if td.blobs == nil {
+		return nil, ErrPhotosUnavailable
+	}
+
+	stored, err := td.photosOf(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return td.photos(stored), nil
+}
+
+func (td tinydates) AddPhoto(
+	ctx context.Context,
+	token string,
+	upload io.Reader,
+) (Photo, error) {
+	id, err := td.caller(ctx, token)
+	if err != nil {
+		return Photo{}, err
+	}
+	if td.blobs == nil {
+		return Photo{}, ErrPhotosUnavailable
+	}
+
+	// checked ahead of the processing, which is the costly part; the store
+	// checks again as it adds the photo, for uploads made at the same time
+	stored, err := td.photosOf(ctx, id)
+	if err != nil {
+		return Photo{}, err
+	}
+	if len(stored) >= maxPhotos {
+		return Photo{}, ErrPhotoLimit
+	}
+
+	processed, err := photos.Process(upload, td.photoLimits)
+	if err != nil {
+		return Photo{}, photoError(err)
+	}
+
+	name, err := randomHex(16)
+	if err != nil {
+		return Photo{}, ErrInternalService.Wrap(err)
+	}
+	photo := store.Photo{
+		Key:          fmt.Sprintf("users/%d/%s.jpg", id, name),
+		ThumbnailKey: fmt.Sprintf("users/%d/%s-thumb.jpg", id, name),
+		Width:        processed.Photo.Width,
+		Height:       processed.Photo.Height,
+	}
+
+	// the images are stored first so that a photo is never listed without
+	// them, they are removed again when the photo cannot be
+	for key, data := range map[string][]byte{
+		photo.Key:          processed.Photo.Data,
+		photo.ThumbnailKey: processed.Thumbnail.Data,
+	} {
+		if err := td.blobs.Put(ctx, key, data, photos.ContentType); err != nil {
+			td.logger.ErrorContext(ctx, "failed to store photo", "id", id, "err", err)
+			td.deleteBlobs(ctx, photo)
+			return Photo{}, ErrInternalService.Wrap(err)
+		}
+	}
+
+	added, err := td.store.AddPhoto(ctx, id, photo, maxPhotos)
+	if err != nil {
+		td.deleteBlobs(ctx, photo)
+		if errors.Is(err, store.ErrNotFound) {
+			return Photo{}, ErrUserNotFound.Wrap(err)
+		}
+		if errors.Is(err, store.ErrLimitReached) {
+			return Photo{}, ErrPhotoLimit.Wrap(err)
+		}
+		td.logger.ErrorContext(ctx, "failed to add photo", "id", id, "err", err)
+		return Photo{}, storeError(err, ErrInternalService)
+	}
+
+	return td.photo(added), nil
+}
+
+func (td tinydates) ReorderPhotos(
+	ctx context.Context,
+	token string,
+	photoIds []int,
+) ([]Photo, error) {
+	id, err := td.caller(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	if td.blobs == nil {
+		return nil, ErrPhotosUnavailable
+	}
+
+	stored, err := td.photosOf(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// every photo of the user is listed, once
+	byId := make(map[int]store.Photo, len(stored))
+	for _, photo := range stored {
+		byId[photo.Id] = photo
+	}
+	ordered := make([]store.Photo, 0, len(photoIds))
+	for _, photoId := range photoIds {
+		photo, ok := byId[photoId]
+		if !ok {
+			return nil, ErrPhotoOrderInvalid.Wrap(
+				fmt.Errorf("photo %d not listed once", photoId),
+			)
+		}
+		ordered = append(ordered, photo)
+		delete(byId, photoId)
+	}
+	if len(byId) > 0 {
+		return nil, ErrPhotoOrderInvalid.Wrap(errors.New("photos missing"))
+	}
+
+	err = td.store.ReorderPhotos(ctx, id, photoIds)
+	if errors.Is(err, store.ErrNotFound) {
+		// a photo was deleted meanwhile
+		return nil, ErrPhotoOrderInvalid.Wrap(err)
+	}
+	if err != nil {
+		td.logger.ErrorContext(ctx, "failed to reorder photos", "id", id, "err", err)
+		return nil, storeError(err, ErrInternalService)
+	}
+
+	return td.photos(ordered), nil
+}
+
+func (td tinydates) DeletePhoto(
+	ctx context.Context,
+	token string,
+	photoId int,
+) error {
+	id, err := td.caller(ctx, token)
+	if err != nil {
+		return err
+	}
+	if td.blobs == nil {
+		return ErrPhotosUnavailable
+	}
+
+	photo, err := td.store.DeletePhoto(ctx, id, photoId)
+	if errors.Is(err, store.ErrNotFound) {
+		return ErrPhotoNotFound.Wrap(err)
+	}
+	if err != nil {
+		td.logger.ErrorContext(ctx, "failed to delete photo", "id", id, "err", err)
+		return storeError(err, ErrInternalService)
+	}
+
+	// the photo is gone by now, images left behind are only logged
+	td.deleteBlobs(ctx, photo)
+
+	return nil
+}
+
+// photosOf returns the photos of the user with the supplied id, in order.
+func (td tinydates) photosOf(ctx context.Context, id int) ([]store.Photo, error) {
+	stored, err := td.store.GetPhotos(ctx, []int{id})
+	if err != nil {
+		td.logger.ErrorContext(ctx, "failed to get photos", "id", id, "err", err)
+		return nil, storeError(err, ErrInternalService)
+	}
+
+	return stored[id], nil
+}
+
+// discoveredPhotos returns the photos of the candidates keyed by user, none
+// when photos are not enabled. Profiles are still served without their
+// photos when these cannot be read.
+func (td tinydates) discoveredPhotos(
+	ctx context.Context,
+	candidates []ranking.Candidate,
+) map[int][]Photo {
+	if td.blobs == nil || len(candidates) == 0 {
+		return nil
+	}
+
+	ids := make([]int, 0, len(candidates))
+	for _, candidate := range candidates {
+		ids = append(ids, candidate.Id)
+	}
+
+	stored, err := td.store.GetPhotos(ctx, ids)
+	if err != nil {
+		td.logger.WarnContext(ctx, "failed to get discovered photos", "err", err)
+		return nil
+	}
+
+	discovered := make(map[int][]Photo, len(stored))
+	for id, userPhotos := range stored {
+		discovered[id] = td.photos(userPhotos)
+	}
+
+	return discovered
+}
+
+// deleteBlobs deletes the images of photo, even once the request is
+// cancelled; failures are only logged as the images are unreachable once the
+// photo is gone.
+func (td tinydates) deleteBlobs(ctx context.Context, photo store.Photo) {
+	ctx = context.WithoutCancel(ctx)
+	for _, key := range []string{photo.Key, photo.ThumbnailKey} {
+		if err := td.blobs.Delete(ctx, key); err != nil {
+			td.logger.WarnContext(ctx, "failed to delete photo", "key", key, "err", err)
+		}
+	}
+}
+
+func (td tinydates) photo(photo store.Photo) Photo {
+	return Photo{
+		Id:           photo.Id,
+		Url:          td.blobs.URL(photo.Key),
+		ThumbnailUrl: td.blobs.URL(photo.ThumbnailKey),
+		Width:        photo.Width,
+		Height:       photo.Height,
+	}
+}
+
+func (td tinydates) photos(stored []store.Photo) []Photo {
+	converted := make([]Photo, 0, len(stored))
+	for _, photo := range stored {
+		converted = append(converted, td.photo(photo))
+	}
+
+	return converted
+}
+
+// photoError translates an upload rejected by photos.Process into the domain
+// error returned to the caller.
+func photoError(err error) error {
+	switch {
+	case errors.Is(err, photos.ErrTooLarge):
+		return ErrPhotoTooLarge.Wrap(err)
+	case errors.Is(err, photos.ErrUnsupportedType):
+		return ErrPhotoUnsupported.Wrap(err)
+	case errors.Is(err, photos.ErrInvalidImage), errors.Is(err, photos.ErrDimensions):
+		return ErrPhotoInvalid.Wrap(err)
+	default:
+		// the upload could not be read, such as when the client went away
+		return ErrInvalidRequest.Wrap(err)
+	}
+}
+
+// randomHex returns n random bytes, hex encoded.
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
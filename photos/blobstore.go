@@ -0,0 +1,148 @@
+package photos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// BlobStore holds the images of the photos, each one under a key such as
+// "users/1/0f3c.jpg", and names the URL it is served from.
+type BlobStore interface {
+	// Put stores data under key, replacing whatever was stored there.
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+
+	// Delete removes the blob under key, a missing blob is not an error.
+	Delete(ctx context.Context, key string) error
+
+	// URL returns the URL the blob under key is served from.
+	URL(key string) string
+}
+
+// FileStore is a BlobStore keeping blobs as files under a directory of the
+// local filesystem. It serves them too, as an http.Handler resolving the
+// path of the request, relative to where it is mounted, as the key.
+type FileStore struct {
+	dir     string
+	baseURL string
+	files   http.Handler
+}
+
+// NewFileStore returns a FileStore keeping blobs under dir, which is created
+// when missing, and serving them from baseURL such as
+// "https://tinydates.example/photos".
+func NewFileStore(dir, baseURL string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("unable to create photo directory: %w", err)
+	}
+
+	return &FileStore{
+		dir:     dir,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		files:   http.FileServer(noListing{http.Dir(dir)}),
+	}, nil
+}
+
+func (s *FileStore) Put(
+	ctx context.Context,
+	key string,
+	data []byte,
+	contentType string,
+) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	// written aside then renamed so that a blob is never served half written
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+func (s *FileStore) Delete(ctx context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
+
+func (s *FileStore) URL(key string) string {
+	return s.baseURL + "/" + key
+}
+
+// ServeHTTP serves the blob keyed by the path of r; blobs are immutable, a
+// new key is used whenever a photo changes, so they may be cached for good.
+func (s *FileStore) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	s.files.ServeHTTP(w, r)
+}
+
+// path returns the file holding the blob under key, failing for keys
+// escaping the directory.
+func (s *FileStore) path(key string) (string, error) {
+	if !fs.ValidPath(key) || key == "." {
+		return "", fmt.Errorf("invalid blob key %q", key)
+	}
+
+	return filepath.Join(s.dir, filepath.FromSlash(key)), nil
+}
+
+// noListing hides the directories of a filesystem so that the keys stored
+// cannot be listed.
+type noListing struct {
+	fs http.FileSystem
+}
+
+func (n noListing) Open(name string) (http.File, error) {
+	f, err := n.fs.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if info.IsDir() {
+		f.Close()
+		return nil, fs.ErrNotExist
+	}
+
+	return f, nil
+}
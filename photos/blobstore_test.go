@@ -0,0 +1,58 @@
+package photos
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileStoreStoresAndServesBlobs(t *testing.T) {
+	ctx := context.Background()
+
+	files, err := NewFileStore(t.TempDir(), "/photos/")
+	require.NoError(t, err)
+	server := httptest.NewServer(http.StripPrefix("/photos", files))
+	defer server.Close()
+
+	require.NoError(t, files.Put(ctx, "users/1/a.jpg", []byte("jpeg"), ContentType))
+	require.Equal(t, "/photos/users/1/a.jpg", files.URL("users/1/a.jpg"))
+
+	get := func(path string) (int, string) {
+		t.Helper()
+
+		resp, err := http.Get(server.URL + path)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp.StatusCode, string(body)
+	}
+
+	status, body := get(files.URL("users/1/a.jpg"))
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, "jpeg", body)
+
+	// the keys stored cannot be listed
+	status, _ = get("/photos/users/1/")
+	require.Equal(t, http.StatusNotFound, status)
+
+	require.NoError(t, files.Delete(ctx, "users/1/a.jpg"))
+	require.NoError(t, files.Delete(ctx, "users/1/a.jpg"))
+
+	status, _ = get(files.URL("users/1/a.jpg"))
+	require.Equal(t, http.StatusNotFound, status)
+}
+
+func TestFileStoreRejectsKeysEscapingItsDirectory(t *testing.T) {
+	files, err := NewFileStore(t.TempDir(), "/photos")
+	require.NoError(t, err)
+
+	for _, key := range []string{"../a.jpg", "/etc/a.jpg", "users/../../a.jpg", ""} {
+		require.Error(t, files.Put(context.Background(), key, []byte("jpeg"), ContentType), key)
+	}
+}
@@ -0,0 +1,215 @@
+package photos
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/draw"
+)
+
+// flatten draws img over a white background, JPEG having no transparency,
+// into an image whose bounds start at the origin.
+func flatten(img image.Image) *image.RGBA {
+	b := img.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), img, b.Min, draw.Over)
+
+	return dst
+}
+
+// fit scales src down so that its longest side is at most size, keeping its
+// aspect ratio; smaller images are returned as they are.
+func fit(src *image.RGBA, size int) *image.RGBA {
+	w, h := src.Bounds().Dx(), src.Bounds().Dy()
+	if w <= size && h <= size {
+		return src
+	}
+
+	if w >= h {
+		return scale(src, size, max(1, (h*size+w/2)/w))
+	}
+
+	return scale(src, max(1, (w*size+h/2)/h), size)
+}
+
+// square crops the largest square from the centre of src, then scales it
+// down so that its side is at most size.
+func square(src *image.RGBA, size int) *image.RGBA {
+	b := src.Bounds()
+	side := min(b.Dx(), b.Dy())
+	corner := b.Min.Add(image.Pt((b.Dx()-side)/2, (b.Dy()-side)/2))
+	crop := src.SubImage(image.Rectangle{Min: corner, Max: corner.Add(image.Pt(side, side))})
+
+	return scale(crop.(*image.RGBA), min(size, side), min(size, side))
+}
+
+// scale resizes src to w by h, every pixel being the average of those of src
+// it covers; this box filter is sound for the downscaling done here.
+func scale(src *image.RGBA, w, h int) *image.RGBA {
+	b := src.Bounds()
+	sw, sh := b.Dx(), b.Dy()
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+
+	for y := 0; y < h; y++ {
+		y0 := y * sh / h
+		y1 := max((y+1)*sh/h, y0+1)
+		for x := 0; x < w; x++ {
+			x0 := x * sw / w
+			x1 := max((x+1)*sw/w, x0+1)
+
+			var sum [4]int
+			for sy := y0; sy < y1; sy++ {
+				row := src.Pix[src.PixOffset(b.Min.X+x0, b.Min.Y+sy):]
+				for i := 0; i < (x1-x0)*4; i++ {
+					sum[i%4] += int(row[i])
+				}
+			}
+
+			n := (x1 - x0) * (y1 - y0)
+			pixel := dst.Pix[dst.PixOffset(x, y):]
+			for i := range sum {
+				pixel[i] = uint8((sum[i] + n/2) / n)
+			}
+		}
+	}
+
+	return dst
+}
+
+// orientation is the EXIF orientation of a JPEG image: how its pixels must be
+// transformed to be shown upright, from 1, as they are, to 8.
+type orientation int
+
+// apply transforms src according to o.
+func (o orientation) apply(src *image.RGBA) *image.RGBA {
+	if o < 2 || o > 8 {
+		return src
+	}
+
+	w, h := src.Bounds().Dx(), src.Bounds().Dy()
+	dw, dh := w, h
+	if o >= 5 {
+		// the orientations from 5 on turn the image on its side
+		dw, dh = h, w
+	}
+
+	// at returns the pixel of src shown at x, y
+	at := func(x, y int) (int, int) {
+		switch o {
+		case 2:
+			return w - 1 - x, y
+		case 3:
+			return w - 1 - x, h - 1 - y
+		case 4:
+			return x, h - 1 - y
+		case 5:
+			return y, x
+		case 6:
+			return y, h - 1 - x
+		case 7:
+			return w - 1 - y, h - 1 - x
+		default:
+			return w - 1 - y, x
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			sx, sy := at(x, y)
+			copy(dst.Pix[dst.PixOffset(x, y):][:4], src.Pix[src.PixOffset(sx, sy):][:4])
+		}
+	}
+
+	return dst
+}
+
+// exifOrientation reads the orientation from the EXIF data of a JPEG image,
+// 1 when it has none or it cannot be read.
+func exifOrientation(data []byte) orientation {
+	if !bytes.HasPrefix(data, []byte{0xff, 0xd8}) {
+		return 1
+	}
+
+	// the EXIF data is held by an APP1 segment, ahead of the image data
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xff {
+			return 1
+		}
+
+		marker := data[i+1]
+		switch marker {
+		case 0xff:
+			// fill byte ahead of a marker
+			i++
+			continue
+		case 0xda, 0xd9:
+			// start of the image data, or its end
+			return 1
+		}
+
+		length := int(binary.BigEndian.Uint16(data[i+2:]))
+		if length < 2 || i+2+length > len(data) {
+			return 1
+		}
+
+		segment := data[i+4 : i+2+length]
+		if marker == 0xe1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return tiffOrientation(segment[6:])
+		}
+
+		i += 2 + length
+	}
+
+	return 1
+}
+
+// tiffOrientation reads the orientation tag from the first IFD of the TIFF
+// structure EXIF data is held in.
+func tiffOrientation(tiff []byte) orientation {
+	const (
+		orientationTag = 0x0112
+		shortType      = 3
+		entrySize      = 12
+	)
+
+	if len(tiff) < 8 {
+		return 1
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+
+	ifd := int64(order.Uint32(tiff[4:]))
+	if ifd+2 > int64(len(tiff)) {
+		return 1
+	}
+
+	entries := int64(order.Uint16(tiff[ifd:]))
+	for n := int64(0); n < entries; n++ {
+		entry := ifd + 2 + n*entrySize
+		if entry+entrySize > int64(len(tiff)) {
+			return 1
+		}
+		if order.Uint16(tiff[entry:]) != orientationTag {
+			continue
+		}
+
+		// a short value is held in the first bytes of the value field
+		o := orientation(order.Uint16(tiff[entry+8:]))
+		if order.Uint16(tiff[entry+2:]) != shortType || o < 1 || o > 8 {
+			return 1
+		}
+		return o
+	}
+
+	return 1
+}
@@ -0,0 +1,165 @@
+// Package photos processes the photos users upload and stores them in a
+// BlobStore. Uploads are sniffed for their type and checked against Limits
+// before being decoded, then re-encoded as JPEG: their metadata, EXIF
+// included, is never kept, only their orientation is applied to the pixels.
+package photos
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+)
+
+// ContentType is the type of every image produced by Process.
+const ContentType = "image/jpeg"
+
+// quality is the JPEG quality the images are encoded with.
+const quality = 85
+
+var (
+	// ErrUnsupportedType is returned when an upload is not a JPEG, PNG or
+	// GIF image.
+	ErrUnsupportedType = errors.New("unsupported image type")
+
+	// ErrTooLarge is returned when an upload is over Limits.MaxBytes.
+	ErrTooLarge = errors.New("image too large")
+
+	// ErrInvalidImage is returned when an upload cannot be decoded as the
+	// type it was sniffed to be.
+	ErrInvalidImage = errors.New("invalid image")
+
+	// ErrDimensions is returned when the width or height of an upload is
+	// outside Limits.MinDimension and Limits.MaxDimension.
+	ErrDimensions = errors.New("image dimensions out of range")
+)
+
+// decoders decode the image types accepted, keyed by their sniffed type.
+var decoders = map[string]struct {
+	decode       func(io.Reader) (image.Image, error)
+	decodeConfig func(io.Reader) (image.Config, error)
+}{
+	"image/jpeg": {jpeg.Decode, jpeg.DecodeConfig},
+	"image/png":  {png.Decode, png.DecodeConfig},
+	"image/gif":  {gif.Decode, gif.DecodeConfig},
+}
+
+// Limits bound the uploads accepted by Process and size the images it
+// produces.
+type Limits struct {
+	// MaxBytes is the largest upload accepted.
+	MaxBytes int64
+
+	// MinDimension and MaxDimension bound both the width and the height of
+	// an upload, as it is stored before its orientation is applied. The
+	// upper bound keeps decoding within memory.
+	MinDimension int
+	MaxDimension int
+
+	// Size is the longest side of the photo produced, larger uploads are
+	// scaled down to it and smaller ones kept as they are.
+	Size int
+
+	// ThumbnailSize is the side of the square thumbnail produced, cropped
+	// from the centre of the photo.
+	ThumbnailSize int
+}
+
+// DefaultLimits returns the limits used when no others are configured.
+func DefaultLimits() Limits {
+	return Limits{
+		MaxBytes:      10 << 20,
+		MinDimension:  200,
+		MaxDimension:  6000,
+		Size:          1080,
+		ThumbnailSize: 320,
+	}
+}
+
+// Image is an encoded image, of ContentType.
+type Image struct {
+	Data   []byte
+	Width  int
+	Height int
+}
+
+// Processed holds the images produced from an upload.
+type Processed struct {
+	Photo     Image
+	Thumbnail Image
+}
+
+// Process reads an upload from r, checks it against limits and produces the
+// photo and the thumbnail stored for it.
+func Process(r io.Reader, limits Limits) (Processed, error) {
+	data, err := io.ReadAll(io.LimitReader(r, limits.MaxBytes+1))
+	if err != nil {
+		return Processed{}, err
+	}
+	if int64(len(data)) > limits.MaxBytes {
+		return Processed{}, ErrTooLarge
+	}
+
+	// the type is told by the content, whatever the upload claims to be
+	contentType := http.DetectContentType(data)
+	decoder, ok := decoders[contentType]
+	if !ok {
+		return Processed{}, fmt.Errorf("%w: %s", ErrUnsupportedType, contentType)
+	}
+
+	// the dimensions are checked before the pixels are decoded
+	config, err := decoder.decodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return Processed{}, fmt.Errorf("%w: %w", ErrInvalidImage, err)
+	}
+	for _, side := range []int{config.Width, config.Height} {
+		if side < limits.MinDimension || side > limits.MaxDimension {
+			return Processed{}, fmt.Errorf(
+				"%w: %dx%d",
+				ErrDimensions,
+				config.Width,
+				config.Height,
+			)
+		}
+	}
+
+	decoded, err := decoder.decode(bytes.NewReader(data))
+	if err != nil {
+		return Processed{}, fmt.Errorf("%w: %w", ErrInvalidImage, err)
+	}
+
+	var o orientation
+	if contentType == "image/jpeg" {
+		o = exifOrientation(data)
+	}
+	upright := o.apply(flatten(decoded))
+
+	photo, err := encode(fit(upright, limits.Size))
+	if err != nil {
+		return Processed{}, err
+	}
+	thumbnail, err := encode(square(upright, limits.ThumbnailSize))
+	if err != nil {
+		return Processed{}, err
+	}
+
+	return Processed{Photo: photo, Thumbnail: thumbnail}, nil
+}
+
+func encode(img image.Image) (Image, error) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}); err != nil {
+		return Image{}, err
+	}
+
+	return Image{
+		Data:   buf.Bytes(),
+		Width:  img.Bounds().Dx(),
+		Height: img.Bounds().Dy(),
+	}, nil
+}
@@ -0,0 +1,184 @@
+package photos
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// halves returns a w by h image, its left half red and its right half blue.
+func halves(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := color.RGBA{R: 255, A: 255}
+			if x >= w/2 {
+				c = color.RGBA{B: 255, A: 255}
+			}
+			img.SetRGBA(x, y, c)
+		}
+	}
+
+	return img
+}
+
+func encodeJPEG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, img, &jpeg.Options{Quality: 95}))
+	return buf.Bytes()
+}
+
+// withExif inserts an APP1 segment holding the orientation and a camera make
+// after the start of image marker of a JPEG image.
+func withExif(t *testing.T, data []byte, o orientation) []byte {
+	t.Helper()
+
+	camera := "SecretCam\x00"
+
+	var tiff bytes.Buffer
+	tiff.WriteString("MM\x00\x2a")
+	binary.Write(&tiff, binary.BigEndian, uint32(8))
+	binary.Write(&tiff, binary.BigEndian, uint16(2))
+	// the camera make, an ASCII string held past the IFD
+	binary.Write(&tiff, binary.BigEndian, []uint16{0x010f, 2})
+	binary.Write(&tiff, binary.BigEndian, []uint32{uint32(len(camera)), 8 + 2 + 2*12 + 4})
+	// the orientation, a short held in the entry
+	binary.Write(&tiff, binary.BigEndian, []uint16{0x0112, 3})
+	binary.Write(&tiff, binary.BigEndian, uint32(1))
+	binary.Write(&tiff, binary.BigEndian, []uint16{uint16(o), 0})
+	binary.Write(&tiff, binary.BigEndian, uint32(0))
+	tiff.WriteString(camera)
+
+	segment := append([]byte("Exif\x00\x00"), tiff.Bytes()...)
+
+	var out bytes.Buffer
+	out.Write(data[:2])
+	out.Write([]byte{0xff, 0xe1})
+	binary.Write(&out, binary.BigEndian, uint16(len(segment)+2))
+	out.Write(segment)
+	out.Write(data[2:])
+
+	return out.Bytes()
+}
+
+// colourAt reports whether the pixel of the JPEG image data at x, y is mostly
+// red or mostly blue.
+func colourAt(t *testing.T, data []byte, x, y int) string {
+	t.Helper()
+
+	img, err := jpeg.Decode(bytes.NewReader(data))
+	require.NoError(t, err)
+
+	r, _, b, _ := img.At(x, y).RGBA()
+	switch {
+	case r > 3*b:
+		return "red"
+	case b > 3*r:
+		return "blue"
+	default:
+		return "mixed"
+	}
+}
+
+func TestProcessScalesDownAndCropsTheThumbnail(t *testing.T) {
+	processed, err := Process(bytes.NewReader(encodeJPEG(t, halves(1600, 800))), DefaultLimits())
+	require.NoError(t, err)
+
+	require.Equal(t, 1080, processed.Photo.Width)
+	require.Equal(t, 540, processed.Photo.Height)
+	require.Equal(t, "red", colourAt(t, processed.Photo.Data, 10, 270))
+	require.Equal(t, "blue", colourAt(t, processed.Photo.Data, 1070, 270))
+
+	// the thumbnail is cut from the centre, where the halves meet
+	require.Equal(t, 320, processed.Thumbnail.Width)
+	require.Equal(t, 320, processed.Thumbnail.Height)
+	require.Equal(t, "red", colourAt(t, processed.Thumbnail.Data, 10, 160))
+	require.Equal(t, "blue", colourAt(t, processed.Thumbnail.Data, 310, 160))
+}
+
+func TestProcessKeepsSmallPhotosAtTheirSize(t *testing.T) {
+	processed, err := Process(bytes.NewReader(encodeJPEG(t, halves(300, 250))), DefaultLimits())
+	require.NoError(t, err)
+
+	require.Equal(t, 300, processed.Photo.Width)
+	require.Equal(t, 250, processed.Photo.Height)
+	require.Equal(t, 250, processed.Thumbnail.Width)
+	require.Equal(t, 250, processed.Thumbnail.Height)
+}
+
+func TestProcessStripsExifAndAppliesTheOrientation(t *testing.T) {
+	// stored landscape, red on the left, to be turned clockwise
+	data := withExif(t, encodeJPEG(t, halves(400, 200)), 6)
+	require.Equal(t, orientation(6), exifOrientation(data))
+
+	processed, err := Process(bytes.NewReader(data), DefaultLimits())
+	require.NoError(t, err)
+
+	require.Equal(t, 200, processed.Photo.Width)
+	require.Equal(t, 400, processed.Photo.Height)
+	require.Equal(t, "red", colourAt(t, processed.Photo.Data, 100, 10))
+	require.Equal(t, "blue", colourAt(t, processed.Photo.Data, 100, 390))
+
+	for _, img := range []Image{processed.Photo, processed.Thumbnail} {
+		require.NotContains(t, string(img.Data), "Exif")
+		require.NotContains(t, string(img.Data), "SecretCam")
+		require.Equal(t, orientation(1), exifOrientation(img.Data))
+	}
+}
+
+func TestProcessFlattensTransparency(t *testing.T) {
+	// transparent but for a red border
+	img := image.NewNRGBA(image.Rect(0, 0, 300, 300))
+	for i := 0; i < 300; i++ {
+		img.Set(i, 0, color.RGBA{R: 255, A: 255})
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+
+	processed, err := Process(&buf, DefaultLimits())
+	require.NoError(t, err)
+
+	decoded, err := jpeg.Decode(bytes.NewReader(processed.Photo.Data))
+	require.NoError(t, err)
+	r, g, b, _ := decoded.At(150, 150).RGBA()
+	require.Greater(t, min(r, g, b), uint32(0xf000), "transparency is drawn over white")
+}
+
+func TestProcessRejectsInvalidUploads(t *testing.T) {
+	limits := DefaultLimits()
+	limits.MaxBytes = 64 << 10
+
+	testCases := []struct {
+		name string
+		data []byte
+		err  error
+	}{
+		{"text", []byte("not an image at all"), ErrUnsupportedType},
+		{"html claiming nothing", []byte("<html><body>hi</body></html>"), ErrUnsupportedType},
+		{"too large", bytes.Repeat([]byte{0xff}, 64<<10+1), ErrTooLarge},
+		{"too small", encodeJPEG(t, halves(100, 400)), ErrDimensions},
+		{"too wide", encodeJPEG(t, image.NewGray(image.Rect(0, 0, 6001, 200))), ErrDimensions},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Process(bytes.NewReader(tc.data), limits)
+			require.ErrorIs(t, err, tc.err)
+		})
+	}
+
+	t.Run("truncated", func(t *testing.T) {
+		data := encodeJPEG(t, halves(400, 400))
+		_, err := Process(bytes.NewReader(data[:len(data)/2]), limits)
+		require.ErrorIs(t, err, ErrInvalidImage)
+	})
+}
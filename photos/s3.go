@@ -0,0 +1,211 @@
+package photos
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+)
+
+// S3Config configures an S3Store.
+type S3Config struct {
+	// Endpoint is the URL of the S3 compatible service, such as
+	// "https://s3.eu-west-1.amazonaws.com" or "http://minio:9000". Buckets
+	// are addressed by path, as every compatible service supports.
+	Endpoint string
+
+	// Region is the region requests are signed for, "us-east-1" when empty
+	// as expected by most compatible services.
+	Region string
+
+	Bucket          string
+	AccessKeyID     string
+	SecretAccessKey string
+
+	// PublicURL, when set, is the URL the blobs are served from in place of
+	// the bucket itself, such as that of a CDN in front of it.
+	PublicURL string
+
+	// Client sends the requests, http.DefaultClient when nil.
+	Client *http.Client
+}
+
+// S3Store is a BlobStore keeping blobs as the objects of a bucket of an S3
+// compatible service, such as Amazon S3 or MinIO. Requests are signed with
+// AWS Signature Version 4, by the signer of the AWS SDK.
+type S3Store struct {
+	cfg    S3Config
+	client *http.Client
+	now    func() time.Time
+}
+
+// NewS3Store returns an S3Store for the bucket of cfg.
+func NewS3Store(cfg S3Config) (*S3Store, error) {
+	if cfg.Endpoint == "" || cfg.Bucket == "" {
+		return nil, fmt.Errorf("s3 endpoint and bucket are required")
+	}
+	if _, err := url.Parse(cfg.Endpoint); err != nil {
+		return nil, fmt.Errorf("invalid s3 endpoint: %w", err)
+	}
+
+	cfg.Endpoint = strings.TrimSuffix(cfg.Endpoint, "/")
+	cfg.PublicURL = strings.TrimSuffix(cfg.PublicURL, "/")
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+
+	client := cfg.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return &S3Store{cfg: cfg, client: client, now: time.Now}, nil
+}
+
+func (s *S3Store) Put(
+	ctx context.Context,
+	key string,
+	data []byte,
+	contentType string,
+) error {
+	req, err := s.request(ctx, http.MethodPut, key, data)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	return s.do(req, http.StatusOK)
+}
+
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+	req, err := s.request(ctx, http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+
+	// deleting a missing object succeeds, S3 answers no content either way
+	return s.do(req, http.StatusNoContent, http.StatusOK, http.StatusNotFound)
+}
+
+func (s *S3Store) URL(key string) string {
+	if s.cfg.PublicURL != "" {
+		return s.cfg.PublicURL + "/" + uriEncode(key, false)
+	}
+
+	return s.objectURL(key)
+}
+
+// objectURL returns the URL of the object under key, the bucket addressed by
+// path.
+func (s *S3Store) objectURL(key string) string {
+	return s.cfg.Endpoint + "/" + uriEncode(s.cfg.Bucket, true) + "/" + uriEncode(key, false)
+}
+
+// request returns the request of method on the object under key, body is
+// held in full so that its hash can be signed.
+func (s *S3Store) request(
+	ctx context.Context,
+	method, key string,
+	body []byte,
+) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(
+		ctx,
+		method,
+		s.objectURL(key),
+		bytes.NewReader(body),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("X-Amz-Content-Sha256", hashHex(body))
+	return req, nil
+}
+
+// do signs and sends req, failing unless it is answered with one of the
+// statuses expected.
+func (s *S3Store) do(req *http.Request, expected ...int) error {
+	err := signV4(req, s.cfg.AccessKeyID, s.cfg.SecretAccessKey, s.cfg.Region, s.now())
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	for _, status := range expected {
+		if resp.StatusCode == status {
+			return nil
+		}
+	}
+
+	// S3 errors are described by an XML document, kept short for the logs
+	message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return fmt.Errorf(
+		"s3 %s %s: %s: %s",
+		req.Method,
+		req.URL.Path,
+		resp.Status,
+		bytes.TrimSpace(message),
+	)
+}
+
+// signV4 signs req with AWS Signature Version 4 for the s3 service, in the
+// Authorization header. The hash of the body must already be set in the
+// X-Amz-Content-Sha256 header.
+func signV4(req *http.Request, accessKeyID, secretAccessKey, region string, now time.Time) error {
+	signer := v4.NewSigner(func(o *v4.SignerOptions) {
+		// the path is escaped once, by objectURL, as S3 expects
+		o.DisableURIPathEscaping = true
+	})
+
+	return signer.SignHTTP(
+		req.Context(),
+		aws.Credentials{AccessKeyID: accessKeyID, SecretAccessKey: secretAccessKey},
+		req,
+		req.Header.Get("X-Amz-Content-Sha256"),
+		"s3",
+		region,
+		now.UTC(),
+	)
+}
+
+// uriEncode percent encodes s the way AWS signatures expect, every byte but
+// the unreserved characters; slashes are kept unless encodeSlash is set.
+func uriEncode(s string, encodeSlash bool) string {
+	const hexDigits = "0123456789ABCDEF"
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '.', c == '_', c == '~',
+			c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			b.WriteByte('%')
+			b.WriteByte(hexDigits[c>>4])
+			b.WriteByte(hexDigits[c&0xf])
+		}
+	}
+
+	return b.String()
+}
+
+func hashHex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,281 @@
+package tinydates
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"tinydates/ranking"
+	"tinydates/store"
+)
+
+// The preferences that may be made dealbreakers.
+const (
+	DealbreakerGenders  = "genders"
+	DealbreakerAge      = "age"
+	DealbreakerDistance = "distance"
+)
+
+var dealbreakers = []string{DealbreakerGenders, DealbreakerAge, DealbreakerDistance}
+
+func (td tinydates) GetPreferences(
+	ctx context.Context,
+	token string,
+) (Preferences, error) {
+	id, err := td.caller(ctx, token)
+	if err != nil {
+		return Preferences{}, err
+	}
+
+	preferences, err := td.store.GetPreferences(ctx, id)
+	if errors.Is(err, store.ErrNotFound) {
+		return Preferences{}, ErrUserNotFound.Wrap(err)
+	}
+	if err != nil {
+		td.logger.ErrorContext(ctx, "failed to get preferences", "id", id, "err", err)
+		return Preferences{}, storeError(err, ErrInternalService)
+	}
+
+	return fromStorePreferences(preferences), nil
+}
+
+func (td tinydates) SetPreferences(
+	ctx context.Context,
+	token string,
+	preferences Preferences,
+) (Preferences, error) {
+	id, err := td.caller(ctx, token)
+	if err != nil {
+		return Preferences{}, err
+	}
+
+	if err := preferences.validate(); err != nil {
+		return Preferences{}, err
+	}
+
+	// genders are stored by id, whichever spelling was supplied
+	genders, err := td.genders(preferences.Genders)
+	if err != nil {
+		return Preferences{}, err
+	}
+
+	stored := store.Preferences{
+		Genders:      genders,
+		MinAge:       preferences.MinAge,
+		MaxAge:       preferences.MaxAge,
+		MaxDistance:  preferences.MaxDistance,
+		Dealbreakers: dedupe(preferences.Dealbreakers),
+
+		ShowOutsidePreferences: preferences.ShowOutsidePreferences,
+	}
+
+	err = td.store.SetPreferences(ctx, id, stored)
+	if errors.Is(err, store.ErrNotFound) {
+		return Preferences{}, ErrUserNotFound.Wrap(err)
+	}
+	if err != nil {
+		td.logger.ErrorContext(ctx, "failed to set preferences", "id", id, "err", err)
+		return Preferences{}, storeError(err, ErrInternalService)
+	}
+
+	return fromStorePreferences(stored), nil
+}
+
+// validate checks that the preferences are within range and only name known
+// dealbreakers, the genders are checked against the taxonomy by the service.
+func (p Preferences) validate() error {
+	for _, value := range []*int{p.MinAge, p.MaxAge, p.MaxDistance} {
+		if value != nil && *value < 0 {
+			return ErrPreferencesInvalid.Wrap(errors.New("negative value"))
+		}
+	}
+	if p.MinAge != nil && p.MaxAge != nil && *p.MinAge > *p.MaxAge {
+		return ErrPreferencesInvalid.Wrap(errors.New("min age above max age"))
+	}
+
+	for _, name := range p.Dealbreakers {
+		if !slices.Contains(dealbreakers, name) {
+			return ErrPreferencesInvalid.Wrap(fmt.Errorf("unknown dealbreaker %q", name))
+		}
+	}
+
+	return nil
+}
+
+// validate checks the filters of a discovery; an age range may be open ended
+// but not inverted. The genders are checked against the taxonomy by the
+// service.
+func (req DiscoverRequest) validate() error {
+	for _, age := range []*int{req.MinAge, req.MaxAge} {
+		if age != nil && *age < 0 {
+			return ErrMinOrMaxAgeInvalid
+		}
+	}
+	if req.MinAge != nil && req.MaxAge != nil && *req.MinAge > *req.MaxAge {
+		return ErrorMinOrMaxFormat
+	}
+
+	if req.MaxDistance != nil && *req.MaxDistance < 0 {
+		return ErrMaxDistanceInvalid
+	}
+
+	return nil
+}
+
+func fromStorePreferences(p store.Preferences) Preferences {
+	return Preferences{
+		Genders:      append([]string{}, p.Genders...),
+		MinAge:       p.MinAge,
+		MaxAge:       p.MaxAge,
+		MaxDistance:  p.MaxDistance,
+		Dealbreakers: append([]string{}, p.Dealbreakers...),
+
+		ShowOutsidePreferences: p.ShowOutsidePreferences,
+	}
+}
+
+// dedupe returns values without duplicates, in the order first seen.
+func dedupe(values []string) []string {
+	deduped := make([]string, 0, len(values))
+	for _, value := range values {
+		if !slices.Contains(deduped, value) {
+			deduped = append(deduped, value)
+		}
+	}
+
+	return deduped
+}
+
+// filters narrow the profiles discovered, each one is unset when nil or
+// empty.
+type filters struct {
+	genders     []string
+	minAge      *int
+	maxAge      *int
+	maxDistance *int
+}
+
+// allows reports whether candidate meets every filter set.
+func (f filters) allows(candidate ranking.Candidate) bool {
+	switch {
+	case len(f.genders) > 0 && !slices.Contains(f.genders, candidate.Gender):
+		return false
+	case f.minAge != nil && candidate.Age < *f.minAge:
+		return false
+	case f.maxAge != nil && candidate.Age > *f.maxAge:
+		return false
+	case f.maxDistance != nil && candidate.Distance > *f.maxDistance:
+		return false
+	default:
+		return true
+	}
+}
+
+// stored returns the filters as the store applies them.
+func (f filters) stored() store.Filters {
+	return store.Filters{
+		Genders:     f.genders,
+		MinAge:      f.minAge,
+		MaxAge:      f.maxAge,
+		MaxDistance: f.maxDistance,
+	}
+}
+
+// String describes the filters set, such as "genders=female;age=25-", so
+// that discoveries with the same filters are told apart from the others.
+func (f filters) String() string {
+	bound := func(value *int) string {
+		if value == nil {
+			return ""
+		}
+		return strconv.Itoa(*value)
+	}
+
+	var parts []string
+	if len(f.genders) > 0 {
+		genders := slices.Clone(f.genders)
+		slices.Sort(genders)
+		parts = append(parts, "genders="+strings.Join(genders, ","))
+	}
+	if f.minAge != nil || f.maxAge != nil {
+		parts = append(parts, "age="+bound(f.minAge)+"-"+bound(f.maxAge))
+	}
+	if f.maxDistance != nil {
+		parts = append(parts, "distance="+bound(f.maxDistance))
+	}
+
+	return strings.Join(parts, ";")
+}
+
+// discovery is what a call to Discover looks for once the stored preferences
+// of the user are applied.
+type discovery struct {
+	// required are the filters every profile discovered meets: those of the
+	// request and the dealbreakers. The store applies them, so that the
+	// profiles left out are never read.
+	required filters
+
+	// preferred are the other preferences, the profiles meeting them are
+	// served ahead of the others.
+	preferred filters
+}
+
+// newDiscovery applies preferences to req, the filters set by req replace
+// the preference they stand for.
+func newDiscovery(req DiscoverRequest, preferences store.Preferences) discovery {
+	var d discovery
+
+	// preference returns the filters a stored preference belongs to
+	preference := func(name string) *filters {
+		if slices.Contains(preferences.Dealbreakers, name) {
+			return &d.required
+		}
+		return &d.preferred
+	}
+
+	if len(req.Genders) > 0 {
+		d.required.genders = req.Genders
+	} else {
+		preference(DealbreakerGenders).genders = preferences.Genders
+	}
+
+	if req.MinAge != nil || req.MaxAge != nil {
+		d.required.minAge, d.required.maxAge = req.MinAge, req.MaxAge
+	} else {
+		f := preference(DealbreakerAge)
+		f.minAge, f.maxAge = preferences.MinAge, preferences.MaxAge
+	}
+
+	if req.MaxDistance != nil {
+		d.required.maxDistance = req.MaxDistance
+	} else {
+		preference(DealbreakerDistance).maxDistance = preferences.MaxDistance
+	}
+
+	return d
+}
+
+// apply ranks the candidates, which meet the required filters, with ranker,
+// then moves those meeting the preferred filters ahead of the others keeping
+// the ranking within each.
+func (d discovery) apply(
+	viewer store.PotentialMatch,
+	candidates []ranking.Candidate,
+	ranker ranking.Ranker,
+) []ranking.Candidate {
+	ranked := ranker.Rank(viewer, candidates)
+
+	preferred := make([]ranking.Candidate, 0, len(ranked))
+	var others []ranking.Candidate
+	for _, candidate := range ranked {
+		if d.preferred.allows(candidate) {
+			preferred = append(preferred, candidate)
+		} else {
+			others = append(others, candidate)
+		}
+	}
+
+	return append(preferred, others...)
+}
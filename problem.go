@@ -0,0 +1,64 @@
+package tinydates
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"tinydates/logging"
+
+	"github.com/gin-gonic/gin"
+)
+
+// problemContentType is the media type of RFC 7807 error responses.
+const problemContentType = "application/problem+json"
+
+// problemTypePrefix prefixes the error code to form the problem type URI.
+const problemTypePrefix = "urn:tinydates:problem:"
+
+// problemDetails middleware is the single place where errors are turned into
+// responses. Handlers attach the error to the gin context and return, this
+// writes the matching RFC 7807 body once the handler chain has finished.
+// Errors that are not domain errors are treated as internal failures and
+// their details are never shown to the caller.
+func problemDetails(logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+
+		err := c.Errors.Last().Err
+
+		var domainErr *Error
+		if !errors.As(err, &domainErr) {
+			domainErr = ErrInternalService.Wrap(err)
+		}
+
+		if domainErr.Status >= http.StatusInternalServerError {
+			logger.ErrorContext(
+				c.Request.Context(),
+				"request failed",
+				"code", domainErr.Code,
+				"err", err,
+			)
+		}
+
+		c.Header("Content-Type", problemContentType)
+		c.JSON(domainErr.Status, NewProblemDetails(c.Request, domainErr))
+	}
+}
+
+// NewProblemDetails builds the RFC 7807 body describing err as the answer to
+// req.
+func NewProblemDetails(req *http.Request, err *Error) ProblemDetails {
+	return ProblemDetails{
+		Type:      problemTypePrefix + err.Code,
+		Title:     http.StatusText(err.Status),
+		Status:    err.Status,
+		Detail:    err.Message,
+		Instance:  req.URL.Path,
+		Code:      err.Code,
+		RequestId: logging.RequestID(req.Context()),
+	}
+}
@@ -0,0 +1,282 @@
+// Package ranking orders the profiles discovered by a user. Discovery is a
+// pipeline: the Source of a Strategy generates the candidates, Extract
+// describes each of them by its features and the Ranker of the strategy
+// orders them, for instance scoring them by weighted features then
+// re-ranking the best for diversity.
+package ranking
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+	"tinydates/rating"
+	"tinydates/store"
+)
+
+const (
+	// distanceScale is the distance at which the distance feature halves.
+	distanceScale = 10.0
+
+	// ageGapScale is the age gap, in years, at which the age gap feature
+	// halves.
+	ageGapScale = 5.0
+
+	// activityHalfLife is the time after which the recency feature of a user
+	// who stopped swiping halves.
+	activityHalfLife = 72 * time.Hour
+)
+
+// Features describe a candidate from the point of view of the viewer. Each is
+// within [0, 1] and higher is better, so that they can be weighted against
+// each other.
+type Features struct {
+	// Distance is 1 for a candidate at the location of the viewer, falling
+	// off as they get further away.
+	Distance float64
+
+	// Popularity is the probability that a user of the initial desirability
+	// likes the candidate.
+	Popularity float64
+
+	// AgeGap is 1 for a candidate of the age of the viewer, falling off as
+	// their ages differ.
+	AgeGap float64
+
+	// Recency is 1 for a candidate who has just swiped, halving every
+	// activityHalfLife since, and 0 for one who never has.
+	Recency float64
+
+	// Reciprocal is the probability that the candidate likes the viewer back,
+	// 1 when they already have.
+	Reciprocal float64
+}
+
+// Candidate is a profile being ranked for the viewer.
+type Candidate struct {
+	store.PotentialMatch
+
+	// Distance is how far the candidate is from the viewer.
+	Distance int
+
+	Features Features
+
+	// Score is the score the candidate was ranked by, only set by rankers
+	// scoring candidates such as Weighted.
+	Score float64
+}
+
+// Extract turns the profiles discovered by viewer into candidates described
+// by their features as of now, in the same order.
+func Extract(
+	viewer store.PotentialMatch,
+	profiles []store.PotentialMatch,
+	now time.Time,
+) []Candidate {
+	candidates := make([]Candidate, 0, len(profiles))
+	for _, profile := range profiles {
+		// locations are the magnitude of where users are from a common origin
+		// so, for simplicity, their difference is the distance between users
+		distance := abs(viewer.Location - profile.Location)
+
+		features := Features{
+			Distance:   falloff(float64(distance), distanceScale),
+			Popularity: rating.Expected(store.InitialDesirability, profile.Desirability),
+			AgeGap:     falloff(float64(abs(viewer.Age-profile.Age)), ageGapScale),
+			Reciprocal: rating.Expected(profile.Desirability, viewer.Desirability),
+		}
+		if !profile.LastActive.IsZero() {
+			idle := now.Sub(profile.LastActive).Hours() / activityHalfLife.Hours()
+			features.Recency = math.Pow(0.5, math.Max(idle, 0))
+		}
+		if profile.LikesMe {
+			features.Reciprocal = 1
+		}
+
+		candidates = append(candidates, Candidate{
+			PotentialMatch: profile,
+			Distance:       distance,
+			Features:       features,
+		})
+	}
+
+	return candidates
+}
+
+// Ranker orders the candidates discovered by viewer, best first. Candidates
+// may be reordered in place.
+type Ranker interface {
+	Rank(viewer store.PotentialMatch, candidates []Candidate) []Candidate
+}
+
+// Versioned is implemented by the rankers whose ranking may change while
+// they are served, such as when weights or a model are reloaded. Version
+// changes along with the ranking, so that what was ranked before is told
+// apart from what is ranked now.
+type Versioned interface {
+	Version() string
+}
+
+// ByDistance ranks the closest candidates first, ties keep their order.
+type ByDistance struct{}
+
+func (ByDistance) Rank(_ store.PotentialMatch, candidates []Candidate) []Candidate {
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Distance < candidates[j].Distance
+	})
+
+	return candidates
+}
+
+// ByDesirability ranks the most desirable candidates first, ties keep their
+// order.
+type ByDesirability struct{}
+
+func (ByDesirability) Rank(_ store.PotentialMatch, candidates []Candidate) []Candidate {
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Desirability > candidates[j].Desirability
+	})
+
+	return candidates
+}
+
+// BySharedInterests ranks the candidates sharing the most interests with the
+// viewer first, then the closest first; ties keep their order.
+type BySharedInterests struct{}
+
+func (BySharedInterests) Rank(_ store.PotentialMatch, candidates []Candidate) []Candidate {
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].SharedInterests != candidates[j].SharedInterests {
+			return candidates[i].SharedInterests > candidates[j].SharedInterests
+		}
+		return candidates[i].Distance < candidates[j].Distance
+	})
+
+	return candidates
+}
+
+// Weights of the features of a candidate in its score, along with how much
+// the best candidates are re-ranked for diversity.
+type Weights struct {
+	Distance   float64 `json:"distance"`
+	Popularity float64 `json:"popularity"`
+	AgeGap     float64 `json:"ageGap"`
+	Recency    float64 `json:"recency"`
+	Reciprocal float64 `json:"reciprocal"`
+
+	// Diversity trades score for variety among the first DiversityWindow
+	// candidates, from 0 leaving them in score order to 1 where resembling a
+	// candidate ranked before costs as much as the best possible score.
+	Diversity float64 `json:"diversity"`
+}
+
+// DefaultWeights favour candidates likely to like the viewer back and close
+// by, with some diversity.
+var DefaultWeights = Weights{
+	Distance:   1,
+	Popularity: 0.5,
+	AgeGap:     0.5,
+	Recency:    0.25,
+	Reciprocal: 1,
+	Diversity:  0.3,
+}
+
+// Score returns the weighted sum of features.
+func (w Weights) Score(features Features) float64 {
+	return w.Distance*features.Distance +
+		w.Popularity*features.Popularity +
+		w.AgeGap*features.AgeGap +
+		w.Recency*features.Recency +
+		w.Reciprocal*features.Reciprocal
+}
+
+// total is the best possible score, every feature being 1.
+func (w Weights) total() float64 {
+	return w.Distance + w.Popularity + w.AgeGap + w.Recency + w.Reciprocal
+}
+
+// DiversityWindow is the number of best candidates re-ranked for diversity,
+// those past it are seldom looked at and stay in score order.
+const DiversityWindow = 50
+
+// Weighted ranks candidates by their score under Weights, highest first, then
+// re-ranks the best of them for diversity.
+type Weighted struct {
+	Weights Weights
+}
+
+func (w Weighted) Rank(_ store.PotentialMatch, candidates []Candidate) []Candidate {
+	for i := range candidates {
+		candidates[i].Score = w.Weights.Score(candidates[i].Features)
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Score > candidates[j].Score
+	})
+
+	if w.Weights.Diversity > 0 {
+		diversify(candidates, w.Weights.Diversity*w.Weights.total())
+	}
+
+	return candidates
+}
+
+// Version describes the weights, the ranking changes with any of them.
+func (w Weighted) Version() string {
+	return fmt.Sprintf("%+v", w.Weights)
+}
+
+// diversify re-ranks the first DiversityWindow candidates, sorted by score,
+// by maximal marginal relevance: each place goes to the candidate whose score
+// minus penalty times their similarity to the closest candidate already
+// placed is the highest.
+func diversify(candidates []Candidate, penalty float64) {
+	window := min(len(candidates), DiversityWindow)
+
+	for placed := 1; placed < window; placed++ {
+		best, bestValue := placed, math.Inf(-1)
+
+		for i := placed; i < window; i++ {
+			var closest float64
+			for _, other := range candidates[:placed] {
+				closest = math.Max(closest, similarity(candidates[i], other))
+			}
+
+			if value := candidates[i].Score - penalty*closest; value > bestValue {
+				best, bestValue = i, value
+			}
+		}
+
+		// shift the chosen candidate into place keeping the others in order
+		chosen := candidates[best]
+		copy(candidates[placed+1:best+1], candidates[placed:best])
+		candidates[placed] = chosen
+	}
+}
+
+// similarity of two candidates within [0, 1], from their gender, age and
+// location.
+func similarity(a, b Candidate) float64 {
+	var gender float64
+	if a.Gender == b.Gender {
+		gender = 1
+	}
+
+	age := falloff(float64(abs(a.Age-b.Age)), ageGapScale)
+	location := falloff(float64(abs(a.Location-b.Location)), distanceScale)
+
+	return (gender + age + location) / 3
+}
+
+// falloff is 1 at 0, halving at scale and tending to 0 beyond.
+func falloff(value, scale float64) float64 {
+	return 1 / (1 + value/scale)
+}
+
+func abs(value int) int {
+	if value < 0 {
+		return -value
+	}
+
+	return value
+}
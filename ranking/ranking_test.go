@@ -0,0 +1,184 @@
+package ranking_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"tinydates/ranking"
+	"tinydates/store"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtract(t *testing.T) {
+	now := time.Date(2026, time.October, 1, 12, 0, 0, 0, time.UTC)
+	viewer := store.PotentialMatch{Id: 1, Age: 30, Location: 10, Desirability: 1500}
+
+	candidates := ranking.Extract(viewer, []store.PotentialMatch{
+		{Id: 2, Age: 30, Location: 10, Desirability: 1500, LastActive: now, LikesMe: true},
+		{Id: 3, Age: 35, Location: 20, Desirability: 1900, LastActive: now.Add(-72 * time.Hour)},
+		{Id: 4, Age: 25, Location: 0, Desirability: 1100},
+	}, now)
+
+	require.Len(t, candidates, 3)
+	require.Equal(t, []int{0, 10, 10}, []int{
+		candidates[0].Distance,
+		candidates[1].Distance,
+		candidates[2].Distance,
+	})
+
+	same := candidates[0].Features
+	require.Equal(t, ranking.Features{
+		Distance:   1,
+		Popularity: 0.5,
+		AgeGap:     1,
+		Recency:    1,
+		Reciprocal: 1,
+	}, same)
+
+	far := candidates[1].Features
+	require.Equal(t, 0.5, far.Distance)
+	require.Equal(t, 0.5, far.AgeGap)
+	require.InDelta(t, 0.5, far.Recency, 1e-9)
+	require.Greater(t, far.Popularity, 0.5)
+	// a desirable candidate is less likely to like the viewer back
+	require.Less(t, far.Reciprocal, 0.5)
+
+	// a candidate who never swiped has no recency
+	require.Zero(t, candidates[2].Features.Recency)
+	require.Less(t, candidates[2].Features.Popularity, 0.5)
+}
+
+func TestByDistance(t *testing.T) {
+	candidates := ranking.ByDistance{}.Rank(store.PotentialMatch{}, []ranking.Candidate{
+		{PotentialMatch: store.PotentialMatch{Id: 1}, Distance: 5},
+		{PotentialMatch: store.PotentialMatch{Id: 2}, Distance: 1},
+		{PotentialMatch: store.PotentialMatch{Id: 3}, Distance: 5},
+	})
+
+	require.Equal(t, []int{2, 1, 3}, ids(candidates))
+}
+
+func TestBySharedInterests(t *testing.T) {
+	candidates := ranking.BySharedInterests{}.Rank(store.PotentialMatch{}, []ranking.Candidate{
+		{PotentialMatch: store.PotentialMatch{Id: 1, SharedInterests: 1}, Distance: 5},
+		{PotentialMatch: store.PotentialMatch{Id: 2, SharedInterests: 0}, Distance: 0},
+		{PotentialMatch: store.PotentialMatch{Id: 3, SharedInterests: 3}, Distance: 9},
+		{PotentialMatch: store.PotentialMatch{Id: 4, SharedInterests: 1}, Distance: 2},
+	})
+
+	require.Equal(t, []int{3, 4, 1, 2}, ids(candidates))
+}
+
+func TestWeighted(t *testing.T) {
+	candidates := func() []ranking.Candidate {
+		return []ranking.Candidate{
+			{
+				PotentialMatch: store.PotentialMatch{Id: 1, Gender: "F", Age: 30, Location: 1},
+				Features:       ranking.Features{Distance: 1, Reciprocal: 0.2},
+			},
+			{
+				PotentialMatch: store.PotentialMatch{Id: 2, Gender: "F", Age: 30, Location: 1},
+				Features:       ranking.Features{Distance: 1, Reciprocal: 0.1},
+			},
+			{
+				PotentialMatch: store.PotentialMatch{Id: 3, Gender: "M", Age: 50, Location: 90},
+				Features:       ranking.Features{Distance: 0.5, Reciprocal: 0.5},
+			},
+		}
+	}
+
+	weights := ranking.Weights{Distance: 1, Reciprocal: 1}
+	ranked := ranking.Weighted{Weights: weights}.Rank(store.PotentialMatch{}, candidates())
+	require.Equal(t, []int{1, 2, 3}, ids(ranked))
+	require.InDelta(t, 1.2, ranked[0].Score, 1e-9)
+
+	// the twin of the best candidate gives way to one unlike them
+	weights.Diversity = 0.5
+	ranked = ranking.Weighted{Weights: weights}.Rank(store.PotentialMatch{}, candidates())
+	require.Equal(t, []int{1, 3, 2}, ids(ranked))
+}
+
+func TestStrategies(t *testing.T) {
+	strategies := ranking.NewStrategies()
+
+	for _, name := range []string{
+		"",
+		ranking.Distance,
+		ranking.Popularity,
+		ranking.Interests,
+		ranking.Recommended,
+	} {
+		_, ok := strategies.Get(name)
+		require.True(t, ok, name)
+	}
+	_, ok := strategies.Get("nearby")
+	require.False(t, ok)
+
+	weights, err := ranking.ReadWeights(strings.NewReader(
+		`{"nearby": {"distance": 1, "diversity": 0.1}}`,
+	))
+	require.NoError(t, err)
+	require.NoError(t, strategies.SetWeights(weights))
+
+	nearby, ok := strategies.Get("nearby")
+	require.True(t, ok)
+	require.Equal(t, ranking.Weighted{Weights: ranking.Weights{Distance: 1, Diversity: 0.1}}, nearby.Ranker)
+
+	// recommended is always served, with its default weights unless replaced
+	recommended, ok := strategies.Get(ranking.Recommended)
+	require.True(t, ok)
+	require.Equal(t, ranking.Weighted{Weights: ranking.DefaultWeights}, recommended.Ranker)
+	require.Equal(
+		t,
+		[]string{
+			ranking.Distance,
+			ranking.Interests,
+			"nearby",
+			ranking.Popularity,
+			ranking.Recommended,
+		},
+		strategies.Names(),
+	)
+}
+
+func TestInvalidWeights(t *testing.T) {
+	for name, weights := range map[string]string{
+		"unknown feature":    `{"nearby": {"height": 1}}`,
+		"negative weight":    `{"nearby": {"distance": -1}}`,
+		"too much diversity": `{"nearby": {"diversity": 2}}`,
+		"built in strategy":  `{"distance": {"distance": 1}}`,
+		"not an object":      `[]`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			strategies := ranking.NewStrategies()
+
+			parsed, err := ranking.ReadWeights(strings.NewReader(weights))
+			if err == nil {
+				err = strategies.SetWeights(parsed)
+			}
+			require.Error(t, err)
+
+			// the strategies in use are left as they were
+			require.Equal(
+				t,
+				[]string{
+					ranking.Distance,
+					ranking.Interests,
+					ranking.Popularity,
+					ranking.Recommended,
+				},
+				strategies.Names(),
+			)
+		})
+	}
+}
+
+func ids(candidates []ranking.Candidate) []int {
+	ids := make([]int, 0, len(candidates))
+	for _, candidate := range candidates {
+		ids = append(ids, candidate.Id)
+	}
+
+	return ids
+}
@@ -0,0 +1,226 @@
+package ranking
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"tinydates/store"
+)
+
+// Names of the built in strategies.
+const (
+	// Distance ranks the profiles not swiped yet closest first, the default.
+	Distance = "distance"
+
+	// Popularity ranks every other profile most desirable first, including
+	// those already swiped.
+	Popularity = "popularity"
+
+	// Interests ranks the profiles not swiped yet by the interests they share
+	// with the viewer, most first, then closest first.
+	Interests = "interests"
+
+	// Recommended ranks the profiles not swiped yet by DefaultWeights, unless
+	// weights are configured for it.
+	Recommended = "recommended"
+)
+
+// Source generates the candidate profiles meeting filters for the user with
+// the supplied id.
+type Source func(
+	ctx context.Context,
+	s store.Store,
+	id int,
+	filters store.Filters,
+) ([]store.PotentialMatch, error)
+
+// Unswiped generates the profiles the user has not swiped yet.
+func Unswiped(
+	ctx context.Context,
+	s store.Store,
+	id int,
+	filters store.Filters,
+) ([]store.PotentialMatch, error) {
+	return s.Discover(ctx, id, filters)
+}
+
+// Everyone generates every other profile, most desirable first.
+func Everyone(
+	ctx context.Context,
+	s store.Store,
+	id int,
+	filters store.Filters,
+) ([]store.PotentialMatch, error) {
+	return s.DiscoverByPopularity(ctx, id, filters)
+}
+
+// Shared generates the profiles the user has not swiped yet, those sharing
+// the most interests with them first.
+func Shared(
+	ctx context.Context,
+	s store.Store,
+	id int,
+	filters store.Filters,
+) ([]store.PotentialMatch, error) {
+	return s.DiscoverBySharedInterests(ctx, id, filters)
+}
+
+// Strategy is a way of discovering profiles, selected by name with the sort
+// parameter of discovery.
+type Strategy struct {
+	Source Source
+	Ranker Ranker
+
+	// Revisits is whether Source includes the profiles already swiped, such
+	// strategies are ranked afresh on every discovery rather than served
+	// from a deck.
+	Revisits bool
+}
+
+// Strategies are the named strategies of discovery. Strategies implemented
+// elsewhere are registered with Add and the weighted strategies can be
+// replaced at runtime with SetWeights, it is safe for concurrent use.
+type Strategies struct {
+	mu       sync.RWMutex
+	added    map[string]Strategy
+	weighted map[string]Weights
+}
+
+// NewStrategies returns the built in strategies, with Recommended weighted by
+// DefaultWeights.
+func NewStrategies() *Strategies {
+	return &Strategies{
+		added:    make(map[string]Strategy),
+		weighted: map[string]Weights{Recommended: DefaultWeights},
+	}
+}
+
+// Get returns the strategy with the supplied name, Distance when it is empty,
+// and whether there is one.
+func (s *Strategies) Get(name string) (Strategy, bool) {
+	switch name {
+	case "", Distance:
+		return Strategy{Source: Unswiped, Ranker: ByDistance{}}, true
+	case Popularity:
+		return Strategy{Source: Everyone, Ranker: ByDesirability{}, Revisits: true}, true
+	case Interests:
+		return Strategy{Source: Shared, Ranker: BySharedInterests{}}, true
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if strategy, ok := s.added[name]; ok {
+		return strategy, true
+	}
+
+	weights, ok := s.weighted[name]
+	if !ok {
+		return Strategy{}, false
+	}
+
+	return Strategy{Source: Unswiped, Ranker: Weighted{Weights: weights}}, true
+}
+
+// Names returns the names of every strategy, sorted.
+func (s *Strategies) Names() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	names := []string{Distance, Interests, Popularity}
+	for name := range s.added {
+		names = append(names, name)
+	}
+	for name := range s.weighted {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Add registers strategy under name, replacing the weighted strategy of the
+// same name if there is one. The built in Distance, Popularity and Interests
+// cannot be replaced.
+func (s *Strategies) Add(name string, strategy Strategy) error {
+	switch name {
+	case "", Distance, Popularity, Interests:
+		return fmt.Errorf("strategy %q cannot be replaced", name)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.weighted, name)
+	s.added[name] = strategy
+	return nil
+}
+
+// SetWeights replaces the weighted strategies by those in weights, plus
+// Recommended weighted by DefaultWeights when weights has no entry for it and
+// it was not added. Nothing is replaced when any of them is invalid.
+func (s *Strategies) SetWeights(weights map[string]Weights) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	weighted := make(map[string]Weights, len(weights)+1)
+	if _, ok := s.added[Recommended]; !ok {
+		weighted[Recommended] = DefaultWeights
+	}
+	for name, w := range weights {
+		if _, ok := s.added[name]; ok {
+			return fmt.Errorf("strategy %q cannot be weighted", name)
+		}
+		if err := validate(name, w); err != nil {
+			return err
+		}
+		weighted[name] = w
+	}
+
+	s.weighted = weighted
+	return nil
+}
+
+// validate checks that a weighted strategy can be used.
+func validate(name string, w Weights) error {
+	switch name {
+	case "", Distance, Popularity, Interests:
+		return fmt.Errorf("strategy %q cannot be weighted", name)
+	}
+
+	for feature, weight := range map[string]float64{
+		"distance":   w.Distance,
+		"popularity": w.Popularity,
+		"ageGap":     w.AgeGap,
+		"recency":    w.Recency,
+		"reciprocal": w.Reciprocal,
+	} {
+		if weight < 0 {
+			return fmt.Errorf("strategy %q: %s weight must not be negative", name, feature)
+		}
+	}
+
+	if w.Diversity < 0 || w.Diversity > 1 {
+		return fmt.Errorf("strategy %q: diversity must be between 0 and 1", name)
+	}
+
+	return nil
+}
+
+// ReadWeights reads the weights of strategies by name from a JSON object such
+// as {"recommended": {"distance": 1, "reciprocal": 2, "diversity": 0.2}},
+// features left out weigh nothing.
+func ReadWeights(r io.Reader) (map[string]Weights, error) {
+	var weights map[string]Weights
+
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&weights); err != nil {
+		return nil, fmt.Errorf("invalid ranking weights: %w", err)
+	}
+
+	return weights, nil
+}
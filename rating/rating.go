@@ -0,0 +1,92 @@
+// Package rating scores how desirable users are from the swipes they receive.
+//
+// Every swipe is an Elo match between the swiper and the swipee, which the
+// swipee wins when liked. The swiper is weighed by their own desirability,
+// how liked they are, not by how often they like others: a like from a user
+// rated above the swipee is worth more than one from a user rated below them,
+// and a pass from them costs less; so a profile can no longer climb the
+// rankings on raw traffic alone. Elo is preferred to Glicko-2 as only one side
+// of each match is rated and swipes arrive one at a time rather than in
+// rating periods.
+package rating
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"tinydates/store"
+)
+
+// K bounds how far a single swipe moves the desirability of the swipee.
+const K = 32.0
+
+// scale is the rating difference at which the higher rated side is expected
+// to win ten times out of eleven.
+const scale = 400.0
+
+// Expected returns the probability that a swiper rated swiper likes a swipee
+// rated swipee.
+func Expected(swiper, swipee float64) float64 {
+	return 1 / (1 + math.Pow(10, (swiper-swipee)/scale))
+}
+
+// Delta returns the change in the desirability of the swipee after a swipe
+// deciding liked, given the desirability of both users beforehand. The
+// desirability of the swiper is left as it is, swiping says nothing of how
+// desirable they are.
+func Delta(swiper, swipee float64, liked bool) float64 {
+	outcome := 0.0
+	if liked {
+		outcome = 1
+	}
+
+	return K * (outcome - Expected(swiper, swipee))
+}
+
+// Rate updates the desirability of the swipee of a swipe. The scores are
+// read and the swipee updated atomically, so swipes rated concurrently are
+// each rated against the score left by the others.
+func Rate(ctx context.Context, s store.Store, swipe store.NewSwipe) error {
+	if err := s.RateDesirability(
+		ctx,
+		swipe.Swiper,
+		swipe.Swipee,
+		func(swiper, swipee float64) float64 {
+			return Delta(swiper, swipee, swipe.Decision)
+		},
+	); err != nil {
+		return fmt.Errorf("failed to rate desirability: %w", err)
+	}
+
+	return nil
+}
+
+// Backfill recomputes the desirability of every user by replaying all the
+// swipes in the order they were made, as if each had been rated when it was
+// made, returning the number of swipes replayed. Swipes made while it runs
+// are overwritten, they are caught up by running it again.
+func Backfill(ctx context.Context, s store.Backfiller) (int, error) {
+	scores := make(map[int]float64)
+	score := func(id int) float64 {
+		if score, ok := scores[id]; ok {
+			return score
+		}
+		return store.InitialDesirability
+	}
+
+	var replayed int
+	if err := s.ReplaySwipes(ctx, func(swipe store.NewSwipe) error {
+		scores[swipe.Swipee] = score(swipe.Swipee) +
+			Delta(score(swipe.Swiper), score(swipe.Swipee), swipe.Decision)
+		replayed++
+		return nil
+	}); err != nil {
+		return 0, fmt.Errorf("failed to replay swipes: %w", err)
+	}
+
+	if err := s.ResetDesirability(ctx, scores); err != nil {
+		return 0, fmt.Errorf("failed to store desirability: %w", err)
+	}
+
+	return replayed, nil
+}
@@ -0,0 +1,122 @@
+package rating_test
+
+import (
+	"context"
+	"testing"
+	"time"
+	"tinydates/rating"
+	"tinydates/store"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpected(t *testing.T) {
+	require.Equal(t, 0.5, rating.Expected(1500, 1500))
+	require.InDelta(t, 1.0/11, rating.Expected(1900, 1500), 1e-9)
+	require.InDelta(t, 1, rating.Expected(1500, 1700)+rating.Expected(1700, 1500), 1e-9)
+}
+
+func TestDelta(t *testing.T) {
+	// a like is worth more from a swiper rated above the swipee
+	require.Greater(t, rating.Delta(1700, 1500, true), rating.Delta(1300, 1500, true))
+	// and a pass costs less
+	require.Greater(t, rating.Delta(1700, 1500, false), rating.Delta(1300, 1500, false))
+
+	require.Equal(t, rating.K/2, rating.Delta(1500, 1500, true))
+	require.Equal(t, -rating.K/2, rating.Delta(1500, 1500, false))
+
+	for _, liked := range []bool{true, false} {
+		delta := rating.Delta(1500, 1900, liked)
+		require.LessOrEqual(t, delta, rating.K)
+		require.GreaterOrEqual(t, delta, -rating.K)
+	}
+}
+
+func TestRate(t *testing.T) {
+	ctx := context.Background()
+	s := store.NewTinydatesInMemoryStore()
+
+	swiper := newUser(t, s, "swiper")
+	swipee := newUser(t, s, "swipee")
+
+	require.NoError(t, rating.Rate(ctx, s, store.NewSwipe{
+		Swiper:   swiper,
+		Swipee:   swipee,
+		Decision: true,
+	}))
+
+	desirability, err := s.GetDesirability(ctx, swipee)
+	require.NoError(t, err)
+	require.Equal(t, store.InitialDesirability+rating.K/2, desirability)
+
+	// the swiper is not rated
+	desirability, err = s.GetDesirability(ctx, swiper)
+	require.NoError(t, err)
+	require.Equal(t, store.InitialDesirability, desirability)
+
+	require.ErrorIs(t, rating.Rate(ctx, s, store.NewSwipe{
+		Swiper: swiper,
+		Swipee: swipee + 100,
+	}), store.ErrNotFound)
+}
+
+func TestBackfillReplaysTheRatingOfEverySwipe(t *testing.T) {
+	ctx := context.Background()
+	s := store.NewTinydatesInMemoryStore()
+
+	users := make([]int, 0, 5)
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		users = append(users, newUser(t, s, name))
+	}
+
+	// every swipe rated as it is made, as the service does
+	for i := 0; i < 40; i++ {
+		swipe := store.NewSwipe{
+			Swiper:   users[i%len(users)],
+			Swipee:   users[(i*3+1)%len(users)],
+			Decision: i%3 != 0,
+		}
+		_, err := s.Swipe(ctx, swipe.Swiper, swipe.Swipee, swipe.Decision)
+		require.NoError(t, err)
+		require.NoError(t, rating.Rate(ctx, s, swipe))
+	}
+
+	rated := desirabilities(t, s, users)
+
+	// scores drift away from the history, then are recomputed from it
+	require.NoError(t, s.AdjustDesirability(ctx, users[0], 250))
+
+	replayed, err := rating.Backfill(ctx, s.(store.Backfiller))
+	require.NoError(t, err)
+	require.Equal(t, 40, replayed)
+
+	backfilled := desirabilities(t, s, users)
+	for _, id := range users {
+		require.InDelta(t, rated[id], backfilled[id], 1e-9)
+	}
+}
+
+func newUser(t *testing.T, s store.Store, name string) int {
+	id, err := s.StoreNewUser(
+		context.Background(),
+		name+"@mail.com",
+		"password",
+		name,
+		"other",
+		time.Now().AddDate(-30, 0, 0),
+		0,
+	)
+	require.NoError(t, err)
+	return id
+}
+
+func desirabilities(t *testing.T, s store.Store, ids []int) map[int]float64 {
+	scores := make(map[int]float64, len(ids))
+	for _, id := range ids {
+		desirability, err := s.GetDesirability(context.Background(), id)
+		require.NoError(t, err)
+		scores[id] = desirability
+	}
+
+	return scores
+}
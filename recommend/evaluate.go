@@ -0,0 +1,149 @@
+package recommend
+
+import (
+	"sort"
+	"tinydates/store"
+)
+
+// Evaluation measures how well a model predicts mutual matches.
+type Evaluation struct {
+	// K is the number of recommendations looked at for each user.
+	K int
+
+	// Trained and HeldOut are the number of swipes the model was trained on
+	// and of those held out to evaluate it.
+	Trained, HeldOut int
+
+	// Users is the number of users evaluated, those with history who went on
+	// to match in the held out swipes.
+	Users int
+
+	// Hits is the number of recommendations that became a match.
+	Hits int
+
+	// Precision is the precision at K of the recommendations, the share of
+	// them that became a match.
+	Precision float64
+
+	// Baseline is the precision at K of recommending the most liked profiles,
+	// for comparison.
+	Baseline float64
+}
+
+// Evaluate trains a model on the first swipes, holding out the last share of
+// them, then measures the precision at k of the recommendations it makes for
+// predicting the matches made in the held out swipes. A recommendation is a
+// hit when the user goes on to like the profile, which liked them back.
+func Evaluate(
+	swipes []store.NewSwipe,
+	holdout float64,
+	k int,
+	neighbours int,
+) Evaluation {
+	split := len(swipes) - int(float64(len(swipes))*holdout)
+	train, heldOut := swipes[:split], swipes[split:]
+
+	m := Fit(train, neighbours)
+
+	// every favourable swipe, for matches completed in the held out swipes
+	// by either side
+	liked := make(map[[2]int]bool)
+	for _, swipe := range swipes {
+		if swipe.Decision {
+			liked[[2]int{swipe.Swiper, swipe.Swipee}] = true
+		}
+	}
+
+	matched := make(map[int]map[int]bool)
+	for _, swipe := range heldOut {
+		if swipe.Decision && liked[[2]int{swipe.Swipee, swipe.Swiper}] {
+			if matched[swipe.Swiper] == nil {
+				matched[swipe.Swiper] = make(map[int]bool)
+			}
+			matched[swipe.Swiper][swipe.Swipee] = true
+		}
+	}
+
+	// profiles already swiped before the held out swipes are not candidates
+	swiped := make(map[int]map[int]bool)
+	popularity := make(map[int]int)
+	for _, swipe := range train {
+		if swiped[swipe.Swiper] == nil {
+			swiped[swipe.Swiper] = make(map[int]bool)
+		}
+		swiped[swipe.Swiper][swipe.Swipee] = true
+		if swipe.Decision {
+			popularity[swipe.Swipee]++
+		}
+	}
+	popular := rank(popularity)
+
+	evaluation := Evaluation{K: k, Trained: len(train), HeldOut: len(heldOut)}
+
+	var baselineHits int
+	for id, matches := range matched {
+		if len(m.Liked[id]) == 0 {
+			// users without history are ranked by distance instead
+			continue
+		}
+
+		candidate := func(other int) bool {
+			return other != id && !swiped[id][other]
+		}
+
+		evaluation.Users++
+		evaluation.Hits += hits(top(rank(m.Scores(id)), candidate, k), matches)
+		baselineHits += hits(top(popular, candidate, k), matches)
+	}
+
+	if evaluation.Users > 0 {
+		recommended := float64(evaluation.Users * k)
+		evaluation.Precision = float64(evaluation.Hits) / recommended
+		evaluation.Baseline = float64(baselineHits) / recommended
+	}
+
+	return evaluation
+}
+
+// rank returns the ids of scores, highest score first.
+func rank[T int | float64](scores map[int]T) []int {
+	ids := make([]int, 0, len(scores))
+	for id := range scores {
+		ids = append(ids, id)
+	}
+
+	sort.Slice(ids, func(i, j int) bool {
+		if scores[ids[i]] != scores[ids[j]] {
+			return scores[ids[i]] > scores[ids[j]]
+		}
+		return ids[i] < ids[j]
+	})
+
+	return ids
+}
+
+// top returns the first k of ranked that are candidates.
+func top(ranked []int, candidate func(int) bool, k int) []int {
+	found := make([]int, 0, k)
+	for _, id := range ranked {
+		if len(found) == k {
+			break
+		}
+		if candidate(id) {
+			found = append(found, id)
+		}
+	}
+
+	return found
+}
+
+func hits(recommended []int, matches map[int]bool) int {
+	var hits int
+	for _, id := range recommended {
+		if matches[id] {
+			hits++
+		}
+	}
+
+	return hits
+}
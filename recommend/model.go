@@ -0,0 +1,105 @@
+// Package recommend recommends profiles by collaborative filtering of the
+// history of swipes: users who liked the same profiles as the viewer also
+// liked those recommended. A Model is trained offline from the swipes, saved
+// to disk and served by a Recommender that ranks the candidates of discovery
+// with it, falling back to distance for users without history.
+package recommend
+
+import (
+	"encoding/gob"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Neighbour is a profile liked alongside another.
+type Neighbour struct {
+	Id int
+
+	// Similarity is the cosine similarity of the users who liked both
+	// profiles, within (0, 1].
+	Similarity float64
+}
+
+// Model is a trained item to item collaborative filter.
+type Model struct {
+	// TrainedAt is when the model was trained.
+	TrainedAt time.Time
+
+	// Swipes is the number of swipes the model was trained on.
+	Swipes int
+
+	// Liked holds the profiles liked by each user, in the order they were
+	// liked.
+	Liked map[int][]int
+
+	// Neighbours holds the profiles most often liked alongside each liked
+	// profile, most similar first.
+	Neighbours map[int][]Neighbour
+}
+
+// Scores returns the recommendation score of every profile liked alongside
+// those the user with the supplied id liked, the sum of their similarities.
+// It is empty for users without history.
+func (m *Model) Scores(id int) map[int]float64 {
+	scores := make(map[int]float64)
+	for _, liked := range m.Liked[id] {
+		for _, neighbour := range m.Neighbours[liked] {
+			scores[neighbour.Id] += neighbour.Similarity
+		}
+	}
+
+	return scores
+}
+
+// Write encodes the model to w.
+func (m *Model) Write(w io.Writer) error {
+	return gob.NewEncoder(w).Encode(m)
+}
+
+// Read decodes a model written by Write from r.
+func Read(r io.Reader) (*Model, error) {
+	var m Model
+	if err := gob.NewDecoder(r).Decode(&m); err != nil {
+		return nil, fmt.Errorf("invalid recommendation model: %w", err)
+	}
+
+	return &m, nil
+}
+
+// Save writes the model to the file at path. The file is replaced at once so
+// that a server reloading it never reads a partial model.
+func (m *Model) Save(path string) error {
+	file, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("unable to save the recommendation model: %w", err)
+	}
+	defer os.Remove(file.Name())
+
+	if err := m.Write(file); err != nil {
+		file.Close()
+		return fmt.Errorf("unable to save the recommendation model: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("unable to save the recommendation model: %w", err)
+	}
+
+	if err := os.Rename(file.Name(), path); err != nil {
+		return fmt.Errorf("unable to save the recommendation model: %w", err)
+	}
+
+	return nil
+}
+
+// Load reads the model saved at path.
+func Load(path string) (*Model, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open the recommendation model: %w", err)
+	}
+	defer file.Close()
+
+	return Read(file)
+}
@@ -0,0 +1,149 @@
+package recommend_test
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+	"tinydates/ranking"
+	"tinydates/recommend"
+	"tinydates/store"
+
+	"github.com/stretchr/testify/require"
+)
+
+// history has users 1 and 2 liking 3 and 4 together, 2 also liking 5.
+var history = []store.NewSwipe{
+	{Swiper: 1, Swipee: 3, Decision: true},
+	{Swiper: 1, Swipee: 4, Decision: true},
+	{Swiper: 1, Swipee: 6, Decision: false},
+	{Swiper: 2, Swipee: 3, Decision: true},
+	{Swiper: 2, Swipee: 4, Decision: true},
+	{Swiper: 2, Swipee: 5, Decision: true},
+}
+
+func TestScores(t *testing.T) {
+	m := recommend.Fit(history, recommend.DefaultNeighbours)
+
+	require.Equal(t, len(history), m.Swipes)
+	require.Equal(t, map[int][]int{1: {3, 4}, 2: {3, 4, 5}}, m.Liked)
+	require.Equal(t, []recommend.Neighbour{{Id: 4, Similarity: 1}}, m.Neighbours[3][:1])
+
+	// 5 was liked alongside both of the profiles 1 liked
+	scores := m.Scores(1)
+	require.InDelta(t, 1, scores[3], 1e-9)
+	require.InDelta(t, 1, scores[4], 1e-9)
+	require.InDelta(t, 2/1.4142135623730951, scores[5], 1e-9)
+	require.NotContains(t, scores, 6)
+
+	require.Empty(t, m.Scores(6))
+
+	// only the most similar neighbours are kept
+	require.Len(t, recommend.Fit(history, 1).Neighbours[3], 1)
+}
+
+func TestTrainOnTheHistoryOfAStore(t *testing.T) {
+	ctx := context.Background()
+	s := store.NewTinydatesInMemoryStore()
+
+	ids := make([]int, 0, 3)
+	for _, name := range []string{"a", "b", "c"} {
+		id, err := s.StoreNewUser(ctx, name+"@mail.com", "password", name, "other", time.Now().AddDate(-30, 0, 0), 0)
+		require.NoError(t, err)
+		ids = append(ids, id)
+	}
+	for _, swipee := range ids[1:] {
+		_, err := s.Swipe(ctx, ids[0], swipee, true)
+		require.NoError(t, err)
+	}
+
+	m, err := recommend.Train(ctx, s.(store.Backfiller), recommend.DefaultNeighbours)
+	require.NoError(t, err)
+	require.Equal(t, 2, m.Swipes)
+	require.Equal(t, []int{ids[1], ids[2]}, m.Liked[ids[0]])
+	require.Equal(t, ids[2], m.Neighbours[ids[1]][0].Id)
+}
+
+func TestModelRoundTrip(t *testing.T) {
+	m := recommend.Fit(history, recommend.DefaultNeighbours)
+
+	var buf bytes.Buffer
+	require.NoError(t, m.Write(&buf))
+	read, err := recommend.Read(&buf)
+	require.NoError(t, err)
+	require.Equal(t, m.Neighbours, read.Neighbours)
+	require.Equal(t, m.Liked, read.Liked)
+	require.True(t, m.TrainedAt.Equal(read.TrainedAt))
+
+	path := filepath.Join(t.TempDir(), "model")
+	require.NoError(t, m.Save(path))
+	loaded, err := recommend.Load(path)
+	require.NoError(t, err)
+	require.Equal(t, m.Neighbours, loaded.Neighbours)
+
+	// the temporary file is renamed into place
+	files, err := filepath.Glob(filepath.Join(filepath.Dir(path), "*"))
+	require.NoError(t, err)
+	require.Equal(t, []string{path}, files)
+
+	_, err = recommend.Read(bytes.NewBufferString("not a model"))
+	require.Error(t, err)
+}
+
+func TestRecommenderRanksByScoreThenDistance(t *testing.T) {
+	candidates := func() []ranking.Candidate {
+		return []ranking.Candidate{
+			{PotentialMatch: store.PotentialMatch{Id: 2}, Distance: 1},
+			{PotentialMatch: store.PotentialMatch{Id: 5}, Distance: 9},
+			{PotentialMatch: store.PotentialMatch{Id: 6}, Distance: 5},
+		}
+	}
+	rank := func(r *recommend.Recommender, viewer int) []int {
+		var ids []int
+		for _, candidate := range r.Rank(store.PotentialMatch{Id: viewer}, candidates()) {
+			ids = append(ids, candidate.Id)
+		}
+		return ids
+	}
+
+	// without a model every user falls back to distance
+	recommender := recommend.NewRecommender(nil)
+	require.Equal(t, []int{2, 6, 5}, rank(recommender, 1))
+
+	recommender.Set(recommend.Fit(history, recommend.DefaultNeighbours))
+	require.Equal(t, []int{5, 2, 6}, rank(recommender, 1))
+	// as do users without history
+	require.Equal(t, []int{2, 6, 5}, rank(recommender, 6))
+
+	strategies := ranking.NewStrategies()
+	require.NoError(t, strategies.Add(recommend.Name, recommender.Strategy()))
+	strategy, ok := strategies.Get(recommend.Name)
+	require.True(t, ok)
+	require.Same(t, recommender, strategy.Ranker)
+}
+
+func TestEvaluate(t *testing.T) {
+	swipes := append([]store.NewSwipe{}, history...)
+	swipes = append(
+		swipes,
+		// 7 is among the most liked, 5 likes 1
+		store.NewSwipe{Swiper: 3, Swipee: 7, Decision: true},
+		store.NewSwipe{Swiper: 4, Swipee: 7, Decision: true},
+		store.NewSwipe{Swiper: 5, Swipee: 1, Decision: true},
+		// held out, 1 matches with 5
+		store.NewSwipe{Swiper: 1, Swipee: 5, Decision: true},
+	)
+
+	evaluation := recommend.Evaluate(swipes, 0.1, 1, recommend.DefaultNeighbours)
+
+	require.Equal(t, recommend.Evaluation{
+		K:         1,
+		Trained:   9,
+		HeldOut:   1,
+		Users:     1,
+		Hits:      1,
+		Precision: 1,
+		Baseline:  0,
+	}, evaluation)
+}
@@ -0,0 +1,84 @@
+package recommend
+
+import (
+	"sort"
+	"sync/atomic"
+	"time"
+	"tinydates/ranking"
+	"tinydates/store"
+)
+
+// Name is the name of the strategy ranking by collaborative filtering,
+// selected with the sort parameter of discovery.
+const Name = "collaborative"
+
+// Recommender ranks candidates with the model it serves, which can be
+// replaced at any time, it is safe for concurrent use.
+type Recommender struct {
+	model atomic.Pointer[Model]
+}
+
+// NewRecommender returns a recommender serving m, which may be nil until a
+// model has been trained.
+func NewRecommender(m *Model) *Recommender {
+	r := &Recommender{}
+	r.model.Store(m)
+	return r
+}
+
+// Model returns the model being served, nil when there is none.
+func (r *Recommender) Model() *Model {
+	return r.model.Load()
+}
+
+// Set serves m from now on.
+func (r *Recommender) Set(m *Model) {
+	r.model.Store(m)
+}
+
+// Strategy returns the strategy ranking the profiles not swiped yet with the
+// recommender.
+func (r *Recommender) Strategy() ranking.Strategy {
+	return ranking.Strategy{Source: ranking.Unswiped, Ranker: r}
+}
+
+// Version is when the model being served was trained, empty when there is
+// none.
+func (r *Recommender) Version() string {
+	m := r.Model()
+	if m == nil {
+		return ""
+	}
+
+	return m.TrainedAt.UTC().Format(time.RFC3339Nano)
+}
+
+// Rank ranks the candidates with the highest score for viewer first, then by
+// distance. Without a model, or history of the viewer, they are ranked by
+// distance alone.
+func (r *Recommender) Rank(
+	viewer store.PotentialMatch,
+	candidates []ranking.Candidate,
+) []ranking.Candidate {
+	candidates = ranking.ByDistance{}.Rank(viewer, candidates)
+
+	m := r.Model()
+	if m == nil {
+		return candidates
+	}
+
+	scores := m.Scores(viewer.Id)
+	if len(scores) == 0 {
+		return candidates
+	}
+
+	for i := range candidates {
+		candidates[i].Score = scores[candidates[i].Id]
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Score > candidates[j].Score
+	})
+
+	return candidates
+}
@@ -0,0 +1,130 @@
+package recommend
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+	"tinydates/store"
+)
+
+// DefaultNeighbours is the number of neighbours kept for each liked profile.
+const DefaultNeighbours = 50
+
+// maxLikesPerUser bounds the likes of a user counted as liked together, the
+// most recent being kept, as their pairs grow with its square.
+const maxLikesPerUser = 200
+
+// History is the history of swipes a model is trained on, implemented by the
+// stores that are a store.Backfiller.
+type History interface {
+	// ReplaySwipes calls fn with every swipe in the order they were made,
+	// stopping at the first error it returns.
+	ReplaySwipes(ctx context.Context, fn func(store.NewSwipe) error) error
+}
+
+// Train trains a model on every swipe of history, keeping neighbours for each
+// liked profile.
+func Train(ctx context.Context, history History, neighbours int) (*Model, error) {
+	t := newTrainer()
+	if err := history.ReplaySwipes(ctx, func(swipe store.NewSwipe) error {
+		t.add(swipe)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return t.model(neighbours), nil
+}
+
+// Fit trains a model on swipes, in the order they were made, keeping
+// neighbours for each liked profile.
+func Fit(swipes []store.NewSwipe, neighbours int) *Model {
+	t := newTrainer()
+	for _, swipe := range swipes {
+		t.add(swipe)
+	}
+
+	return t.model(neighbours)
+}
+
+// trainer accumulates the likes of every user.
+type trainer struct {
+	swipes int
+	liked  map[int][]int
+	seen   map[[2]int]bool
+}
+
+func newTrainer() *trainer {
+	return &trainer{
+		liked: make(map[int][]int),
+		seen:  make(map[[2]int]bool),
+	}
+}
+
+func (t *trainer) add(swipe store.NewSwipe) {
+	t.swipes++
+
+	pair := [2]int{swipe.Swiper, swipe.Swipee}
+	if !swipe.Decision || t.seen[pair] {
+		return
+	}
+
+	t.seen[pair] = true
+	t.liked[swipe.Swiper] = append(t.liked[swipe.Swiper], swipe.Swipee)
+}
+
+// model computes the cosine similarity of every pair of profiles liked by the
+// same user, by the users who liked them, keeping the most similar.
+func (t *trainer) model(neighbours int) *Model {
+	likers := make(map[int]int)
+	together := make(map[int]map[int]int)
+
+	for _, liked := range t.liked {
+		liked = liked[max(len(liked)-maxLikesPerUser, 0):]
+
+		for i, a := range liked {
+			likers[a]++
+
+			for _, b := range liked[i+1:] {
+				increment(together, a, b)
+				increment(together, b, a)
+			}
+		}
+	}
+
+	m := &Model{
+		TrainedAt:  time.Now().UTC(),
+		Swipes:     t.swipes,
+		Liked:      t.liked,
+		Neighbours: make(map[int][]Neighbour, len(together)),
+	}
+
+	for a, counts := range together {
+		similar := make([]Neighbour, 0, len(counts))
+		for b, count := range counts {
+			similar = append(similar, Neighbour{
+				Id:         b,
+				Similarity: float64(count) / math.Sqrt(float64(likers[a]*likers[b])),
+			})
+		}
+
+		sort.Slice(similar, func(i, j int) bool {
+			if similar[i].Similarity != similar[j].Similarity {
+				return similar[i].Similarity > similar[j].Similarity
+			}
+			return similar[i].Id < similar[j].Id
+		})
+
+		m.Neighbours[a] = similar[:min(len(similar), neighbours)]
+	}
+
+	return m
+}
+
+func increment(counts map[int]map[int]int, a, b int) {
+	if counts[a] == nil {
+		counts[a] = make(map[int]int)
+	}
+	counts[a][b]++
+}
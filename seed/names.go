@@ -0,0 +1,25 @@
+package seed
+
+var femaleNames = []string{
+	"Amara", "Chloe", "Zoe", "Ifeoma", "Sofia", "Hannah", "Priya", "Aisha",
+	"Grace", "Mia", "Olivia", "Emma", "Yara", "Leila", "Ngozi", "Isla",
+	"Freya", "Ava", "Lucia", "Mei", "Sakura", "Ana", "Nadia", "Ruth",
+}
+
+var maleNames = []string{
+	"Ebuka", "James", "Oliver", "Arjun", "Kwame", "Mateo", "Noah", "Liam",
+	"Tunde", "Hiro", "Omar", "Luca", "Daniel", "Samuel", "Ethan", "Jakub",
+	"Kofi", "Diego", "Wei", "Ravi", "Finn", "Leo", "Yusuf", "Tomas",
+}
+
+var otherNames = []string{
+	"Alex", "Sam", "Jordan", "Riley", "Charlie", "Robin", "Quinn", "Avery",
+	"Rowan", "Sasha", "Kai", "Remi",
+}
+
+var lastNames = []string{
+	"Agbanyim", "Smith", "Okafor", "Patel", "Nguyen", "Garcia", "Kowalski",
+	"Mensah", "Silva", "Tanaka", "Khan", "Murphy", "Rossi", "Jones", "Adeyemi",
+	"Miller", "Novak", "Haddad", "Kim", "Brown", "Eze", "Lopez", "Cohen",
+	"Ivanova", "Taylor", "Chen", "Williams", "Dubois", "Okonkwo", "Larsen",
+}
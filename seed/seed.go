@@ -0,0 +1,320 @@
+// Package seed generates realistic users and swipes to fill a database for
+// demos and for benchmarking the discover queries at scale. Everything is
+// derived from a seed value so that runs are reproducible.
+package seed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+	"tinydates/store"
+)
+
+const letters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+
+// Config describes the data to generate.
+type Config struct {
+	// Users is the number of users to generate.
+	Users int
+
+	// Swipes is the number of swipes to generate, no user swipes on the same
+	// profile twice.
+	Swipes int
+
+	// LikeRatio is the share of swipes that are favourable.
+	LikeRatio float64
+
+	// Reciprocity is the chance that a favourable swipe is returned, making
+	// a match.
+	Reciprocity float64
+
+	// Towns is the number of places users cluster around.
+	Towns int
+
+	// Locations bounds the location of users to [0, Locations).
+	Locations int
+
+	// Password is given to every user so that any of them can log in, each
+	// user gets a random password when it is empty.
+	Password string
+
+	// Seed makes the generated data reproducible.
+	Seed int64
+}
+
+// DefaultConfig returns a small configuration matching the distances used by
+// the service.
+func DefaultConfig() Config {
+	return Config{
+		Users:       1000,
+		Swipes:      10000,
+		LikeRatio:   0.3,
+		Reciprocity: 0.2,
+		Towns:       5,
+		Locations:   50,
+		Password:    "password",
+		Seed:        1,
+	}
+}
+
+// Validate reports the first invalid setting of the configuration.
+func (cfg Config) Validate() error {
+	switch {
+	case cfg.Users < 0:
+		return errors.New("the number of users cannot be negative")
+	case cfg.Swipes < 0:
+		return errors.New("the number of swipes cannot be negative")
+	case cfg.Swipes > cfg.Users*(cfg.Users-1)/2:
+		return fmt.Errorf("too many swipes for %d users", cfg.Users)
+	case cfg.LikeRatio < 0 || cfg.LikeRatio > 1:
+		return errors.New("the like ratio must be between 0 and 1")
+	case cfg.Reciprocity < 0 || cfg.Reciprocity > 1:
+		return errors.New("the reciprocity must be between 0 and 1")
+	case cfg.Towns < 1:
+		return errors.New("there must be at least one town")
+	case cfg.Locations < 1:
+		return errors.New("there must be at least one location")
+	default:
+		return nil
+	}
+}
+
+// Generator produces the users then the swipes described by a Config. Users
+// and swipes come from separate random sources so either can be generated
+// first. Birth dates are relative to the day, in UTC, the generator is
+// created, so the ages generated are the same whatever the day.
+type Generator struct {
+	cfg Config
+
+	today   time.Time
+	users   *rand.Rand
+	created int
+	towns   []int
+	weights []float64
+
+	swipes     *rand.Rand
+	swiped     int
+	popularity []int
+	zipf       *rand.Zipf
+	seen       map[[2]int]struct{}
+	returned   *store.NewSwipe
+}
+
+// New returns the generator for cfg, which must be valid.
+func New(cfg Config) *Generator {
+	now := time.Now().UTC()
+	g := &Generator{
+		cfg:    cfg,
+		today:  time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC),
+		users:  rand.New(rand.NewSource(cfg.Seed)),
+		swipes: rand.New(rand.NewSource(cfg.Seed + 1)),
+		seen:   make(map[[2]int]struct{}, cfg.Swipes),
+	}
+
+	// town sizes fall off with their rank, like those of a real region
+	var total float64
+	for i := 0; i < cfg.Towns; i++ {
+		g.towns = append(g.towns, g.users.Intn(cfg.Locations))
+		total += 1 / float64(i+1)
+		g.weights = append(g.weights, total)
+	}
+
+	if cfg.Users > 1 {
+		// a few users receive most of the swipes, who they are is shuffled so
+		// that popularity does not follow the order users are created in
+		g.popularity = g.swipes.Perm(cfg.Users)
+		g.zipf = rand.NewZipf(g.swipes, 1.1, 1, uint64(cfg.Users-1))
+	}
+
+	return g
+}
+
+// NextUser returns the next user, false once every user has been generated.
+func (g *Generator) NextUser() (store.NewUser, bool) {
+	if g.created == g.cfg.Users {
+		return store.NewUser{}, false
+	}
+	g.created++
+
+	var (
+		gender string
+		first  string
+	)
+	switch r := g.users.Float64(); {
+	case r < 0.48:
+		gender, first = "female", pick(g.users, femaleNames)
+	case r < 0.96:
+		gender, first = "male", pick(g.users, maleNames)
+	default:
+		gender, first = "other", pick(g.users, otherNames)
+	}
+	last := pick(g.users, lastNames)
+
+	password := g.cfg.Password
+	if password == "" {
+		password = randomString(g.users, 20)
+	}
+
+	return store.NewUser{
+		Email: fmt.Sprintf(
+			"%s.%s.%d@mail.com",
+			strings.ToLower(first),
+			strings.ToLower(last),
+			g.created,
+		),
+		Password: password,
+		Name:     first + " " + last,
+		Gender:   gender,
+		Location: g.location(),
+
+		BirthDate: g.birthDate(),
+	}, true
+}
+
+// birthDate makes users an age centred on the early thirties and never below
+// the legal age, born on any day of the year.
+func (g *Generator) birthDate() time.Time {
+	age := clamp(int(math.Round(31+8*g.users.NormFloat64())), 18, 80)
+
+	return g.today.AddDate(-age, 0, -g.users.Intn(365))
+}
+
+// location places a user around a town, bigger towns being more likely.
+func (g *Generator) location() int {
+	town := sort.SearchFloat64s(
+		g.weights,
+		g.users.Float64()*g.weights[len(g.weights)-1],
+	)
+	spread := float64(g.cfg.Locations) / float64(4*g.cfg.Towns)
+
+	return clamp(
+		g.towns[town]+int(math.Round(spread*g.users.NormFloat64())),
+		0,
+		g.cfg.Locations-1,
+	)
+}
+
+// NextSwipe returns the next swipe, false once every swipe has been
+// generated. Swiper and Swipee are the positions of the users in the order
+// they are generated, starting from 0.
+func (g *Generator) NextSwipe() (store.NewSwipe, bool) {
+	if g.swiped == g.cfg.Swipes {
+		return store.NewSwipe{}, false
+	}
+	g.swiped++
+
+	if returned := g.returned; returned != nil {
+		g.returned = nil
+		return *returned, true
+	}
+
+	for {
+		swiper := g.swipes.Intn(g.cfg.Users)
+		swipee := g.popularity[g.zipf.Uint64()]
+		if swiper == swipee || g.isSeen(swiper, swipee) {
+			continue
+		}
+		g.see(swiper, swipee)
+
+		decision := g.swipes.Float64() < g.cfg.LikeRatio
+		if decision &&
+			g.swipes.Float64() < g.cfg.Reciprocity &&
+			!g.isSeen(swipee, swiper) {
+			g.see(swipee, swiper)
+			g.returned = &store.NewSwipe{
+				Swiper:   swipee,
+				Swipee:   swiper,
+				Decision: true,
+			}
+		}
+
+		return store.NewSwipe{
+			Swiper:   swiper,
+			Swipee:   swipee,
+			Decision: decision,
+		}, true
+	}
+}
+
+func (g *Generator) isSeen(swiper, swipee int) bool {
+	_, ok := g.seen[[2]int{swiper, swipee}]
+	return ok
+}
+
+func (g *Generator) see(swiper, swipee int) {
+	g.seen[[2]int{swiper, swipee}] = struct{}{}
+}
+
+// Load inserts every user then every swipe of g into s, in bulk when s is a
+// store.Loader and one at a time through the Store methods otherwise.
+func Load(ctx context.Context, s store.Store, g *Generator) error {
+	if loader, ok := s.(store.Loader); ok {
+		firstId, err := loader.LoadUsers(ctx, g.NextUser)
+		if err != nil {
+			return fmt.Errorf("failed to load users: %w", err)
+		}
+
+		if err = loader.LoadSwipes(ctx, func() (store.NewSwipe, bool) {
+			swipe, ok := g.NextSwipe()
+			swipe.Swiper += firstId
+			swipe.Swipee += firstId
+			return swipe, ok
+		}); err != nil {
+			return fmt.Errorf("failed to load swipes: %w", err)
+		}
+
+		return nil
+	}
+
+	ids := make([]int, 0, g.cfg.Users)
+	for user, ok := g.NextUser(); ok; user, ok = g.NextUser() {
+		id, err := s.StoreNewUser(
+			ctx,
+			user.Email,
+			user.Password,
+			user.Name,
+			user.Gender,
+			user.BirthDate,
+			user.Location,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to store user: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	for swipe, ok := g.NextSwipe(); ok; swipe, ok = g.NextSwipe() {
+		if _, err := s.Swipe(
+			ctx,
+			ids[swipe.Swiper],
+			ids[swipe.Swipee],
+			swipe.Decision,
+		); err != nil {
+			return fmt.Errorf("failed to store swipe: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func pick(r *rand.Rand, words []string) string {
+	return words[r.Intn(len(words))]
+}
+
+func randomString(r *rand.Rand, length int) string {
+	b := make([]byte, length)
+	for i := range b {
+		b[i] = letters[r.Intn(len(letters))]
+	}
+
+	return string(b)
+}
+
+func clamp(value, low, high int) int {
+	return max(low, min(value, high))
+}
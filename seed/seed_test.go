@@ -0,0 +1,149 @@
+package seed_test
+
+import (
+	"context"
+	"testing"
+	"time"
+	"tinydates/seed"
+	"tinydates/store"
+
+	"github.com/stretchr/testify/require"
+)
+
+// generate returns everything produced by a generator for cfg.
+func generate(cfg seed.Config) ([]store.NewUser, []store.NewSwipe) {
+	g := seed.New(cfg)
+
+	var users []store.NewUser
+	for user, ok := g.NextUser(); ok; user, ok = g.NextUser() {
+		users = append(users, user)
+	}
+
+	var swipes []store.NewSwipe
+	for swipe, ok := g.NextSwipe(); ok; swipe, ok = g.NextSwipe() {
+		swipes = append(swipes, swipe)
+	}
+
+	return users, swipes
+}
+
+func TestReproducible(t *testing.T) {
+	cfg := seed.DefaultConfig()
+	users, swipes := generate(cfg)
+	sameUsers, sameSwipes := generate(cfg)
+	require.Equal(t, users, sameUsers)
+	require.Equal(t, swipes, sameSwipes)
+
+	cfg.Seed++
+	otherUsers, otherSwipes := generate(cfg)
+	require.NotEqual(t, users, otherUsers)
+	require.NotEqual(t, swipes, otherSwipes)
+}
+
+func TestGeneratedData(t *testing.T) {
+	cfg := seed.DefaultConfig()
+	users, swipes := generate(cfg)
+	require.Len(t, users, cfg.Users)
+	require.Len(t, swipes, cfg.Swipes)
+
+	emails := make(map[string]bool)
+	genders := make(map[string]int)
+	for _, user := range users {
+		require.False(t, emails[user.Email], "duplicate email %s", user.Email)
+		emails[user.Email] = true
+		genders[user.Gender]++
+
+		require.Equal(t, cfg.Password, user.Password)
+		require.NotEmpty(t, user.Name)
+		age := store.Age(user.BirthDate, time.Now().UTC())
+		require.GreaterOrEqual(t, age, 18)
+		require.LessOrEqual(t, age, 80)
+		require.GreaterOrEqual(t, user.Location, 0)
+		require.Less(t, user.Location, cfg.Locations)
+	}
+	require.Len(t, genders, 3)
+
+	pairs := make(map[[2]int]bool)
+	likes, matches := 0, 0
+	for _, swipe := range swipes {
+		require.NotEqual(t, swipe.Swiper, swipe.Swipee)
+		require.GreaterOrEqual(t, swipe.Swiper, 0)
+		require.Less(t, swipe.Swiper, cfg.Users)
+		require.GreaterOrEqual(t, swipe.Swipee, 0)
+		require.Less(t, swipe.Swipee, cfg.Users)
+
+		pair := [2]int{swipe.Swiper, swipe.Swipee}
+		require.False(t, pairs[pair], "duplicate swipe %v", pair)
+		pairs[pair] = true
+
+		if swipe.Decision {
+			likes++
+		}
+	}
+	for _, swipe := range swipes {
+		if swipe.Decision && pairs[[2]int{swipe.Swipee, swipe.Swiper}] {
+			matches++
+		}
+	}
+
+	// returned likes come on top of the like ratio
+	returned := cfg.LikeRatio * cfg.Reciprocity
+	require.InDelta(
+		t,
+		(cfg.LikeRatio+returned)/(1+returned),
+		float64(likes)/float64(len(swipes)),
+		0.03,
+	)
+	require.NotZero(t, matches)
+}
+
+func TestRandomPasswords(t *testing.T) {
+	cfg := seed.DefaultConfig()
+	cfg.Password = ""
+	users, _ := generate(cfg)
+	require.NotEqual(t, users[0].Password, users[1].Password)
+}
+
+func TestValidate(t *testing.T) {
+	require.NoError(t, seed.DefaultConfig().Validate())
+
+	for name, change := range map[string]func(*seed.Config){
+		"negative users":       func(cfg *seed.Config) { cfg.Users = -1 },
+		"too many swipes":      func(cfg *seed.Config) { cfg.Users, cfg.Swipes = 3, 4 },
+		"like ratio above 1":   func(cfg *seed.Config) { cfg.LikeRatio = 1.5 },
+		"negative reciprocity": func(cfg *seed.Config) { cfg.Reciprocity = -0.1 },
+		"no towns":             func(cfg *seed.Config) { cfg.Towns = 0 },
+		"no locations":         func(cfg *seed.Config) { cfg.Locations = 0 },
+	} {
+		t.Run(name, func(t *testing.T) {
+			cfg := seed.DefaultConfig()
+			change(&cfg)
+			require.Error(t, cfg.Validate())
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	ctx := context.Background()
+	cfg := seed.DefaultConfig()
+	cfg.Users, cfg.Swipes = 50, 200
+	users, swipes := generate(cfg)
+
+	s := store.NewTinydatesInMemoryStore()
+	require.NoError(t, seed.Load(ctx, s, seed.New(cfg)))
+
+	_, password, err := s.GetPassword(ctx, users[len(users)-1].Email)
+	require.NoError(t, err)
+	require.Equal(t, cfg.Password, password)
+
+	// the first user is hidden everyone they swiped on, ids start at 1
+	swiped := 0
+	for _, swipe := range swipes {
+		if swipe.Swiper == 0 {
+			swiped++
+		}
+	}
+	found, err := s.Discover(ctx, 1, store.Filters{})
+	require.NoError(t, err)
+	require.Len(t, found, cfg.Users-1-swiped)
+}
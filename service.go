@@ -4,49 +4,29 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"log/slog"
 	"math"
 	"math/rand"
-	"sort"
-	"strconv"
+	"time"
+	"tinydates/about"
 	"tinydates/cache"
+	"tinydates/identity"
+	"tinydates/logging"
+	"tinydates/photos"
+	"tinydates/ranking"
+	"tinydates/rating"
 	"tinydates/store"
 )
 
 const (
 	letters     = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	legalAge    = 18
 	maxAge      = 120
 	maxDistance = 50
 	maxLength   = 20
 )
 
-var gender = []string{"male", "female", "other"}
-
-var (
-	// ErrCreateUser is returned when there is an error creating a new user
-	ErrCreateUser = errors.New("error creating new user")
-
-	// ErrInvalidPassword is returned when supplied and found passwords differs
-	ErrInvalidPassword = errors.New("error invalid password")
-
-	// ErrUnauthorised is returned when the request is not authorised
-	ErrUnauthorized = errors.New("error unathorized access")
-
-	// ErrInternalService is returned when something major has gone wrong
-	ErrInternalService = errors.New("error unathorized access")
-
-	// ErrMinOrMaxAgeMissing is returned when a request is invalid
-	ErrMinOrMaxAgeMissing = errors.New("error min age or max age not supplied")
-
-	// ErrMinOrMaxAgeInvalid is returned when the supplied min or max age is not
-	// an integer
-	ErrMinOrMaxAgeInvalid = errors.New(
-		"error min age or max age can only be an integer",
-	)
-
-	// ErrorMinOrMaxFormat is returned when min age is not less than max age
-	ErrorMinOrMaxFormat = errors.New("error min age must be less than max age")
-)
-
 // Service interface encapsulates all functionalities of the tinydates service
 type Service interface {
 	// CreateUser creates and stores a new user in the system.
@@ -55,18 +35,82 @@ type Service interface {
 	// Login logs a user into the system by means of an entry into the cache.
 	Login(ctx context.Context, req LoginRequest) (LoginResponse, error)
 
-	// Discover finds profiles that are a match for the user with supplied id.
+	// Discover finds profiles that are a match for the user the token was
+	// started for, filtered by their stored preferences unless the request
+	// replaces them and ranked by the named strategy or the default one when
+	// it is empty. At most limit profiles are returned, or a page of them
+	// when limit is 0.
 	Discover(
 		ctx context.Context,
-		id int,
 		token string,
-		minAge string,
-		minAgeSupplied bool,
-		maxAge string,
-		maxAgeSupplied bool,
-		orderByPopularity bool,
+		req DiscoverRequest,
 	) (DiscoverResponse, error)
 
+	// GetPreferences returns the discovery preferences of the user the token
+	// was started for.
+	GetPreferences(ctx context.Context, token string) (Preferences, error)
+
+	// SetPreferences replaces the discovery preferences of the user the token
+	// was started for, returning them as stored.
+	SetPreferences(
+		ctx context.Context,
+		token string,
+		preferences Preferences,
+	) (Preferences, error)
+
+	// Taxonomy returns the genders and pronouns users may pick from.
+	Taxonomy(ctx context.Context) Taxonomy
+
+	// GetIdentity returns the gender and pronouns of the user the token was
+	// started for.
+	GetIdentity(ctx context.Context, token string) (Identity, error)
+
+	// SetIdentity replaces the gender and pronouns of the user the token was
+	// started for, returning them as stored: by their spelling in the
+	// taxonomy.
+	SetIdentity(
+		ctx context.Context,
+		token string,
+		identity Identity,
+	) (Identity, error)
+
+	// GetPhotos returns the photos of the user the token was started for, in
+	// the order they are shown.
+	GetPhotos(ctx context.Context, token string) ([]Photo, error)
+
+	// AddPhoto processes the image read from upload and adds it after the
+	// other photos of the user the token was started for.
+	AddPhoto(
+		ctx context.Context,
+		token string,
+		upload io.Reader,
+	) (Photo, error)
+
+	// ReorderPhotos shows the photos of the user the token was started for in
+	// the order of photoIds, which lists every one of them, returning them in
+	// that order.
+	ReorderPhotos(
+		ctx context.Context,
+		token string,
+		photoIds []int,
+	) ([]Photo, error)
+
+	// DeletePhoto deletes the photo with photoId of the user the token was
+	// started for, along with its images.
+	DeletePhoto(ctx context.Context, token string, photoId int) error
+
+	// Catalog returns the prompts and interests users may pick from.
+	Catalog(ctx context.Context) Catalog
+
+	// GetAbout returns the bio, answered prompts and interests of the user
+	// the token was started for.
+	GetAbout(ctx context.Context, token string) (About, error)
+
+	// SetAbout replaces the bio, answered prompts and interests of the user
+	// the token was started for, returning them as stored: by their ids in
+	// the catalog.
+	SetAbout(ctx context.Context, token string, a About) (About, error)
+
 	// Swipe handles the action when a user swipes on a discovered profile.
 	Swipe(
 		ctx context.Context,
@@ -76,12 +120,54 @@ type Service interface {
 }
 
 type tinydates struct {
-	store store.Store
-	cache cache.Cache
+	store      store.Store
+	cache      cache.Cache
+	logger     *slog.Logger
+	strategies *ranking.Strategies
+	decks      *decks
+	taxonomy   *identity.Taxonomy
+	catalog    *about.Catalog
+
+	// blobs holds the images of the photos, photos are disabled when nil
+	blobs       photos.BlobStore
+	photoLimits photos.Limits
+}
+
+// Option configures the service created by New.
+type Option func(*tinydates)
+
+// WithStrategies sets the strategies Discover ranks profiles with, the built
+// in ranking.NewStrategies by default.
+func WithStrategies(strategies *ranking.Strategies) Option {
+	return func(td *tinydates) {
+		td.strategies = strategies
+	}
 }
 
-func New(store store.Store, cache cache.Cache) Service {
-	return tinydates{store: store, cache: cache}
+// New creates the tinydates service; a nil logger discards all output.
+func New(
+	store store.Store,
+	cache cache.Cache,
+	logger *slog.Logger,
+	opts ...Option,
+) Service {
+	if logger == nil {
+		logger = logging.Discard()
+	}
+
+	td := tinydates{
+		store:      store,
+		cache:      cache,
+		logger:     logger,
+		strategies: ranking.NewStrategies(),
+		taxonomy:   identity.Default(),
+		catalog:    about.Default(),
+	}
+	for _, opt := range opts {
+		opt(&td)
+	}
+
+	return td
 }
 
 func (td tinydates) CreateUser(ctx context.Context) (User, error) {
@@ -89,9 +175,18 @@ func (td tinydates) CreateUser(ctx context.Context) (User, error) {
 	randomEmail := fmt.Sprintf("%v@mail.com", randomName)
 	// returning password as plaintext is no bueno
 	randomPassword := createRandomString(maxLength)
-	randomGender := gender[rand.Intn(len(gender))]
-	randomAge := rand.Intn(maxAge)
+	genders := td.taxonomy.Ids()
+	randomGender := genders[rand.Intn(len(genders))]
+	// born on any day of the year, between the legal age and maxAge; the
+	// birth date is never asked of the user, so that no user is ever underage
+	now := time.Now().UTC()
+	randomBirthDate := now.AddDate(
+		-(legalAge + rand.Intn(maxAge-legalAge)),
+		0,
+		-rand.Intn(365),
+	)
 	randomLocation := rand.Intn(maxDistance)
+	age := store.Age(randomBirthDate, now)
 
 	// store user in the database
 	newId, err := td.store.StoreNewUser(
@@ -100,13 +195,15 @@ func (td tinydates) CreateUser(ctx context.Context) (User, error) {
 		randomPassword,
 		randomName,
 		randomGender,
-		randomAge,
+		randomBirthDate,
 		randomLocation,
 	)
 	if err != nil {
 		// for simplicity not handling the specific error from the data store
-		// only returning an error back that is well formatted for the caller
-		return User{}, ErrCreateUser
+		// only returning an error back that is well formatted for the caller,
+		// the underlying error is logged so it is not lost
+		td.logger.ErrorContext(ctx, "failed to store new user", "err", err)
+		return User{}, storeError(err, ErrCreateUser)
 	}
 
 	return User{
@@ -115,8 +212,10 @@ func (td tinydates) CreateUser(ctx context.Context) (User, error) {
 		Password: randomPassword,
 		Name:     randomName,
 		Gender:   randomGender,
-		Age:      randomAge,
+		Age:      age,
 		Location: randomLocation,
+
+		BirthDate: randomBirthDate.Format(time.DateOnly),
 	}, nil
 }
 
@@ -125,116 +224,166 @@ func (td tinydates) Login(
 	req LoginRequest,
 ) (LoginResponse, error) {
 	// for brevity assuming user not logged in
-	storedPassword, err := td.store.GetPassword(ctx, req.Email)
+	id, storedPassword, err := td.store.GetPassword(ctx, req.Email)
+	if errors.Is(err, store.ErrNotFound) {
+		// an unknown email is reported exactly like a wrong password so that
+		// registered emails cannot be enumerated
+		return LoginResponse{}, ErrInvalidPassword
+	}
 	if err != nil {
-		return LoginResponse{}, ErrInternalService
+		td.logger.ErrorContext(
+			ctx,
+			"failed to get password",
+			"login", req,
+			"err", err,
+		)
+		return LoginResponse{}, storeError(err, ErrInternalService)
 	}
 
 	if req.Password == storedPassword {
 		// new token created in same way as name for simplicity
 		token := createRandomString(maxLength)
-		if err := td.cache.StartSession(ctx, token); err != nil {
-			return LoginResponse{}, err
+		if err := td.cache.StartSession(ctx, token, id); err != nil {
+			td.logger.ErrorContext(ctx, "failed to start session", "err", err)
+			return LoginResponse{}, ErrInternalService.Wrap(err)
 		}
-		return LoginResponse{Token: token}, err
+		return LoginResponse{Token: token}, nil
 	} else {
 		return LoginResponse{}, ErrInvalidPassword
 	}
 }
 
+// caller returns the id of the user token was started for, the user
+// discovery and the /me routes act on.
+func (td tinydates) caller(ctx context.Context, token string) (int, error) {
+	id, ok := td.cache.Session(ctx, token)
+	if !ok {
+		return 0, ErrUnauthorized
+	}
+
+	return id, nil
+}
+
 func (td tinydates) Discover(
 	ctx context.Context,
-	id int,
 	token string,
-	minAge string,
-	minAgeSupplied bool,
-	maxAge string,
-	maxAgeSupplied bool,
-	orderByPopularity bool,
+	req DiscoverRequest,
 ) (DiscoverResponse, error) {
-	if !td.cache.Authorized(ctx, token) {
-		return DiscoverResponse{}, ErrUnauthorized
+	id, err := td.caller(ctx, token)
+	if err != nil {
+		return DiscoverResponse{}, err
 	}
 
-	var profiles []store.PotentialMatch
-
-	if orderByPopularity {
-		foundProfiles, err := td.store.DiscoverByPopularity(ctx, id)
-		if err != nil {
-			return DiscoverResponse{}, err
-		}
-
-		profiles = foundProfiles
-	} else {
-		foundProfiles, err := td.store.Discover(ctx, id)
-		if err != nil {
-			return DiscoverResponse{}, err
-		}
-
-		profiles = foundProfiles
+	strategy, ok := td.strategies.Get(req.Sort)
+	if !ok {
+		return DiscoverResponse{}, ErrUnknownSort
 	}
 
-	if minAgeSupplied || maxAgeSupplied {
-		// for simplicity enforcing min and max age supplied
-		if minAgeSupplied == true && maxAgeSupplied == false ||
-			minAgeSupplied == false && maxAgeSupplied == true {
-			return DiscoverResponse{}, ErrMinOrMaxAgeMissing
-		}
-
-		minAgeInt, err := strconv.Atoi(minAge)
-		if err != nil {
-			return DiscoverResponse{}, ErrMinOrMaxAgeInvalid
-		}
-
-		maxAgeInt, err := strconv.Atoi(maxAge)
-		if err != nil {
-			return DiscoverResponse{}, ErrMinOrMaxAgeInvalid
-		}
+	if req.Limit < 0 {
+		return DiscoverResponse{}, ErrLimitInvalid
+	}
 
-		if minAgeInt > maxAgeInt {
-			return DiscoverResponse{}, ErrorMinOrMaxFormat
-		}
+	if err := req.validate(); err != nil {
+		return DiscoverResponse{}, err
+	}
+	genders, err := td.genders(req.Genders)
+	if err != nil {
+		return DiscoverResponse{}, err
+	}
+	req.Genders = genders
 
-		ageFilteredProfiles := make([]store.PotentialMatch, 0)
+	// the profile of the user, such as their current location, is what the
+	// features of the candidates are relative to
+	viewer, err := td.store.GetProfile(ctx, id)
+	if errors.Is(err, store.ErrNotFound) {
+		return DiscoverResponse{}, ErrUserNotFound.Wrap(err)
+	}
+	if err != nil {
+		td.logger.ErrorContext(ctx, "failed to get profile", "id", id, "err", err)
+		return DiscoverResponse{}, storeError(err, ErrInternalService)
+	}
 
-		for _, profile := range profiles {
-			if profile.Age >= minAgeInt && profile.Age <= maxAgeInt {
-				ageFilteredProfiles = append(ageFilteredProfiles, profile)
-			}
-		}
+	preferences, err := td.store.GetPreferences(ctx, id)
+	if errors.Is(err, store.ErrNotFound) {
+		return DiscoverResponse{}, ErrUserNotFound.Wrap(err)
+	}
+	if err != nil {
+		td.logger.ErrorContext(ctx, "failed to get preferences", "id", id, "err", err)
+		return DiscoverResponse{}, storeError(err, ErrInternalService)
+	}
+	d := newDiscovery(req, preferences)
 
-		profiles = ageFilteredProfiles
+	if td.decks != nil && !strategy.Revisits {
+		return td.discoverFromDeck(ctx, viewer, req.Sort, strategy, d, req.Limit)
 	}
 
-	// get access to the users current location
-	currentLocation, err := td.store.GetLocation(ctx, id)
+	candidates, err := td.rank(ctx, viewer, req.Sort, strategy, d)
 	if err != nil {
-		return DiscoverResponse{}, ErrInternalService
+		return DiscoverResponse{}, err
+	}
+	if req.Limit > 0 {
+		candidates = candidates[:min(req.Limit, len(candidates))]
 	}
 
-	discoveredUsers := make(DiscoveredUsers, 0)
-
-	for _, profile := range profiles {
-		var usr DiscoveredUser
+	return DiscoverResponse{
+		Results: discovered(candidates, td.discoveredPhotos(ctx, candidates)),
+	}, nil
+}
 
-		usr.Id = profile.Id
-		usr.Name = profile.Name
-		usr.Gender = profile.Gender
-		usr.Age = profile.Age
-		usr.DistanceFromMe = calculateDistance(
-			currentLocation,
-			profile.Location,
+// rank generates the candidates of strategy for viewer meeting the filters
+// d requires, then ranks them as d asks.
+func (td tinydates) rank(
+	ctx context.Context,
+	viewer store.PotentialMatch,
+	strategyName string,
+	strategy ranking.Strategy,
+	d discovery,
+) ([]ranking.Candidate, error) {
+	// candidate generation
+	profiles, err := strategy.Source(ctx, td.store, viewer.Id, d.required.stored())
+	if err != nil {
+		td.logger.ErrorContext(
+			ctx,
+			"failed to discover",
+			"id", viewer.Id,
+			"sort", strategyName,
+			"err", err,
 		)
-
-		discoveredUsers = append(discoveredUsers, usr)
+		return nil, storeError(err, ErrInternalService)
 	}
 
-	if !orderByPopularity {
-		// sort interface has been implemented for sorting by location
-		sort.Sort(discoveredUsers)
+	// feature extraction, the preferred filters need the distance, then
+	// scoring and re-ranking as the strategy does
+	return d.apply(
+		viewer,
+		ranking.Extract(viewer, profiles, time.Now()),
+		strategy.Ranker,
+	), nil
+}
+
+// discovered turns ranked candidates into the users returned by Discover,
+// along with their photos keyed by user.
+func discovered(
+	candidates []ranking.Candidate,
+	photosByUser map[int][]Photo,
+) DiscoveredUsers {
+	discoveredUsers := make(DiscoveredUsers, 0, len(candidates))
+
+	for _, candidate := range candidates {
+		discoveredUsers = append(discoveredUsers, DiscoveredUser{
+			Id:              candidate.Id,
+			Name:            candidate.Name,
+			Gender:          candidate.Gender,
+			Pronouns:        candidate.Pronouns,
+			Age:             candidate.Age,
+			DistanceFromMe:  candidate.Distance,
+			Popularity:      int(math.Round(candidate.Desirability)),
+			SharedInterests: candidate.SharedInterests,
+			Photos:          append([]Photo{}, photosByUser[candidate.Id]...),
+		})
 	}
 
-	return DiscoverResponse{Results: discoveredUsers}, nil
+	return discoveredUsers
 }
 
 func (td tinydates) Swipe(
@@ -253,12 +402,29 @@ func (td tinydates) Swipe(
 		req.Decision,
 	)
 	if err != nil {
-		return SwipeResponse{}, ErrInternalService
+		td.logger.ErrorContext(ctx, "failed to store swipe", "err", err)
+		return SwipeResponse{}, storeError(err, ErrInternalService)
+	}
+
+	// the swipee must never be served again from the deck of the swiper
+	if td.decks != nil {
+		td.forgetSwipee(ctx, req.SwiperId, req.SwipeeId)
+	}
+
+	// the swipe is recorded by now so failing to rate it is only logged, the
+	// rating is caught up by the next backfill
+	if err := rating.Rate(ctx, td.store, store.NewSwipe{
+		Swiper:   req.SwiperId,
+		Swipee:   req.SwipeeId,
+		Decision: req.Decision,
+	}); err != nil {
+		td.logger.WarnContext(ctx, "failed to rate swipe", "err", err)
 	}
 
 	match, err := td.store.IsMatch(ctx, req.SwipeeId, req.SwiperId)
 	if err != nil {
-		return SwipeResponse{}, ErrInternalService
+		td.logger.ErrorContext(ctx, "failed to check match", "err", err)
+		return SwipeResponse{}, storeError(err, ErrInternalService)
 	}
 
 	// only a match if the swiper also swiped favourably
@@ -269,13 +435,19 @@ func (td tinydates) Swipe(
 	}
 }
 
-// calculateDistance uses a simple subtraction of the magnitude of the users
-// location from the potential match location to find distance. This assumes
-// for simplicity that all locations are from a common origin of (0, 0) on a
-// 2D plane of which the magnitude from origin is stored.
-func calculateDistance(userLocation, potentialMatchLocation int) int {
-	distance := math.Abs(float64(userLocation) - float64(potentialMatchLocation))
-	return int(distance)
+// storeError translates a data store error into the domain error returned to
+// the caller, keeping the store error as its cause. Timeouts and
+// cancellations are kept distinct so they can be told apart from genuine
+// failures, everything else becomes the fallback error.
+func storeError(err error, fallback *Error) error {
+	switch {
+	case errors.Is(err, store.ErrTimeout):
+		return ErrTimeout.Wrap(err)
+	case errors.Is(err, store.ErrCanceled):
+		return ErrCanceled.Wrap(err)
+	default:
+		return fallback.Wrap(err)
+	}
 }
 
 func createRandomString(n int) string {
@@ -0,0 +1,192 @@
+package tinydates
+
+import (
+	"context"
+	"io"
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// instrumentedService decorates a Service counting the business events of
+// interest; the wrapped service is unaware it is being measured.
+type instrumentedService struct {
+	next           Service
+	logins         prometheus.Counter
+	failedLogins   prometheus.Counter
+	swipes         *prometheus.CounterVec
+	matchesCreated prometheus.Counter
+	photosAdded    prometheus.Counter
+}
+
+// NewInstrumentedService wraps next registering its counters with reg.
+func NewInstrumentedService(next Service, reg prometheus.Registerer) Service {
+	factory := promauto.With(reg)
+
+	return &instrumentedService{
+		next: next,
+		logins: factory.NewCounter(prometheus.CounterOpts{
+			Namespace: "tinydates",
+			Name:      "logins_total",
+			Help:      "Total number of successful logins.",
+		}),
+		failedLogins: factory.NewCounter(prometheus.CounterOpts{
+			Namespace: "tinydates",
+			Name:      "login_failures_total",
+			Help:      "Total number of failed login attempts.",
+		}),
+		swipes: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: "tinydates",
+				Name:      "swipes_total",
+				Help:      "Total number of swipes by decision.",
+			},
+			[]string{"decision"},
+		),
+		matchesCreated: factory.NewCounter(prometheus.CounterOpts{
+			Namespace: "tinydates",
+			Name:      "matches_created_total",
+			Help:      "Total number of mutual matches created.",
+		}),
+		photosAdded: factory.NewCounter(prometheus.CounterOpts{
+			Namespace: "tinydates",
+			Name:      "photos_added_total",
+			Help:      "Total number of photos added to profiles.",
+		}),
+	}
+}
+
+func (s *instrumentedService) CreateUser(ctx context.Context) (User, error) {
+	return s.next.CreateUser(ctx)
+}
+
+func (s *instrumentedService) Login(
+	ctx context.Context,
+	req LoginRequest,
+) (LoginResponse, error) {
+	resp, err := s.next.Login(ctx, req)
+	if err != nil {
+		s.failedLogins.Inc()
+	} else {
+		s.logins.Inc()
+	}
+
+	return resp, err
+}
+
+func (s *instrumentedService) Discover(
+	ctx context.Context,
+	token string,
+	req DiscoverRequest,
+) (DiscoverResponse, error) {
+	return s.next.Discover(ctx, token, req)
+}
+
+func (s *instrumentedService) GetPreferences(
+	ctx context.Context,
+	token string,
+) (Preferences, error) {
+	return s.next.GetPreferences(ctx, token)
+}
+
+func (s *instrumentedService) SetPreferences(
+	ctx context.Context,
+	token string,
+	preferences Preferences,
+) (Preferences, error) {
+	return s.next.SetPreferences(ctx, token, preferences)
+}
+
+func (s *instrumentedService) Taxonomy(ctx context.Context) Taxonomy {
+	return s.next.Taxonomy(ctx)
+}
+
+func (s *instrumentedService) GetIdentity(
+	ctx context.Context,
+	token string,
+) (Identity, error) {
+	return s.next.GetIdentity(ctx, token)
+}
+
+func (s *instrumentedService) SetIdentity(
+	ctx context.Context,
+	token string,
+	identity Identity,
+) (Identity, error) {
+	return s.next.SetIdentity(ctx, token, identity)
+}
+
+func (s *instrumentedService) GetPhotos(
+	ctx context.Context,
+	token string,
+) ([]Photo, error) {
+	return s.next.GetPhotos(ctx, token)
+}
+
+func (s *instrumentedService) AddPhoto(
+	ctx context.Context,
+	token string,
+	upload io.Reader,
+) (Photo, error) {
+	photo, err := s.next.AddPhoto(ctx, token, upload)
+	if err == nil {
+		s.photosAdded.Inc()
+	}
+
+	return photo, err
+}
+
+func (s *instrumentedService) ReorderPhotos(
+	ctx context.Context,
+	token string,
+	photoIds []int,
+) ([]Photo, error) {
+	return s.next.ReorderPhotos(ctx, token, photoIds)
+}
+
+func (s *instrumentedService) DeletePhoto(
+	ctx context.Context,
+	token string,
+	photoId int,
+) error {
+	return s.next.DeletePhoto(ctx, token, photoId)
+}
+
+func (s *instrumentedService) Catalog(ctx context.Context) Catalog {
+	return s.next.Catalog(ctx)
+}
+
+func (s *instrumentedService) GetAbout(
+	ctx context.Context,
+	token string,
+) (About, error) {
+	return s.next.GetAbout(ctx, token)
+}
+
+func (s *instrumentedService) SetAbout(
+	ctx context.Context,
+	token string,
+	a About,
+) (About, error) {
+	return s.next.SetAbout(ctx, token, a)
+}
+
+func (s *instrumentedService) Swipe(
+	ctx context.Context,
+	token string,
+	req SwipeRequest,
+) (SwipeResponse, error) {
+	resp, err := s.next.Swipe(ctx, token, req)
+	if err != nil {
+		return resp, err
+	}
+
+	s.swipes.WithLabelValues(strconv.FormatBool(req.Decision)).Inc()
+	// mirrors the service; a match is only created by a favourable swipe
+	if resp.Matched && req.Decision {
+		s.matchesCreated.Inc()
+	}
+
+	return resp, nil
+}
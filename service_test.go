@@ -1,38 +1,89 @@
-package tinydates
+package tinydates_test
 
 import (
 	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
+	"image"
+	"image/jpeg"
+	"io"
 	"log"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"strconv"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
+	"tinydates"
 	"tinydates/cache"
+	"tinydates/client"
+	"tinydates/photos"
+	"tinydates/ranking"
+	"tinydates/recommend"
 	"tinydates/store"
 
-	_ "github.com/golang-migrate/migrate/v4/database/postgres"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/ory/dockertest/v3"
 	"github.com/stretchr/testify/require"
 )
 
 var (
-	dbPool      *pgxpool.Pool
-	testStore   store.TestStore
-	testCache   cache.Cache
-	testHandler http.Handler
-	service     Service
+	testStore  store.TestStore
+	testCache  cache.Cache
+	testServer *httptest.Server
+	service    tinydates.Service
 )
 
+// postgresEnv selects the Postgres store, started in Docker, instead of the
+// in memory store the tests run against by default.
+const postgresEnv = "TINYDATES_TEST_POSTGRES"
+
 func TestMain(m *testing.M) {
 	ctx := context.Background()
 
+	// initialise test version of the store
+	teardown := func() {}
+	if os.Getenv(postgresEnv) != "" {
+		testStore, teardown = setupPostgres(ctx)
+	} else {
+		testStore = store.NewTestTinydatesInMemoryStore()
+	}
+
+	// create and initialise test version of the cache
+	testCache = cache.NewTinydatesInMemoryCache(0)
+
+	// create and wire up service
+	service = tinydates.New(testStore, testCache, nil)
+
+	// router initialisation, served over a real connection for the client
+	testServer = httptest.NewServer(
+		tinydates.NewTinydatesHandler(service, tinydates.HandlerConfig{}),
+	)
+
+	// run tear up and down scripts at start, tear up to connect, reuse and
+	// drop any lingering container resources
+	if err := testStore.Down(ctx); err != nil {
+		log.Fatalf("could not teardown the resource: %s", err)
+	}
+	if err := testStore.Up(ctx); err != nil {
+		log.Fatalf("could not create the resource: %s", err)
+	}
+
+	// run tests below
+	code := m.Run()
+
+	testServer.Close()
+	teardown()
+
+	os.Exit(code)
+}
+
+// setupPostgres starts a short-lived Postgres container returning a test
+// store connected to it, along with the function that removes the container.
+func setupPostgres(ctx context.Context) (store.TestStore, func()) {
 	pool, err := dockertest.NewPool("")
 	if err != nil {
 		log.Fatalf("could not connect to docker: %s", err)
@@ -57,6 +108,7 @@ func TestMain(m *testing.M) {
 	// set max life of the test container
 	resource.Expire(60)
 
+	var dbPool *pgxpool.Pool
 	if err = pool.Retry(func() error {
 		dbPool, err = pgxpool.New(
 			ctx,
@@ -69,57 +121,37 @@ func TestMain(m *testing.M) {
 	}); err != nil {
 		log.Fatalf("could not connect to docker: %s", err.Error())
 	}
-	defer dbPool.Close()
 
 	// need to introduce a sleep here because it is too fast to connec to the
 	// postgres container and needs to send a fast restart message
 	time.Sleep(time.Second * 2)
 
-	// initialise test version of the store
-	testStore = store.NewTestTinydatesPgStore(dbPool)
-
-	// create and initialise test version of the cache
-	testCache = cache.NewTinydatesInMemoryCache()
-
-	// create and wire up service
-	service = New(testStore, testCache)
-
-	// router initialisation
-	testHandler = NewTinydatesHandler(ctx, service)
-
-	// run tear up and down scripts at start, tear up to connect, reuse and
-	// drop any lingering container resources
-	if err := testStore.Down(ctx); err != nil {
-		log.Fatalf("could not teardown the resource: %s", err)
-	}
-	if err := testStore.Up(ctx); err != nil {
-		log.Fatalf("could not create the resource: %s", err)
-	}
-
-	// run tests below
-	code := m.Run()
-
-	if err := pool.Purge(resource); err != nil {
-		log.Fatalf("could not purge the resource: %s", err)
+	return store.NewTestTinydatesPgStore(dbPool), func() {
+		dbPool.Close()
+		if err := pool.Purge(resource); err != nil {
+			log.Fatalf("could not purge the resource: %s", err)
+		}
 	}
-
-	os.Exit(code)
 }
 
 func TestNewUserCreation(t *testing.T) {
-	ctx := context.Background()
-	req := httptest.NewRequest("GET", "/user/create", nil)
-	req.WithContext(ctx)
-	req.Header.Set("Content-Type", "application/json")
-	rec := httptest.NewRecorder()
-	testHandler.ServeHTTP(rec, req)
-	resp := rec.Result()
+	user, err := client.New(testServer.URL).CreateUser(context.Background())
 
-	var user User
-	err := json.NewDecoder(resp.Body).Decode(&user)
+	require.NoError(t, err)
+	require.NotZero(t, user.Id)
+	require.NotEmpty(t, user.Email)
 
+	// only adults register, their age derived from their birth date
+	birthDate, err := time.Parse(time.DateOnly, user.BirthDate)
 	require.NoError(t, err)
-	require.NotNil(t, user)
+	require.GreaterOrEqual(t, user.Age, 18)
+	require.Equal(t, store.Age(birthDate, time.Now().UTC()), user.Age)
+}
+
+// born returns a birth date making a user age years old, half a year away
+// from their birthday, as of the current day in UTC as ages are computed.
+func born(age int) time.Time {
+	return time.Now().UTC().AddDate(-age, -6, 0)
 }
 
 func TestUserLogin(t *testing.T) {
@@ -129,47 +161,44 @@ func TestUserLogin(t *testing.T) {
 	require.NoError(t, err)
 	testCases := []struct {
 		name     string
-		testCase LoginRequest
+		testCase tinydates.LoginRequest
 	}{
-		{"success", LoginRequest{user.Email, user.Password}},
-		{"failure email not found", LoginRequest{"invalid email", user.Password}},
-		{"failure password incorrect", LoginRequest{user.Email, "invalid password"}},
+		{"success", tinydates.LoginRequest{Email: user.Email, Password: user.Password}},
+		{"failure email not found", tinydates.LoginRequest{Email: "invalid email", Password: user.Password}},
+		{"failure password incorrect", tinydates.LoginRequest{Email: user.Email, Password: "invalid password"}},
 	}
 
 	for _, tc := range testCases {
+		c := client.New(testServer.URL)
+		success, err := c.Login(ctx, tc.testCase.Email, tc.testCase.Password)
+
 		if tc.name == "success" {
-			jsonRequest, err := json.Marshal(tc.testCase)
-			require.NoError(t, err)
-			reader := bytes.NewReader(jsonRequest)
-			req := httptest.NewRequest("POST", "/login", reader)
-			req.WithContext(ctx)
-			req.Header.Set("Content-Type", "application/json")
-			rec := httptest.NewRecorder()
-			testHandler.ServeHTTP(rec, req)
-			resp := rec.Result()
-
-			var success LoginResponse
-			err = json.NewDecoder(resp.Body).Decode(&success)
-
-			require.Equal(t, 201, resp.StatusCode)
 			require.NoError(t, err)
-			require.NotNil(t, success)
+			require.NotEmpty(t, success.Token)
+			require.Equal(t, success.Token, c.Token())
 		} else {
-			jsonRequest, err := json.Marshal(tc.testCase)
-			require.NoError(t, err)
-			reader := bytes.NewReader(jsonRequest)
-			req := httptest.NewRequest("POST", "/login", reader)
-			req.WithContext(ctx)
-			req.Header.Set("Content-Type", "application/json")
-			rec := httptest.NewRecorder()
-			testHandler.ServeHTTP(rec, req)
-			resp := rec.Result()
-
-			require.Equal(t, 500, resp.StatusCode)
+			// unknown emails and wrong passwords are indistinguishable
+			require.ErrorIs(t, err, tinydates.ErrInvalidPassword)
+
+			var responseErr *client.ResponseError
+			require.ErrorAs(t, err, &responseErr)
+			require.Equal(t, 401, responseErr.StatusCode)
+			require.Empty(t, c.Token())
 		}
 	}
 }
 
+// loggedIn returns a client holding a session for user.
+func loggedIn(t *testing.T, user tinydates.User) *client.Client {
+	t.Helper()
+
+	c := client.New(testServer.URL)
+	_, err := c.Login(context.Background(), user.Email, user.Password)
+	require.NoError(t, err)
+
+	return c
+}
+
 func TestUserDiscoveryAndResultOrder(t *testing.T) {
 	ctx := context.Background()
 	user1, err := service.CreateUser(ctx)
@@ -177,26 +206,14 @@ func TestUserDiscoveryAndResultOrder(t *testing.T) {
 	_, err = service.CreateUser(ctx)
 	require.NoError(t, err)
 
-	// discovery based on user 1, login to obtain loginResponse
-	loginResponse, err := service.Login(ctx, LoginRequest{user1.Email, user1.Password})
-	require.NoError(t, err)
-
-	req := httptest.NewRequest("GET", "/discover", nil)
-	req.WithContext(ctx)
-	// for simplicity not following standard Authorization: <scheme> <token>
-	req.Header.Set("Id", strconv.Itoa(user1.Id))
-	req.Header.Set("Authorization", loginResponse.Token)
-	req.Header.Set("Content-Type", "application/json")
-	rec := httptest.NewRecorder()
-	testHandler.ServeHTTP(rec, req)
-	resp := rec.Result()
-
-	var discoverResponse DiscoverResponse
-	err = json.NewDecoder(resp.Body).Decode(&discoverResponse)
+	// discovery based on user 1
+	discoverResponse, err := loggedIn(t, user1).Discover(
+		ctx,
+		client.DiscoverOptions{},
+	)
 
-	// for simplicity not checking exact users match just status code and
-	// correct number of results
-	require.Equal(t, 200, resp.StatusCode)
+	// for simplicity not checking exact users match just correct number of
+	// results
 	require.NoError(t, err)
 	require.Equal(t, 3, len(discoverResponse.Results))
 	// check results are ordered by closest to furthest; for simplicity and to
@@ -221,26 +238,12 @@ func TestUserDiscoveryByAge(t *testing.T) {
 	_, err = service.CreateUser(ctx)
 	require.NoError(t, err)
 
-	// discovery based on user 1, login to obtain loginResponse
-	loginResponse, err := service.Login(ctx, LoginRequest{user1.Email, user1.Password})
-	require.NoError(t, err)
-
-	req := httptest.NewRequest("GET", "/discover?minAge=20&maxAge=40", nil)
-	req.WithContext(ctx)
-	// for simplicity not following standard Authorization: <scheme> <token>
-	req.Header.Set("Id", strconv.Itoa(user1.Id))
-	req.Header.Set("Authorization", loginResponse.Token)
-	req.Header.Set("Content-Type", "application/json")
-	rec := httptest.NewRecorder()
-	testHandler.ServeHTTP(rec, req)
-	resp := rec.Result()
-
-	var discoverResponse DiscoverResponse
-	err = json.NewDecoder(resp.Body).Decode(&discoverResponse)
+	// discovery based on user 1
+	discoverResponse, err := loggedIn(t, user1).Discover(
+		ctx,
+		client.DiscoverOptions{Age: &client.AgeRange{Min: 20, Max: 40}},
+	)
 
-	// for simplicity not checking exact users match just status code and
-	// correct number of results
-	require.Equal(t, 200, resp.StatusCode)
 	require.NoError(t, err)
 	for _, found := range discoverResponse.Results {
 		require.GreaterOrEqual(t, found.Age, 20)
@@ -256,26 +259,14 @@ func TestUserDiscoveryByPopularity(t *testing.T) {
 	_, err = service.CreateUser(ctx)
 	require.NoError(t, err)
 
-	// discovery based on user 1, login to obtain loginResponse
-	loginResponse, err := service.Login(ctx, LoginRequest{user1.Email, user1.Password})
-	require.NoError(t, err)
-
-	req := httptest.NewRequest("GET", "/discover?orderByPopularity=true", nil)
-	req.WithContext(ctx)
-	// for simplicity not following standard Authorization: <scheme> <token>
-	req.Header.Set("Id", strconv.Itoa(user1.Id))
-	req.Header.Set("Authorization", loginResponse.Token)
-	req.Header.Set("Content-Type", "application/json")
-	rec := httptest.NewRecorder()
-	testHandler.ServeHTTP(rec, req)
-	resp := rec.Result()
-
-	var discoverResponse DiscoverResponse
-	err = json.NewDecoder(resp.Body).Decode(&discoverResponse)
+	// discovery based on user 1
+	discoverResponse, err := loggedIn(t, user1).Discover(
+		ctx,
+		client.DiscoverOptions{OrderByPopularity: true},
+	)
 
-	// for simplicity not checking exact users match just status code and
-	// correct number of results
-	require.Equal(t, 200, resp.StatusCode)
+	// for simplicity not checking exact users match just correct number of
+	// results
 	require.NoError(t, err)
 	require.Equal(t, 7, len(discoverResponse.Results))
 	// check results are ordered by Popularity;
@@ -307,89 +298,863 @@ func TestInvalidQueryUserDiscovery(t *testing.T) {
 	user1, err := service.CreateUser(ctx)
 	require.NoError(t, err)
 
-	// discovery based on user 1, login to obtain loginResponse
-	loginResponse, err := service.Login(ctx, LoginRequest{user1.Email, user1.Password})
+	// invalid query parameter - minAge is greater than maxAge
+	_, err = loggedIn(t, user1).Discover(
+		ctx,
+		client.DiscoverOptions{Age: &client.AgeRange{Min: 30, Max: 26}},
+	)
+
+	require.ErrorIs(t, err, tinydates.ErrorMinOrMaxFormat)
+	var responseErr *client.ResponseError
+	require.ErrorAs(t, err, &responseErr)
+	require.Equal(t, 400, responseErr.StatusCode)
+}
+
+func TestUnauthorizedUserDiscovery(t *testing.T) {
+	ctx := context.Background()
+
+	// no session has been started
+	_, err := client.New(testServer.URL).Discover(
+		ctx,
+		client.DiscoverOptions{},
+	)
+	require.ErrorIs(t, err, tinydates.ErrUnauthorized)
+}
+
+func TestDiscoveryActsOnTheUserOfTheSession(t *testing.T) {
+	ctx := context.Background()
+	me, err := service.CreateUser(ctx)
+	require.NoError(t, err)
+	other, err := service.CreateUser(ctx)
+	require.NoError(t, err)
+	login, err := service.Login(ctx, tinydates.LoginRequest{
+		Email:    me.Email,
+		Password: me.Password,
+	})
 	require.NoError(t, err)
 
-	// invalid query parameter - minAge is greater than maxAge
-	req := httptest.NewRequest("GET", "/discover?minAge=30&maxAge=26", nil)
-	req.WithContext(ctx)
-	// for simplicity not following standard Authorization: <scheme> <token>
-	req.Header.Set("Id", strconv.Itoa(user1.Id))
-	req.Header.Set("Authorization", loginResponse.Token)
-	req.Header.Set("Content-Type", "application/json")
-	rec := httptest.NewRecorder()
-	testHandler.ServeHTTP(rec, req)
-	resp := rec.Result()
-
-	var discoverResponse DiscoverResponse
-	err = json.NewDecoder(resp.Body).Decode(&discoverResponse)
-
-	// for simplicity not checking exact users match just status code and
-	// correct number of results
-	require.Equal(t, 400, resp.StatusCode)
+	// the Id header of older clients names no one
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, testServer.URL+"/v1/discover", nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", login.Token)
+	req.Header.Set("Id", strconv.Itoa(other.Id))
+	resp, err := http.DefaultClient.Do(req)
 	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var found tinydates.DiscoverResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&found))
+	for _, user := range found.Results {
+		require.NotEqual(t, me.Id, user.Id)
+	}
+
+	// a session outliving its user finds no one
+	require.NoError(t, testCache.StartSession(ctx, "gone", 1<<30))
+	_, err = service.Discover(ctx, "gone", tinydates.DiscoverRequest{})
+	require.ErrorIs(t, err, tinydates.ErrUserNotFound)
 }
 
-func TestUserSwipes(t *testing.T) {
+func TestUserDiscoveryBySort(t *testing.T) {
 	ctx := context.Background()
-	// create new users
 	user1, err := service.CreateUser(ctx)
 	require.NoError(t, err)
 	user2, err := service.CreateUser(ctx)
 	require.NoError(t, err)
 
-	// login both users to obtain tokens
-	user1Login, err := service.Login(ctx, LoginRequest{user1.Email, user1.Password})
-	require.NoError(t, err)
-	user2Login, err := service.Login(ctx, LoginRequest{user2.Email, user2.Password})
+	// user 2 likes user 1, who has yet to swipe them
+	_, err = loggedIn(t, user2).Swipe(ctx, tinydates.SwipeRequest{
+		SwiperId: user2.Id,
+		SwipeeId: user1.Id,
+		Decision: true,
+	})
 	require.NoError(t, err)
 
-	// create swipe requests for user1 and user2 to send
-	user1SwipeRequest := SwipeRequest{SwiperId: user1.Id, SwipeeId: user2.Id, Decision: true}
-	user2SwipeRequest := SwipeRequest{SwiperId: user2.Id, SwipeeId: user1.Id, Decision: true}
+	user1Client := loggedIn(t, user1)
+	ids := func(sort string) []int {
+		t.Helper()
+
+		found, err := user1Client.Discover(ctx, client.DiscoverOptions{Sort: sort})
+		require.NoError(t, err)
+
+		var ids []int
+		for _, result := range found.Results {
+			ids = append(ids, result.Id)
+		}
+		return ids
+	}
+
+	// every strategy ranking the profiles not swiped yet finds the same ones
+	byDistance := ids("")
+	require.Contains(t, byDistance, user2.Id)
+	require.Equal(t, byDistance, ids(ranking.Distance))
+	require.ElementsMatch(t, byDistance, ids(ranking.Recommended))
+
+	_, err = user1Client.Discover(ctx, client.DiscoverOptions{Sort: "newest"})
+	require.ErrorIs(t, err, tinydates.ErrUnknownSort)
+	var responseErr *client.ResponseError
+	require.ErrorAs(t, err, &responseErr)
+	require.Equal(t, 400, responseErr.StatusCode)
+}
 
-	// marshall each request and create readers ready to send
-	user1Json, err := json.Marshal(user1SwipeRequest)
+func TestUserSwipes(t *testing.T) {
+	ctx := context.Background()
+	// create new users
+	user1, err := service.CreateUser(ctx)
 	require.NoError(t, err)
-	user2Json, err := json.Marshal(user2SwipeRequest)
+	user2, err := service.CreateUser(ctx)
 	require.NoError(t, err)
-	user1Reader := bytes.NewReader(user1Json)
-	user2Reader := bytes.NewReader(user2Json)
 
-	// send the first swipe request
-	req := httptest.NewRequest("POST", "/swipe", user1Reader)
-	req.WithContext(ctx)
-	req.Header.Set("Id", strconv.Itoa(user1.Id))
-	req.Header.Set("Authorization", user1Login.Token)
-	req.Header.Set("Content-Type", "application/json")
-	rec := httptest.NewRecorder()
-	testHandler.ServeHTTP(rec, req)
-	resp := rec.Result()
+	// login both users to obtain their sessions
+	user1Client := loggedIn(t, user1)
+	user2Client := loggedIn(t, user2)
 
-	// decode the response and check that matchId is not present primarily
-	var user1SwipeResponse SwipeResponse
-	err = json.NewDecoder(resp.Body).Decode(&user1SwipeResponse)
+	// send the first swipe request and check that matchId is not present
+	// primarily
+	user1SwipeResponse, err := user1Client.Swipe(ctx, tinydates.SwipeRequest{
+		SwiperId: user1.Id,
+		SwipeeId: user2.Id,
+		Decision: true,
+	})
 	require.NoError(t, err)
-	require.Equal(t, 200, resp.StatusCode)
 	require.Equal(t, false, user1SwipeResponse.Matched)
 	require.Empty(t, user1SwipeResponse.MatchId)
 
-	// send the second swipe request
-	req = httptest.NewRequest("POST", "/swipe", user2Reader)
-	req.WithContext(ctx)
-	req.Header.Set("Id", strconv.Itoa(user1.Id))
-	req.Header.Set("Authorization", user2Login.Token)
-	req.Header.Set("Content-Type", "application/json")
-	rec = httptest.NewRecorder()
-	testHandler.ServeHTTP(rec, req)
-	resp = rec.Result()
-
-	// decode the response and check that matchId is not present primarily
-	var user2SwipeResponse SwipeResponse
-	err = json.NewDecoder(resp.Body).Decode(&user2SwipeResponse)
-	require.NoError(t, err)
-	require.Equal(t, 200, resp.StatusCode)
+	// send the second swipe request which makes the match
+	user2SwipeResponse, err := user2Client.Swipe(ctx, tinydates.SwipeRequest{
+		SwiperId: user2.Id,
+		SwipeeId: user1.Id,
+		Decision: true,
+	})
+	require.NoError(t, err)
 	require.Equal(t, true, user2SwipeResponse.Matched)
 	require.NotEmpty(t, user2SwipeResponse.MatchId)
 }
+
+func TestSwipesRateTheSwipee(t *testing.T) {
+	ctx := context.Background()
+	swiper, err := service.CreateUser(ctx)
+	require.NoError(t, err)
+	liked, err := service.CreateUser(ctx)
+	require.NoError(t, err)
+	passed, err := service.CreateUser(ctx)
+	require.NoError(t, err)
+
+	swiperClient := loggedIn(t, swiper)
+	for swipee, decision := range map[int]bool{liked.Id: true, passed.Id: false} {
+		_, err := swiperClient.Swipe(ctx, tinydates.SwipeRequest{
+			SwiperId: swiper.Id,
+			SwipeeId: swipee,
+			Decision: decision,
+		})
+		require.NoError(t, err)
+	}
+
+	desirability := func(id int) float64 {
+		score, err := testStore.GetDesirability(ctx, id)
+		require.NoError(t, err)
+		return score
+	}
+	require.Greater(t, desirability(liked.Id), store.InitialDesirability)
+	require.Less(t, desirability(passed.Id), store.InitialDesirability)
+	require.Equal(t, store.InitialDesirability, desirability(swiper.Id))
+}
+
+func TestDiscoveryIsServedFromDecks(t *testing.T) {
+	ctx := context.Background()
+	decks := tinydates.New(
+		testStore,
+		testCache,
+		nil,
+		tinydates.WithDecks(tinydates.DeckConfig{
+			Size:     4,
+			PageSize: 2,
+			Refill:   3,
+			TTL:      time.Hour,
+		}),
+	)
+
+	user, err := decks.CreateUser(ctx)
+	require.NoError(t, err)
+	for i := 0; i < 5; i++ {
+		_, err := decks.CreateUser(ctx)
+		require.NoError(t, err)
+	}
+	login, err := decks.Login(ctx, tinydates.LoginRequest{
+		Email:    user.Email,
+		Password: user.Password,
+	})
+	require.NoError(t, err)
+
+	discover := func(limit int) []int {
+		t.Helper()
+
+		found, err := decks.Discover(ctx, login.Token, tinydates.DiscoverRequest{
+			Limit: limit,
+		})
+		require.NoError(t, err)
+
+		var ids []int
+		for _, result := range found.Results {
+			ids = append(ids, result.Id)
+		}
+		return ids
+	}
+	swipe := func(swipee int) {
+		t.Helper()
+
+		_, err := decks.Swipe(ctx, login.Token, tinydates.SwipeRequest{
+			SwiperId: user.Id,
+			SwipeeId: swipee,
+		})
+		require.NoError(t, err)
+	}
+
+	// each page of the deck follows the last one served, with no swipe in
+	// between
+	deck, ok, err := testCache.Deck(ctx, user.Id)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	page := discover(0)
+	require.Len(t, page, 2)
+	next := discover(0)
+	require.Len(t, next, 2)
+	for _, id := range next {
+		require.NotContains(t, page, id)
+	}
+
+	// running low, the deck is refilled in the background after the
+	// profiles served
+	require.Eventually(t, func() bool {
+		deck, ok, err = testCache.Deck(ctx, user.Id)
+		return err == nil && ok && len(deck.Ids) > 4
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Equal(t, page, deck.Ids[:2])
+	require.GreaterOrEqual(t, deck.Served, 2)
+
+	// a swiped profile is never served again
+	unserved := deck.Ids[len(deck.Ids)-1]
+	swipe(unserved)
+	require.NotContains(t, discover(10), unserved)
+
+	// another age range is another deck
+	minAge, maxAge := 200, 300
+	found, err := decks.Discover(ctx, login.Token, tinydates.DiscoverRequest{
+		MinAge: &minAge,
+		MaxAge: &maxAge,
+	})
+	require.NoError(t, err)
+	require.Empty(t, found.Results)
+
+	// as are other preferences
+	_, err = decks.SetPreferences(ctx, login.Token, tinydates.Preferences{
+		MinAge:       &minAge,
+		Dealbreakers: []string{tinydates.DealbreakerAge},
+	})
+	require.NoError(t, err)
+	found, err = decks.Discover(ctx, login.Token, tinydates.DiscoverRequest{})
+	require.NoError(t, err)
+	require.Empty(t, found.Results)
+}
+
+func TestDeckRefillKeepsThePagesServedMeanwhile(t *testing.T) {
+	ctx := context.Background()
+
+	// the refill ranks once held is closed, the build before it at once
+	held := make(chan struct{})
+	var sourced atomic.Int32
+	strategies := ranking.NewStrategies()
+	require.NoError(t, strategies.Add("held", ranking.Strategy{
+		Source: func(
+			ctx context.Context,
+			s store.Store,
+			id int,
+			filters store.Filters,
+		) ([]store.PotentialMatch, error) {
+			if sourced.Add(1) > 1 {
+				<-held
+			}
+			return ranking.Unswiped(ctx, s, id, filters)
+		},
+		Ranker: ranking.ByDistance{},
+	}))
+	decks := tinydates.New(
+		testStore,
+		testCache,
+		nil,
+		tinydates.WithStrategies(strategies),
+		tinydates.WithDecks(tinydates.DeckConfig{
+			Size:     4,
+			PageSize: 2,
+			Refill:   3,
+			TTL:      time.Hour,
+		}),
+	)
+
+	user, err := decks.CreateUser(ctx)
+	require.NoError(t, err)
+	for i := 0; i < 6; i++ {
+		_, err := decks.CreateUser(ctx)
+		require.NoError(t, err)
+	}
+	login, err := decks.Login(ctx, tinydates.LoginRequest{
+		Email:    user.Email,
+		Password: user.Password,
+	})
+	require.NoError(t, err)
+
+	discover := func() []int {
+		t.Helper()
+
+		found, err := decks.Discover(ctx, login.Token, tinydates.DiscoverRequest{Sort: "held"})
+		require.NoError(t, err)
+
+		var ids []int
+		for _, result := range found.Results {
+			ids = append(ids, result.Id)
+		}
+		return ids
+	}
+
+	// the first page starts a refill, the second is served while it ranks
+	served := discover()
+	served = append(served, discover()...)
+	require.Len(t, served, 4)
+	close(held)
+
+	var deck cache.Deck
+	require.Eventually(t, func() bool {
+		var ok bool
+		deck, ok, err = testCache.Deck(ctx, user.Id)
+		return err == nil && ok && len(deck.Ids) > 4
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Equal(t, served, deck.Ids[:4])
+	require.Equal(t, 4, deck.Served)
+
+	for _, id := range discover() {
+		require.NotContains(t, served, id)
+	}
+}
+
+func TestDecksAreRebuiltWithReloadedWeights(t *testing.T) {
+	ctx := context.Background()
+	strategies := ranking.NewStrategies()
+	decks := tinydates.New(
+		testStore,
+		testCache,
+		nil,
+		tinydates.WithStrategies(strategies),
+		tinydates.WithDecks(tinydates.DefaultDeckConfig()),
+	)
+
+	user, err := decks.CreateUser(ctx)
+	require.NoError(t, err)
+	login, err := decks.Login(ctx, tinydates.LoginRequest{
+		Email:    user.Email,
+		Password: user.Password,
+	})
+	require.NoError(t, err)
+
+	key := func() string {
+		t.Helper()
+
+		_, err := decks.Discover(ctx, login.Token, tinydates.DiscoverRequest{
+			Sort: ranking.Recommended,
+		})
+		require.NoError(t, err)
+
+		deck, ok, err := testCache.Deck(ctx, user.Id)
+		require.NoError(t, err)
+		require.True(t, ok)
+		return deck.Key
+	}
+
+	built := key()
+	require.Equal(t, built, key())
+
+	weights := ranking.DefaultWeights
+	weights.Distance = 2
+	require.NoError(t, strategies.SetWeights(map[string]ranking.Weights{ranking.Recommended: weights}))
+	require.NotEqual(t, built, key())
+}
+
+func TestDecksAreRebuiltWithReloadedModels(t *testing.T) {
+	ctx := context.Background()
+	recommender := recommend.NewRecommender(nil)
+	strategies := ranking.NewStrategies()
+	require.NoError(t, strategies.Add(recommend.Name, recommender.Strategy()))
+	decks := tinydates.New(
+		testStore,
+		testCache,
+		nil,
+		tinydates.WithStrategies(strategies),
+		tinydates.WithDecks(tinydates.DefaultDeckConfig()),
+	)
+
+	user, err := decks.CreateUser(ctx)
+	require.NoError(t, err)
+	login, err := decks.Login(ctx, tinydates.LoginRequest{
+		Email:    user.Email,
+		Password: user.Password,
+	})
+	require.NoError(t, err)
+
+	key := func() string {
+		t.Helper()
+
+		_, err := decks.Discover(ctx, login.Token, tinydates.DiscoverRequest{
+			Sort: recommend.Name,
+		})
+		require.NoError(t, err)
+
+		deck, ok, err := testCache.Deck(ctx, user.Id)
+		require.NoError(t, err)
+		require.True(t, ok)
+		return deck.Key
+	}
+
+	// the first model replaces ranking by distance alone, then each model
+	// trained after it replaces the one before
+	built := key()
+	require.Equal(t, built, key())
+	for _, trainedAt := range []time.Time{
+		time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC),
+	} {
+		recommender.Set(&recommend.Model{TrainedAt: trainedAt})
+		reloaded := key()
+		require.NotEqual(t, built, reloaded)
+		built = reloaded
+	}
+}
+
+func TestDiscoveryAppliesStoredPreferences(t *testing.T) {
+	ctx := context.Background()
+
+	// far from every user created at random, so that only these are close
+	newUser := func(email, gender string, age, location int) int {
+		t.Helper()
+
+		id, err := testStore.StoreNewUser(ctx, email, "password", email, gender, born(age), location)
+		require.NoError(t, err)
+		return id
+	}
+	newUser("preferences@mail.com", "male", 30, 1000)
+	match := newUser("preferred@mail.com", "female", 30, 1005)
+	other := newUser("other@mail.com", "male", 50, 1002)
+	far := newUser("far@mail.com", "female", 30, 1040)
+
+	c := client.New(testServer.URL)
+	_, err := c.Login(ctx, "preferences@mail.com", "password")
+	require.NoError(t, err)
+
+	preferences, err := c.Preferences(ctx)
+	require.NoError(t, err)
+	require.Equal(t, tinydates.Preferences{Genders: []string{}, Dealbreakers: []string{}}, preferences)
+
+	minAge, maxAge, maxDistance := 25, 35, 10
+	want := tinydates.Preferences{
+		Genders:      []string{"female"},
+		MinAge:       &minAge,
+		MaxAge:       &maxAge,
+		MaxDistance:  &maxDistance,
+		Dealbreakers: []string{tinydates.DealbreakerDistance},
+	}
+	preferences, err = c.SetPreferences(ctx, want)
+	require.NoError(t, err)
+	require.Equal(t, want, preferences)
+	preferences, err = c.Preferences(ctx)
+	require.NoError(t, err)
+	require.Equal(t, want, preferences)
+
+	ids := func(opts client.DiscoverOptions) []int {
+		t.Helper()
+
+		found, err := c.Discover(ctx, opts)
+		require.NoError(t, err)
+
+		var ids []int
+		for _, result := range found.Results {
+			ids = append(ids, result.Id)
+		}
+		return ids
+	}
+
+	// distance is a dealbreaker, the other preferences only rank the
+	// profiles meeting them ahead of closer ones
+	require.Equal(t, []int{match, other}, ids(client.DiscoverOptions{}))
+
+	// the query replaces a preference for the request, as a dealbreaker
+	require.Equal(t, []int{match}, ids(client.DiscoverOptions{Genders: []string{"female"}}))
+	wide := 50
+	require.Equal(t, []int{match, far, other}, ids(client.DiscoverOptions{MaxDistance: &wide}))
+	require.Equal(t, []int{other}, ids(client.DiscoverOptions{
+		Age: &client.AgeRange{Min: 45},
+	}))
+
+	for _, invalid := range []tinydates.Preferences{
+		{MinAge: &maxAge, MaxAge: &minAge},
+		{Dealbreakers: []string{"height"}},
+	} {
+		_, err = c.SetPreferences(ctx, invalid)
+		require.ErrorIs(t, err, tinydates.ErrPreferencesInvalid)
+	}
+	_, err = c.SetPreferences(ctx, tinydates.Preferences{Genders: []string{"robot"}})
+	require.ErrorIs(t, err, tinydates.ErrUnknownGender)
+	_, err = c.Discover(ctx, client.DiscoverOptions{Genders: []string{"robot"}})
+	require.ErrorIs(t, err, tinydates.ErrUnknownGender)
+
+	// the preferences are those of the user logged in, whoever they claim
+	// to be
+	_, err = client.New(testServer.URL).Preferences(ctx)
+	require.ErrorIs(t, err, tinydates.ErrUnauthorized)
+}
+
+func TestDiscoveryHonoursPreferencesBothWays(t *testing.T) {
+	ctx := context.Background()
+
+	_, err := testStore.StoreNewUser(ctx, "both-ways@mail.com", "password", "me", "male", born(30), 2000)
+	require.NoError(t, err)
+	picky, err := testStore.StoreNewUser(ctx, "picky@mail.com", "password", "picky", "female", born(30), 2001)
+	require.NoError(t, err)
+
+	login, err := service.Login(ctx, tinydates.LoginRequest{
+		Email:    "both-ways@mail.com",
+		Password: "password",
+	})
+	require.NoError(t, err)
+
+	near := 5
+	discovered := func() []int {
+		t.Helper()
+
+		found, err := service.Discover(ctx, login.Token, tinydates.DiscoverRequest{
+			MaxDistance: &near,
+		})
+		require.NoError(t, err)
+
+		var ids []int
+		for _, result := range found.Results {
+			ids = append(ids, result.Id)
+		}
+		return ids
+	}
+	require.Equal(t, []int{picky}, discovered())
+
+	pickyLogin, err := service.Login(ctx, tinydates.LoginRequest{
+		Email:    "picky@mail.com",
+		Password: "password",
+	})
+	require.NoError(t, err)
+
+	// picky is not interested in me, so they are not shown to me either
+	preferences := tinydates.Preferences{Genders: []string{"female"}}
+	_, err = service.SetPreferences(ctx, pickyLogin.Token, preferences)
+	require.NoError(t, err)
+	require.Empty(t, discovered())
+
+	// unless they ask to be shown outside their preferences
+	preferences.ShowOutsidePreferences = true
+	_, err = service.SetPreferences(ctx, pickyLogin.Token, preferences)
+	require.NoError(t, err)
+	require.Equal(t, []int{picky}, discovered())
+}
+
+func TestGenderIdentity(t *testing.T) {
+	ctx := context.Background()
+
+	_, err := testStore.StoreNewUser(ctx, "identity@mail.com", "password", "me", "male", born(30), 3000)
+	require.NoError(t, err)
+	them, err := testStore.StoreNewUser(ctx, "them@mail.com", "password", "them", "female", born(30), 3001)
+	require.NoError(t, err)
+
+	c := client.New(testServer.URL)
+	taxonomy, err := c.Taxonomy(ctx)
+	require.NoError(t, err)
+	require.Contains(t, taxonomy.Genders, tinydates.Gender{Id: "nonbinary", Label: "Non-binary"})
+	require.Contains(t, taxonomy.Pronouns, "they/them")
+
+	_, err = c.Login(ctx, "identity@mail.com", "password")
+	require.NoError(t, err)
+	theirs := client.New(testServer.URL)
+	_, err = theirs.Login(ctx, "them@mail.com", "password")
+	require.NoError(t, err)
+
+	// any spelling of the taxonomy is stored as it is spelled there
+	identity, err := theirs.SetIdentity(ctx, tinydates.Identity{
+		Gender:   "Non-binary",
+		Pronouns: "They / Them",
+	})
+	require.NoError(t, err)
+	require.Equal(t, tinydates.Identity{Gender: "nonbinary", Pronouns: "they/them"}, identity)
+	identity, err = theirs.Identity(ctx)
+	require.NoError(t, err)
+	require.Equal(t, tinydates.Identity{Gender: "nonbinary", Pronouns: "they/them"}, identity)
+
+	// discovery filters on the genders by any spelling too
+	near := 5
+	found, err := c.Discover(ctx, client.DiscoverOptions{
+		Genders:     []string{"enby"},
+		MaxDistance: &near,
+	})
+	require.NoError(t, err)
+	require.Len(t, found.Results, 1)
+	require.Equal(t, them, found.Results[0].Id)
+	require.Equal(t, "they/them", found.Results[0].Pronouns)
+
+	// who a user is interested in is apart from their own gender
+	preferences, err := c.SetPreferences(ctx, tinydates.Preferences{
+		Genders: []string{"Woman", "f", "nb"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"female", "nonbinary"}, preferences.Genders)
+	identity, err = c.Identity(ctx)
+	require.NoError(t, err)
+	require.Equal(t, tinydates.Identity{Gender: "male"}, identity)
+
+	_, err = c.SetIdentity(ctx, tinydates.Identity{Gender: "robot"})
+	require.ErrorIs(t, err, tinydates.ErrUnknownGender)
+	_, err = c.SetIdentity(ctx, tinydates.Identity{Gender: "male", Pronouns: "it/its"})
+	require.ErrorIs(t, err, tinydates.ErrUnknownPronouns)
+	_, err = client.New(testServer.URL).Identity(ctx)
+	require.ErrorIs(t, err, tinydates.ErrUnauthorized)
+
+	// users are created with a gender of the taxonomy
+	user, err := service.CreateUser(ctx)
+	require.NoError(t, err)
+	require.Contains(t, []string{"female", "male", "nonbinary", "other"}, user.Gender)
+}
+
+func TestAbout(t *testing.T) {
+	ctx := context.Background()
+
+	_, err := testStore.StoreNewUser(ctx, "about@mail.com", "password", "me", "male", born(30), 5000)
+	require.NoError(t, err)
+	near, err := testStore.StoreNewUser(ctx, "near@mail.com", "password", "near", "female", born(30), 5001)
+	require.NoError(t, err)
+	alike, err := testStore.StoreNewUser(ctx, "alike@mail.com", "password", "alike", "female", born(30), 5002)
+	require.NoError(t, err)
+
+	c := client.New(testServer.URL)
+	catalog, err := c.Catalog(ctx)
+	require.NoError(t, err)
+	require.Contains(t, catalog.Prompts, tinydates.Prompt{Id: "ideal-sunday", Text: "My ideal Sunday is…"})
+	require.Contains(t, catalog.Interests, tinydates.Interest{Id: "board-games", Label: "Board games", Category: "games"})
+
+	_, err = c.Login(ctx, "about@mail.com", "password")
+	require.NoError(t, err)
+
+	// stored trimmed, the interests by id without duplicates
+	about, err := c.SetAbout(ctx, tinydates.About{
+		Bio:       "  Mostly outside.\nSometimes not.  ",
+		Prompts:   []tinydates.PromptAnswer{{Prompt: "ideal-sunday", Answer: " A long walk. "}},
+		Interests: []string{"Hiking", "board games", "hiking", "tea"},
+	})
+	require.NoError(t, err)
+	want := tinydates.About{
+		Bio:       "Mostly outside.\nSometimes not.",
+		Prompts:   []tinydates.PromptAnswer{{Prompt: "ideal-sunday", Answer: "A long walk."}},
+		Interests: []string{"board-games", "hiking", "tea"},
+	}
+	require.Equal(t, want, about)
+	about, err = c.About(ctx)
+	require.NoError(t, err)
+	require.Equal(t, want, about)
+
+	for email, interests := range map[string][]string{
+		"near@mail.com":  {"tea"},
+		"alike@mail.com": {"hiking", "tea"},
+	} {
+		other := client.New(testServer.URL)
+		_, err = other.Login(ctx, email, "password")
+		require.NoError(t, err)
+		_, err = other.SetAbout(ctx, tinydates.About{Interests: interests})
+		require.NoError(t, err)
+	}
+
+	// distance ranks near first, the shared interests rank alike first
+	nearby := 5
+	found, err := c.Discover(ctx, client.DiscoverOptions{MaxDistance: &nearby})
+	require.NoError(t, err)
+	require.Len(t, found.Results, 2)
+	require.Equal(t, near, found.Results[0].Id)
+	require.Equal(t, 1, found.Results[0].SharedInterests)
+	found, err = c.Discover(ctx, client.DiscoverOptions{MaxDistance: &nearby, Sort: "interests"})
+	require.NoError(t, err)
+	require.Len(t, found.Results, 2)
+	require.Equal(t, alike, found.Results[0].Id)
+	require.Equal(t, 2, found.Results[0].SharedInterests)
+	require.Equal(t, near, found.Results[1].Id)
+
+	_, err = c.SetAbout(ctx, tinydates.About{Bio: strings.Repeat("é", 501)})
+	require.ErrorIs(t, err, tinydates.ErrAboutInvalid)
+	_, err = c.SetAbout(ctx, tinydates.About{Bio: "bell\a"})
+	require.ErrorIs(t, err, tinydates.ErrAboutInvalid)
+	_, err = c.SetAbout(ctx, tinydates.About{
+		Prompts: []tinydates.PromptAnswer{
+			{Prompt: "green-flag", Answer: "Kindness."},
+			{Prompt: "green-flag", Answer: "Punctuality."},
+		},
+	})
+	require.ErrorIs(t, err, tinydates.ErrAboutInvalid)
+	_, err = c.SetAbout(ctx, tinydates.About{
+		Prompts: []tinydates.PromptAnswer{{Prompt: "green-flag", Answer: " "}},
+	})
+	require.ErrorIs(t, err, tinydates.ErrAboutInvalid)
+	_, err = c.SetAbout(ctx, tinydates.About{
+		Prompts: []tinydates.PromptAnswer{{Prompt: "favourite-colour", Answer: "Blue."}},
+	})
+	require.ErrorIs(t, err, tinydates.ErrUnknownPrompt)
+	_, err = c.SetAbout(ctx, tinydates.About{Interests: []string{"knitting"}})
+	require.ErrorIs(t, err, tinydates.ErrUnknownInterest)
+	_, err = c.SetAbout(ctx, tinydates.About{Interests: []string{
+		"hiking", "camping", "climbing", "cycling", "gardening", "running",
+		"football", "swimming", "tennis", "yoga", "cooking",
+	}})
+	require.ErrorIs(t, err, tinydates.ErrAboutInvalid)
+
+	// a rejected change leaves the profile as it was
+	about, err = c.About(ctx)
+	require.NoError(t, err)
+	require.Equal(t, want, about)
+}
+
+func TestPhotos(t *testing.T) {
+	ctx := context.Background()
+
+	files, err := photos.NewFileStore(t.TempDir(), "/photos")
+	require.NoError(t, err)
+	server := httptest.NewServer(tinydates.NewTinydatesHandler(
+		tinydates.New(testStore, testCache, nil, tinydates.WithPhotos(files, photos.DefaultLimits())),
+		tinydates.HandlerConfig{Photos: files},
+	))
+	defer server.Close()
+
+	_, err = testStore.StoreNewUser(ctx, "photos@mail.com", "password", "me", "male", born(30), 4000)
+	require.NoError(t, err)
+	them, err := testStore.StoreNewUser(ctx, "pictured@mail.com", "password", "them", "female", born(30), 4001)
+	require.NoError(t, err)
+
+	c := client.New(server.URL)
+	_, err = c.Login(ctx, "photos@mail.com", "password")
+	require.NoError(t, err)
+	theirs := client.New(server.URL)
+	_, err = theirs.Login(ctx, "pictured@mail.com", "password")
+	require.NoError(t, err)
+
+	upload := func(w, h int) io.Reader {
+		var buf bytes.Buffer
+		require.NoError(t, jpeg.Encode(&buf, image.NewGray(image.Rect(0, 0, w, h)), nil))
+		return &buf
+	}
+	fetch := func(url string) image.Config {
+		t.Helper()
+
+		resp, err := http.Get(server.URL + url)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		config, err := jpeg.DecodeConfig(resp.Body)
+		require.NoError(t, err)
+		return config
+	}
+
+	first, err := theirs.AddPhoto(ctx, upload(2000, 1000))
+	require.NoError(t, err)
+	require.Equal(t, 1080, first.Width)
+	require.Equal(t, 540, first.Height)
+	stored := fetch(first.Url)
+	require.Equal(t, []int{1080, 540}, []int{stored.Width, stored.Height})
+	thumbnail := fetch(first.ThumbnailUrl)
+	require.Equal(t, []int{320, 320}, []int{thumbnail.Width, thumbnail.Height})
+
+	second, err := theirs.AddPhoto(ctx, upload(300, 400))
+	require.NoError(t, err)
+
+	listed, err := theirs.Photos(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []tinydates.Photo{first, second}, listed)
+
+	// discovered profiles come with their photos
+	near := 5
+	found, err := c.Discover(ctx, client.DiscoverOptions{MaxDistance: &near})
+	require.NoError(t, err)
+	require.Len(t, found.Results, 1)
+	require.Equal(t, them, found.Results[0].Id)
+	require.Equal(t, []tinydates.Photo{first, second}, found.Results[0].Photos)
+
+	reordered, err := theirs.ReorderPhotos(ctx, []int{second.Id, first.Id})
+	require.NoError(t, err)
+	require.Equal(t, []tinydates.Photo{second, first}, reordered)
+	listed, err = theirs.Photos(ctx)
+	require.NoError(t, err)
+	require.Equal(t, reordered, listed)
+
+	// the order names every photo of the user, once
+	for _, ids := range [][]int{{second.Id}, {second.Id, second.Id}, {second.Id, first.Id + 100}} {
+		_, err = theirs.ReorderPhotos(ctx, ids)
+		require.ErrorIs(t, err, tinydates.ErrPhotoOrderInvalid, ids)
+	}
+
+	require.NoError(t, theirs.DeletePhoto(ctx, first.Id))
+	require.ErrorIs(t, theirs.DeletePhoto(ctx, first.Id), tinydates.ErrPhotoNotFound)
+	resp, err := http.Get(server.URL + first.Url)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	// the photos of another user cannot be deleted, nor listed, by someone
+	// else
+	require.ErrorIs(t, c.DeletePhoto(ctx, second.Id), tinydates.ErrPhotoNotFound)
+	listed, err = c.Photos(ctx)
+	require.NoError(t, err)
+	require.Empty(t, listed)
+
+	_, err = c.AddPhoto(ctx, strings.NewReader("not an image"))
+	require.ErrorIs(t, err, tinydates.ErrPhotoUnsupported)
+	_, err = c.AddPhoto(ctx, upload(100, 100))
+	require.ErrorIs(t, err, tinydates.ErrPhotoInvalid)
+
+	for i := 0; i < 6; i++ {
+		_, err = c.AddPhoto(ctx, upload(200, 200))
+		require.NoError(t, err)
+	}
+	_, err = c.AddPhoto(ctx, upload(200, 200))
+	require.ErrorIs(t, err, tinydates.ErrPhotoLimit)
+
+	// the service answers without photos when they are not configured
+	_, err = loggedIn(t, tinydates.User{Email: "photos@mail.com", Password: "password"}).Photos(ctx)
+	require.ErrorIs(t, err, tinydates.ErrPhotosUnavailable)
+}
+
+func TestCancelledContextAbortsStoreQueries(t *testing.T) {
+	user, err := service.CreateUser(context.Background())
+	require.NoError(t, err)
+
+	// a context cancelled before the query, as when the client disconnects
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = testStore.Discover(cancelled, user.Id, store.Filters{})
+	require.ErrorIs(t, err, store.ErrCanceled)
+	_, err = testStore.GetLocation(cancelled, user.Id)
+	require.ErrorIs(t, err, store.ErrCanceled)
+
+	// a context whose deadline has passed reports a timeout instead
+	expired, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+	defer cancel()
+	<-expired.Done()
+
+	_, err = testStore.DiscoverByPopularity(expired, user.Id, store.Filters{})
+	require.ErrorIs(t, err, store.ErrTimeout)
+	_, err = testStore.Swipe(expired, user.Id, user.Id, true)
+	require.ErrorIs(t, err, store.ErrTimeout)
+
+	// the service keeps the distinction so that it maps onto 503 and 504
+	_, err = service.CreateUser(expired)
+	require.ErrorIs(t, err, tinydates.ErrTimeout)
+	require.ErrorIs(t, err, store.ErrTimeout)
+	_, err = service.CreateUser(cancelled)
+	require.ErrorIs(t, err, tinydates.ErrCanceled)
+}
@@ -0,0 +1,231 @@
+package tinydates
+
+import (
+	"context"
+	"io"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracedService decorates a Service starting a span around every method, the
+// span context is passed on so store and cache spans become its children.
+type tracedService struct {
+	next   Service
+	tracer trace.Tracer
+}
+
+// NewTracedService wraps next creating spans with tp.
+func NewTracedService(next Service, tp trace.TracerProvider) Service {
+	return &tracedService{next: next, tracer: tp.Tracer("tinydates/service")}
+}
+
+// endSpan records err, if any, on span before ending it.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+func (s *tracedService) CreateUser(ctx context.Context) (User, error) {
+	ctx, span := s.tracer.Start(ctx, "Service.CreateUser")
+	user, err := s.next.CreateUser(ctx)
+	if err == nil {
+		span.SetAttributes(attribute.Int("user.id", user.Id))
+	}
+	endSpan(span, err)
+
+	return user, err
+}
+
+func (s *tracedService) Login(
+	ctx context.Context,
+	req LoginRequest,
+) (LoginResponse, error) {
+	ctx, span := s.tracer.Start(ctx, "Service.Login")
+	resp, err := s.next.Login(ctx, req)
+	endSpan(span, err)
+
+	return resp, err
+}
+
+func (s *tracedService) Discover(
+	ctx context.Context,
+	token string,
+	req DiscoverRequest,
+) (DiscoverResponse, error) {
+	ctx, span := s.tracer.Start(
+		ctx,
+		"Service.Discover",
+		trace.WithAttributes(
+			attribute.String("discover.sort", req.Sort),
+			attribute.Int("discover.limit", req.Limit),
+		),
+	)
+	resp, err := s.next.Discover(ctx, token, req)
+	if err == nil {
+		span.SetAttributes(attribute.Int("discover.results", len(resp.Results)))
+	}
+	endSpan(span, err)
+
+	return resp, err
+}
+
+func (s *tracedService) GetPreferences(
+	ctx context.Context,
+	token string,
+) (Preferences, error) {
+	ctx, span := s.tracer.Start(ctx, "Service.GetPreferences")
+	preferences, err := s.next.GetPreferences(ctx, token)
+	endSpan(span, err)
+
+	return preferences, err
+}
+
+func (s *tracedService) SetPreferences(
+	ctx context.Context,
+	token string,
+	preferences Preferences,
+) (Preferences, error) {
+	ctx, span := s.tracer.Start(ctx, "Service.SetPreferences")
+	preferences, err := s.next.SetPreferences(ctx, token, preferences)
+	endSpan(span, err)
+
+	return preferences, err
+}
+
+// Taxonomy is not traced, it is read from memory.
+func (s *tracedService) Taxonomy(ctx context.Context) Taxonomy {
+	return s.next.Taxonomy(ctx)
+}
+
+func (s *tracedService) GetIdentity(
+	ctx context.Context,
+	token string,
+) (Identity, error) {
+	ctx, span := s.tracer.Start(ctx, "Service.GetIdentity")
+	identity, err := s.next.GetIdentity(ctx, token)
+	endSpan(span, err)
+
+	return identity, err
+}
+
+func (s *tracedService) SetIdentity(
+	ctx context.Context,
+	token string,
+	identity Identity,
+) (Identity, error) {
+	ctx, span := s.tracer.Start(ctx, "Service.SetIdentity")
+	identity, err := s.next.SetIdentity(ctx, token, identity)
+	endSpan(span, err)
+
+	return identity, err
+}
+
+func (s *tracedService) GetPhotos(
+	ctx context.Context,
+	token string,
+) ([]Photo, error) {
+	ctx, span := s.tracer.Start(ctx, "Service.GetPhotos")
+	photos, err := s.next.GetPhotos(ctx, token)
+	endSpan(span, err)
+
+	return photos, err
+}
+
+func (s *tracedService) AddPhoto(
+	ctx context.Context,
+	token string,
+	upload io.Reader,
+) (Photo, error) {
+	ctx, span := s.tracer.Start(ctx, "Service.AddPhoto")
+	photo, err := s.next.AddPhoto(ctx, token, upload)
+	if err == nil {
+		span.SetAttributes(attribute.Int("photo.id", photo.Id))
+	}
+	endSpan(span, err)
+
+	return photo, err
+}
+
+func (s *tracedService) ReorderPhotos(
+	ctx context.Context,
+	token string,
+	photoIds []int,
+) ([]Photo, error) {
+	ctx, span := s.tracer.Start(ctx, "Service.ReorderPhotos")
+	photos, err := s.next.ReorderPhotos(ctx, token, photoIds)
+	endSpan(span, err)
+
+	return photos, err
+}
+
+func (s *tracedService) DeletePhoto(
+	ctx context.Context,
+	token string,
+	photoId int,
+) error {
+	ctx, span := s.tracer.Start(
+		ctx,
+		"Service.DeletePhoto",
+		trace.WithAttributes(attribute.Int("photo.id", photoId)),
+	)
+	err := s.next.DeletePhoto(ctx, token, photoId)
+	endSpan(span, err)
+
+	return err
+}
+
+// Catalog is not traced, it is read from memory.
+func (s *tracedService) Catalog(ctx context.Context) Catalog {
+	return s.next.Catalog(ctx)
+}
+
+func (s *tracedService) GetAbout(
+	ctx context.Context,
+	token string,
+) (About, error) {
+	ctx, span := s.tracer.Start(ctx, "Service.GetAbout")
+	a, err := s.next.GetAbout(ctx, token)
+	endSpan(span, err)
+
+	return a, err
+}
+
+func (s *tracedService) SetAbout(
+	ctx context.Context,
+	token string,
+	a About,
+) (About, error) {
+	ctx, span := s.tracer.Start(ctx, "Service.SetAbout")
+	a, err := s.next.SetAbout(ctx, token, a)
+	endSpan(span, err)
+
+	return a, err
+}
+
+func (s *tracedService) Swipe(
+	ctx context.Context,
+	token string,
+	req SwipeRequest,
+) (SwipeResponse, error) {
+	ctx, span := s.tracer.Start(
+		ctx,
+		"Service.Swipe",
+		trace.WithAttributes(
+			attribute.Int("swipe.swiper_id", req.SwiperId),
+			attribute.Int("swipe.swipee_id", req.SwipeeId),
+			attribute.Bool("swipe.decision", req.Decision),
+		),
+	)
+	resp, err := s.next.Swipe(ctx, token, req)
+	if err == nil {
+		span.SetAttributes(attribute.Bool("swipe.matched", resp.Matched))
+	}
+	endSpan(span, err)
+
+	return resp, err
+}
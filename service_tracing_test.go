@@ -0,0 +1,51 @@
+package tinydates
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracingContinuesIncomingTraceContext(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	stub := &stubService{}
+	handler := NewTinydatesHandler(
+		NewTracedService(stub, tp),
+		HandlerConfig{TracerProvider: tp},
+	)
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	req := httptest.NewRequest("GET", "/user/create", nil)
+	req.Header.Set(
+		"traceparent",
+		"00-"+traceID+"-00f067aa0ba902b7-01",
+	)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	require.Equal(t, 201, rec.Code)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+
+	// the service span ends first and is a child of the server span, which
+	// in turn continues the trace supplied by the caller
+	serviceSpan, serverSpan := spans[0], spans[1]
+	require.Equal(t, "Service.CreateUser", serviceSpan.Name)
+	require.Equal(t, "/user/create", serverSpan.Name)
+	require.Equal(t, traceID, serverSpan.SpanContext.TraceID().String())
+	require.Equal(t, serverSpan.SpanContext.SpanID(), serviceSpan.Parent.SpanID())
+
+	// the context handed to the service belongs to the request
+	require.Equal(
+		t,
+		serviceSpan.SpanContext.SpanID(),
+		trace.SpanFromContext(stub.ctx).SpanContext().SpanID(),
+	)
+}
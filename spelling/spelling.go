@@ -0,0 +1,50 @@
+// Package spelling indexes the values users pick from by the spellings they
+// may type them in, so that "Board Games" or " non-binary" find the value
+// listed as "board-games" or "nonbinary".
+package spelling
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Index holds the value each spelling stands for, the zero value is not
+// usable, an Index is made with New.
+type Index struct {
+	values map[string]string
+}
+
+// New returns an empty index.
+func New() Index {
+	return Index{values: make(map[string]string)}
+}
+
+// Add indexes name as a spelling of value, failing when name is empty once
+// normalised or already spells another value.
+func (idx Index) Add(name string, value string) error {
+	key := Normalise(name)
+	if key == "" {
+		return errors.New("empty name")
+	}
+	if existing, ok := idx.values[key]; ok && existing != value {
+		return fmt.Errorf("%q is already a spelling of %q", name, existing)
+	}
+
+	idx.values[key] = value
+	return nil
+}
+
+// Lookup returns the value spelled name, false when there is none.
+func (idx Index) Lookup(name string) (string, bool) {
+	value, ok := idx.values[Normalise(name)]
+	return value, ok
+}
+
+// Normalise folds the spellings of a name together: case, surrounding space
+// and the spaces, hyphens and underscores within are ignored.
+func Normalise(name string) string {
+	return strings.NewReplacer(" ", "", "-", "", "_", "").Replace(
+		strings.ToLower(strings.TrimSpace(name)),
+	)
+}
@@ -0,0 +1,44 @@
+package spelling_test
+
+import (
+	"testing"
+	"tinydates/spelling"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpellingsAreFoldedTogether(t *testing.T) {
+	for name, want := range map[string]string{
+		"board-games":    "boardgames",
+		" Board Games ":  "boardgames",
+		"BOARD_GAMES":    "boardgames",
+		"They / Them":    "they/them",
+		"Non-Binary":     "nonbinary",
+		"café au lait":   "caféaulait",
+		"\tlive-music\n": "livemusic",
+	} {
+		require.Equal(t, want, spelling.Normalise(name), name)
+	}
+}
+
+func TestIndex(t *testing.T) {
+	idx := spelling.New()
+	require.NoError(t, idx.Add("nonbinary", "nonbinary"))
+	require.NoError(t, idx.Add("Enby", "nonbinary"))
+	// the same spelling of the same value again is no conflict
+	require.NoError(t, idx.Add("non-binary", "nonbinary"))
+
+	value, ok := idx.Lookup(" ENBY ")
+	require.True(t, ok)
+	require.Equal(t, "nonbinary", value)
+	_, ok = idx.Lookup("binary")
+	require.False(t, ok)
+
+	require.ErrorContains(t, idx.Add("Non Binary", "other"), `already a spelling of "nonbinary"`)
+	require.ErrorContains(t, idx.Add(" - ", "other"), "empty name")
+
+	// a refused spelling leaves the index as it was
+	value, ok = idx.Lookup("nonbinary")
+	require.True(t, ok)
+	require.Equal(t, "nonbinary", value)
+}
@@ -0,0 +1,42 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWrapErrClassifiesTheErrorBeforeTheContext(t *testing.T) {
+	expired, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// answers of the server are kept for what they are, however late
+	err := wrapErr(expired, pgx.ErrNoRows)
+	require.ErrorIs(t, err, ErrNotFound)
+	require.NotErrorIs(t, err, ErrTimeout)
+
+	unique := &pgconn.PgError{Code: "23505"}
+	err = wrapErr(expired, unique)
+	require.Equal(t, unique, err)
+
+	err = wrapSqliteErr(expired, errors.New("UNIQUE constraint failed"))
+	require.NotErrorIs(t, err, ErrTimeout)
+
+	// a cancelled statement is told apart by the context
+	statement := &pgconn.PgError{Code: queryCanceled}
+	require.ErrorIs(t, wrapErr(context.Background(), statement), ErrTimeout)
+	require.ErrorIs(t, wrapErr(cancelled, statement), ErrCanceled)
+
+	// as are the errors the server never answered
+	require.ErrorIs(t, wrapErr(expired, io.ErrUnexpectedEOF), ErrTimeout)
+	require.ErrorIs(t, wrapErr(cancelled, io.ErrUnexpectedEOF), ErrCanceled)
+	require.Equal(t, io.ErrUnexpectedEOF, wrapErr(context.Background(), io.ErrUnexpectedEOF))
+}
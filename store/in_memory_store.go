@@ -0,0 +1,866 @@
+package store
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+// memoryUser is a row of the users table held by the in memory store.
+type memoryUser struct {
+	id           int
+	email        string
+	password     string
+	name         string
+	gender       string
+	pronouns     string
+	birthDate    time.Time
+	location     int
+	desirability float64
+	lastActive   time.Time
+	bio          string
+
+	// prompts and interests are the rows of the prompt_answers and interests
+	// tables of the user, the prompts in order and the interests sorted
+	prompts   []PromptAnswer
+	interests []string
+}
+
+// memorySwipe is a row of the swipes table held by the in memory store.
+type memorySwipe struct {
+	id       int
+	swiper   int
+	swipee   int
+	decision bool
+}
+
+// tinydatesInMemoryStore provide access to the Store methods for an in memory
+// database. It mirrors the semantics of tinydatesPgStore, rows are kept in
+// insertion order and ids are allocated the way bigserial columns are, so it
+// can stand in for Postgres wherever Docker is unavailable.
+type tinydatesInMemoryStore struct {
+	mu          sync.RWMutex
+	users       []memoryUser
+	swipes      []memorySwipe
+	preferences map[int]Preferences
+
+	// photos are kept in insertion order, lastPhotoId is the id of the last
+	// one added as photos may be deleted
+	photos      []Photo
+	lastPhotoId int
+}
+
+func NewTinydatesInMemoryStore() Store {
+	return &tinydatesInMemoryStore{}
+}
+
+func NewTestTinydatesInMemoryStore() TestStore {
+	return &tinydatesInMemoryStore{}
+}
+
+func (store *tinydatesInMemoryStore) StoreNewUser(
+	ctx context.Context,
+	email, password, name, gender string,
+	birthDate time.Time,
+	location int,
+) (int, error) {
+	if err := contextErr(ctx); err != nil {
+		return 0, err
+	}
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	id := len(store.users) + 1
+	store.users = append(store.users, memoryUser{
+		id:           id,
+		email:        email,
+		password:     password,
+		name:         name,
+		gender:       gender,
+		birthDate:    birthDate,
+		location:     location,
+		desirability: InitialDesirability,
+	})
+
+	return id, nil
+}
+
+func (store *tinydatesInMemoryStore) GetPassword(
+	ctx context.Context,
+	email string,
+) (int, string, error) {
+	if err := contextErr(ctx); err != nil {
+		return 0, "", err
+	}
+
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	for _, user := range store.users {
+		if user.email == email {
+			return user.id, user.password, nil
+		}
+	}
+
+	return 0, "", ErrNotFound
+}
+
+func (store *tinydatesInMemoryStore) Discover(
+	ctx context.Context,
+	id int,
+	filters Filters,
+) ([]PotentialMatch, error) {
+	if err := contextErr(ctx); err != nil {
+		return nil, err
+	}
+
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	return store.unswiped(id, filters), nil
+}
+
+func (store *tinydatesInMemoryStore) DiscoverByPopularity(
+	ctx context.Context,
+	id int,
+	filters Filters,
+) ([]PotentialMatch, error) {
+	if err := contextErr(ctx); err != nil {
+		return nil, err
+	}
+
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	likesMe := store.likes(id)
+	shared := store.shared(id)
+	potentials := make([]PotentialMatch, 0)
+	for _, user := range store.users {
+		if user.id == id || store.excludes(user, id) || !store.meets(user, id, filters) {
+			continue
+		}
+		potentials = append(potentials, user.potentialMatch(likesMe[user.id], shared[user.id]))
+	}
+
+	// users are held in id order so ties are broken by id, as in the
+	// Postgres query
+	sort.SliceStable(potentials, func(i, j int) bool {
+		return potentials[i].Desirability > potentials[j].Desirability
+	})
+
+	return potentials, nil
+}
+
+func (store *tinydatesInMemoryStore) DiscoverBySharedInterests(
+	ctx context.Context,
+	id int,
+	filters Filters,
+) ([]PotentialMatch, error) {
+	if err := contextErr(ctx); err != nil {
+		return nil, err
+	}
+
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	var location int
+	for _, user := range store.users {
+		if user.id == id {
+			location = user.location
+		}
+	}
+	distance := func(potential PotentialMatch) int {
+		return max(potential.Location-location, location-potential.Location)
+	}
+
+	// ties are broken by id as the users are held in id order, as in the
+	// Postgres query
+	potentials := store.unswiped(id, filters)
+	sort.SliceStable(potentials, func(i, j int) bool {
+		if potentials[i].SharedInterests != potentials[j].SharedInterests {
+			return potentials[i].SharedInterests > potentials[j].SharedInterests
+		}
+		return distance(potentials[i]) < distance(potentials[j])
+	})
+
+	return potentials, nil
+}
+
+func (store *tinydatesInMemoryStore) Swipe(
+	ctx context.Context,
+	swiperId int,
+	swipeeId int,
+	decision bool,
+) (int, error) {
+	if err := contextErr(ctx); err != nil {
+		return 0, err
+	}
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	id := len(store.swipes) + 1
+	store.swipes = append(store.swipes, memorySwipe{
+		id:       id,
+		swiper:   swiperId,
+		swipee:   swipeeId,
+		decision: decision,
+	})
+
+	now := time.Now()
+	for i := range store.users {
+		if store.users[i].id == swiperId {
+			store.users[i].lastActive = now
+		}
+	}
+
+	return id, nil
+}
+
+func (store *tinydatesInMemoryStore) IsMatch(
+	ctx context.Context,
+	swipeeId int,
+	swiperId int,
+) (bool, error) {
+	if err := contextErr(ctx); err != nil {
+		return false, err
+	}
+
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	// same as the isMatch statement; the swipee having swiped on the swiper
+	// at all, regardless of the decision
+	for _, swipe := range store.swipes {
+		if swipe.swiper == swipeeId && swipe.swipee == swiperId {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (store *tinydatesInMemoryStore) GetLocation(
+	ctx context.Context,
+	id int,
+) (int, error) {
+	if err := contextErr(ctx); err != nil {
+		return 0, err
+	}
+
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	for _, user := range store.users {
+		if user.id == id {
+			return user.location, nil
+		}
+	}
+
+	return 0, ErrNotFound
+}
+
+func (store *tinydatesInMemoryStore) GetProfile(
+	ctx context.Context,
+	id int,
+) (PotentialMatch, error) {
+	if err := contextErr(ctx); err != nil {
+		return PotentialMatch{}, err
+	}
+
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	for _, user := range store.users {
+		if user.id == id {
+			return user.potentialMatch(false, 0), nil
+		}
+	}
+
+	return PotentialMatch{}, ErrNotFound
+}
+
+func (store *tinydatesInMemoryStore) GetProfiles(
+	ctx context.Context,
+	viewer int,
+	ids []int,
+) ([]PotentialMatch, error) {
+	if err := contextErr(ctx); err != nil {
+		return nil, err
+	}
+
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	wanted := make(map[int]bool, len(ids))
+	for _, id := range ids {
+		wanted[id] = true
+	}
+
+	likesMe := store.likes(viewer)
+	shared := store.shared(viewer)
+	profiles := make([]PotentialMatch, 0, len(ids))
+	for _, user := range store.users {
+		if wanted[user.id] {
+			profiles = append(profiles, user.potentialMatch(likesMe[user.id], shared[user.id]))
+		}
+	}
+
+	return profiles, nil
+}
+
+func (store *tinydatesInMemoryStore) GetDesirability(
+	ctx context.Context,
+	id int,
+) (float64, error) {
+	if err := contextErr(ctx); err != nil {
+		return 0, err
+	}
+
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	for _, user := range store.users {
+		if user.id == id {
+			return user.desirability, nil
+		}
+	}
+
+	return 0, ErrNotFound
+}
+
+func (store *tinydatesInMemoryStore) AdjustDesirability(
+	ctx context.Context,
+	id int,
+	delta float64,
+) error {
+	if err := contextErr(ctx); err != nil {
+		return err
+	}
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	for i := range store.users {
+		if store.users[i].id == id {
+			store.users[i].desirability += delta
+			return nil
+		}
+	}
+
+	return ErrNotFound
+}
+
+func (store *tinydatesInMemoryStore) RateDesirability(
+	ctx context.Context,
+	swiper, swipee int,
+	rate func(swiper, swipee float64) float64,
+) error {
+	if err := contextErr(ctx); err != nil {
+		return err
+	}
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	rater, rated := -1, -1
+	for i, user := range store.users {
+		if user.id == swiper {
+			rater = i
+		}
+		if user.id == swipee {
+			rated = i
+		}
+	}
+	if rater == -1 || rated == -1 {
+		return ErrNotFound
+	}
+
+	store.users[rated].desirability += rate(
+		store.users[rater].desirability,
+		store.users[rated].desirability,
+	)
+	return nil
+}
+
+func (store *tinydatesInMemoryStore) GetPreferences(
+	ctx context.Context,
+	id int,
+) (Preferences, error) {
+	if err := contextErr(ctx); err != nil {
+		return Preferences{}, err
+	}
+
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	if !store.exists(id) {
+		return Preferences{}, ErrNotFound
+	}
+
+	return store.preferences[id].clone(), nil
+}
+
+func (store *tinydatesInMemoryStore) SetPreferences(
+	ctx context.Context,
+	id int,
+	preferences Preferences,
+) error {
+	if err := contextErr(ctx); err != nil {
+		return err
+	}
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if !store.exists(id) {
+		return ErrNotFound
+	}
+
+	if store.preferences == nil {
+		store.preferences = make(map[int]Preferences)
+	}
+	store.preferences[id] = preferences.clone()
+
+	return nil
+}
+
+func (store *tinydatesInMemoryStore) SetIdentity(
+	ctx context.Context,
+	id int,
+	gender, pronouns string,
+) error {
+	if err := contextErr(ctx); err != nil {
+		return err
+	}
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	for i := range store.users {
+		if store.users[i].id == id {
+			store.users[i].gender = gender
+			store.users[i].pronouns = pronouns
+			return nil
+		}
+	}
+
+	return ErrNotFound
+}
+
+func (store *tinydatesInMemoryStore) GetAbout(
+	ctx context.Context,
+	id int,
+) (About, error) {
+	if err := contextErr(ctx); err != nil {
+		return About{}, err
+	}
+
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	for _, user := range store.users {
+		if user.id == id {
+			return About{
+				Bio:       user.bio,
+				Prompts:   append([]PromptAnswer{}, user.prompts...),
+				Interests: append([]string{}, user.interests...),
+			}, nil
+		}
+	}
+
+	return About{}, ErrNotFound
+}
+
+func (store *tinydatesInMemoryStore) SetAbout(
+	ctx context.Context,
+	id int,
+	about About,
+) error {
+	if err := contextErr(ctx); err != nil {
+		return err
+	}
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	for i := range store.users {
+		if store.users[i].id == id {
+			store.users[i].bio = about.Bio
+			store.users[i].prompts = append([]PromptAnswer{}, about.Prompts...)
+			store.users[i].interests = append([]string{}, about.Interests...)
+			// sorted as the Postgres store reads them
+			slices.Sort(store.users[i].interests)
+			return nil
+		}
+	}
+
+	return ErrNotFound
+}
+
+func (store *tinydatesInMemoryStore) GetPhotos(
+	ctx context.Context,
+	ids []int,
+) (map[int][]Photo, error) {
+	if err := contextErr(ctx); err != nil {
+		return nil, err
+	}
+
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	photos := make(map[int][]Photo)
+	for _, photo := range store.photos {
+		if slices.Contains(ids, photo.UserId) {
+			photos[photo.UserId] = append(photos[photo.UserId], photo)
+		}
+	}
+	for _, userPhotos := range photos {
+		sort.SliceStable(userPhotos, func(i, j int) bool {
+			return userPhotos[i].Position < userPhotos[j].Position
+		})
+	}
+
+	return photos, nil
+}
+
+func (store *tinydatesInMemoryStore) AddPhoto(
+	ctx context.Context,
+	id int,
+	photo Photo,
+	limit int,
+) (Photo, error) {
+	if err := contextErr(ctx); err != nil {
+		return Photo{}, err
+	}
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if !store.exists(id) {
+		return Photo{}, ErrNotFound
+	}
+
+	photo.UserId = id
+	photo.Position = 0
+	count := 0
+	for _, other := range store.photos {
+		if other.UserId == id {
+			photo.Position = max(photo.Position, other.Position+1)
+			count++
+		}
+	}
+	if count >= limit {
+		return Photo{}, ErrLimitReached
+	}
+
+	store.lastPhotoId++
+	photo.Id = store.lastPhotoId
+	store.photos = append(store.photos, photo)
+
+	return photo, nil
+}
+
+func (store *tinydatesInMemoryStore) ReorderPhotos(
+	ctx context.Context,
+	id int,
+	photoIds []int,
+) error {
+	if err := contextErr(ctx); err != nil {
+		return err
+	}
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	positions := make(map[int]int, len(photoIds))
+	for i, photoId := range photoIds {
+		positions[photoId] = i
+	}
+
+	// every id must be a photo of the user, listed once
+	var found int
+	for _, photo := range store.photos {
+		if _, ok := positions[photo.Id]; ok && photo.UserId == id {
+			found++
+		}
+	}
+	if found != len(photoIds) {
+		return ErrNotFound
+	}
+
+	for i, photo := range store.photos {
+		if position, ok := positions[photo.Id]; ok {
+			store.photos[i].Position = position
+		}
+	}
+
+	return nil
+}
+
+func (store *tinydatesInMemoryStore) DeletePhoto(
+	ctx context.Context,
+	id, photoId int,
+) (Photo, error) {
+	if err := contextErr(ctx); err != nil {
+		return Photo{}, err
+	}
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	for i, photo := range store.photos {
+		if photo.Id == photoId && photo.UserId == id {
+			store.photos = slices.Delete(store.photos, i, i+1)
+			return photo, nil
+		}
+	}
+
+	return Photo{}, ErrNotFound
+}
+
+// ReplaySwipes holds the read lock while fn runs, fn must not call back into
+// the store.
+func (store *tinydatesInMemoryStore) ReplaySwipes(
+	ctx context.Context,
+	fn func(NewSwipe) error,
+) error {
+	if err := contextErr(ctx); err != nil {
+		return err
+	}
+
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	for _, swipe := range store.swipes {
+		if err := fn(NewSwipe{
+			Swiper:   swipe.swiper,
+			Swipee:   swipe.swipee,
+			Decision: swipe.decision,
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (store *tinydatesInMemoryStore) ResetDesirability(
+	ctx context.Context,
+	scores map[int]float64,
+) error {
+	if err := contextErr(ctx); err != nil {
+		return err
+	}
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	for i := range store.users {
+		score, ok := scores[store.users[i].id]
+		if !ok {
+			score = InitialDesirability
+		}
+		store.users[i].desirability = score
+	}
+
+	return nil
+}
+
+// Up is a no-op beyond resetting the store, there is no schema to create.
+func (store *tinydatesInMemoryStore) Up(ctx context.Context) error {
+	return store.reset()
+}
+
+// Down drops every row, and restarts the id sequences, like dropping tables.
+func (store *tinydatesInMemoryStore) Down(ctx context.Context) error {
+	return store.reset()
+}
+
+func (store *tinydatesInMemoryStore) reset() error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	store.users = nil
+	store.swipes = nil
+	store.preferences = nil
+	store.photos = nil
+	store.lastPhotoId = 0
+
+	return nil
+}
+
+// exists returns whether there is a user with the supplied id.
+func (store *tinydatesInMemoryStore) exists(id int) bool {
+	for _, user := range store.users {
+		if user.id == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+// excludes reports whether the preferences of user exclude the user with the
+// supplied id, as the reciprocal condition of the Postgres queries.
+func (store *tinydatesInMemoryStore) excludes(user memoryUser, id int) bool {
+	preferences, ok := store.preferences[user.id]
+	if !ok || preferences.ShowOutsidePreferences {
+		return false
+	}
+
+	for _, me := range store.users {
+		if me.id != id {
+			continue
+		}
+
+		distance := me.location - user.location
+		if distance < 0 {
+			distance = -distance
+		}
+		age := Age(me.birthDate, time.Now().UTC())
+
+		switch {
+		case len(preferences.Genders) > 0 && !slices.Contains(preferences.Genders, me.gender):
+			return true
+		case preferences.MinAge != nil && age < *preferences.MinAge:
+			return true
+		case preferences.MaxAge != nil && age > *preferences.MaxAge:
+			return true
+		case preferences.MaxDistance != nil && distance > *preferences.MaxDistance:
+			return true
+		}
+	}
+
+	return false
+}
+
+// meets reports whether user meets the filters of the user with the supplied
+// id, as the filtered condition of the Postgres queries.
+func (store *tinydatesInMemoryStore) meets(user memoryUser, id int, filters Filters) bool {
+	var location int
+	for _, me := range store.users {
+		if me.id == id {
+			location = me.location
+		}
+	}
+	distance := max(user.location-location, location-user.location)
+	age := Age(user.birthDate, time.Now().UTC())
+
+	switch {
+	case len(filters.Genders) > 0 && !slices.Contains(filters.Genders, user.gender):
+		return false
+	case filters.MinAge != nil && age < *filters.MinAge:
+		return false
+	case filters.MaxAge != nil && age > *filters.MaxAge:
+		return false
+	case filters.MaxDistance != nil && distance > *filters.MaxDistance:
+		return false
+	default:
+		return true
+	}
+}
+
+// unswiped returns the profiles the user with the supplied id has not swiped
+// yet and meeting filters, as the discover statement of the Postgres store.
+func (store *tinydatesInMemoryStore) unswiped(id int, filters Filters) []PotentialMatch {
+	swiped := make(map[int]bool)
+	for _, swipe := range store.swipes {
+		if swipe.swiper == id {
+			swiped[swipe.swipee] = true
+		}
+	}
+
+	likesMe := store.likes(id)
+	shared := store.shared(id)
+	potentials := make([]PotentialMatch, 0)
+	for _, user := range store.users {
+		if user.id == id || swiped[user.id] || store.excludes(user, id) ||
+			!store.meets(user, id, filters) {
+			continue
+		}
+		potentials = append(potentials, user.potentialMatch(likesMe[user.id], shared[user.id]))
+	}
+
+	return potentials
+}
+
+// shared returns how many interests the other users have in common with the
+// user with the supplied id, as the shared_interests expression of the
+// Postgres queries.
+func (store *tinydatesInMemoryStore) shared(id int) map[int]int {
+	var mine []string
+	for _, user := range store.users {
+		if user.id == id {
+			mine = user.interests
+		}
+	}
+
+	shared := make(map[int]int)
+	for _, user := range store.users {
+		if user.id == id {
+			continue
+		}
+		for _, interest := range user.interests {
+			if slices.Contains(mine, interest) {
+				shared[user.id]++
+			}
+		}
+	}
+
+	return shared
+}
+
+// likes returns the users who favourably swiped the user with the supplied
+// id, as the likes_me expression of the Postgres queries.
+func (store *tinydatesInMemoryStore) likes(id int) map[int]bool {
+	likes := make(map[int]bool)
+	for _, swipe := range store.swipes {
+		if swipe.swipee == id && swipe.decision {
+			likes[swipe.swiper] = true
+		}
+	}
+
+	return likes
+}
+
+func (user memoryUser) potentialMatch(likesMe bool, sharedInterests int) PotentialMatch {
+	return PotentialMatch{
+		Id:           user.id,
+		Name:         user.name,
+		Gender:       user.gender,
+		Pronouns:     user.pronouns,
+		Age:          Age(user.birthDate, time.Now().UTC()),
+		Location:     user.location,
+		Desirability: user.desirability,
+		LastActive:   user.lastActive,
+		LikesMe:      likesMe,
+
+		SharedInterests: sharedInterests,
+	}
+}
+
+// clone copies the preferences so that the store shares no memory with its
+// callers, lists are never nil as when read from a database.
+func (p Preferences) clone() Preferences {
+	clone := func(value *int) *int {
+		if value == nil {
+			return nil
+		}
+		v := *value
+		return &v
+	}
+
+	return Preferences{
+		Genders:      append([]string{}, p.Genders...),
+		MinAge:       clone(p.MinAge),
+		MaxAge:       clone(p.MaxAge),
+		MaxDistance:  clone(p.MaxDistance),
+		Dealbreakers: append([]string{}, p.Dealbreakers...),
+
+		ShowOutsidePreferences: p.ShowOutsidePreferences,
+	}
+}
@@ -2,7 +2,12 @@ package store
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"time"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -16,9 +21,47 @@ func NewTinydatesPgStore(db *pgxpool.Pool) Store {
 	return &tinydatesPgStore{Db: db}
 }
 
+// queryCanceled is the Postgres error code raised when a statement is
+// cancelled, by statement_timeout or by pgx when the context is done.
+const queryCanceled = "57014"
+
+// wrapErr converts missing rows, context and cancellation errors raised
+// while querying into ErrNotFound, ErrTimeout or ErrCanceled, keeping the
+// original error in the chain. Other errors are returned unchanged.
+//
+// The error itself is classified first, so that an answer of the server, such
+// as a missing row or a constraint violation, is never mistaken for a timeout
+// because the context happened to expire since. Only errors the server did not
+// answer, such as a connection closed under the query, fall back to ctx.
+func wrapErr(ctx context.Context, err error) error {
+	var pgErr *pgconn.PgError
+
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return fmt.Errorf("%w: %w", ErrNotFound, err)
+	case errors.Is(err, context.DeadlineExceeded):
+		return fmt.Errorf("%w: %w", ErrTimeout, err)
+	case errors.Is(err, context.Canceled):
+		return fmt.Errorf("%w: %w", ErrCanceled, err)
+	case errors.As(err, &pgErr) && pgErr.Code == queryCanceled:
+		// cancelled by pgx for a cancelled context, by statement_timeout
+		// otherwise
+		if errors.Is(ctx.Err(), context.Canceled) {
+			return fmt.Errorf("%w: %w", ErrCanceled, err)
+		}
+		return fmt.Errorf("%w: %w", ErrTimeout, err)
+	case errors.As(err, &pgErr):
+		return err
+	case ctx.Err() != nil:
+		return fmt.Errorf("%w: %w", contextErr(ctx), err)
+	default:
+		return err
+	}
+}
+
 const (
 	storeUser = `
-        INSERT INTO users (email, password, name, gender, age, location)
+        INSERT INTO users (email, password, name, gender, birth_date, location)
 		VALUES ($1, $2, $3, $4, $5, $6)
 		RETURNING id
 	`
@@ -27,7 +70,8 @@ const (
 func (store *tinydatesPgStore) StoreNewUser(
 	ctx context.Context,
 	email, password, name, gender string,
-	age, location int,
+	birthDate time.Time,
+	location int,
 ) (int, error) {
 	var id int
 
@@ -38,12 +82,12 @@ func (store *tinydatesPgStore) StoreNewUser(
 		password,
 		name,
 		gender,
-		age,
+		birthDate,
 		location,
 	).Scan(
 		&id,
 	); err != nil {
-		return 0, err
+		return 0, wrapErr(ctx, err)
 	}
 
 	return id, nil
@@ -51,7 +95,7 @@ func (store *tinydatesPgStore) StoreNewUser(
 
 const (
 	getPassword = `
-        SELECT password
+        SELECT id, password
 		FROM users
 		WHERE email = $1
 	`
@@ -60,119 +104,268 @@ const (
 func (store *tinydatesPgStore) GetPassword(
 	ctx context.Context,
 	email string,
-) (string, error) {
-	var password string
+) (int, string, error) {
+	var (
+		id       int
+		password string
+	)
 
 	if err := store.Db.QueryRow(
 		ctx,
 		getPassword,
 		email,
 	).Scan(
+		&id,
 		&password,
 	); err != nil {
-		return "", err
+		return 0, "", wrapErr(ctx, err)
+	}
+
+	return id, password, nil
+}
+
+// userAge is the age of a user in whole years, computed from their birth date
+// when read so that it stays correct over time. Today is the day in UTC, as
+// for SQLite and Age, whatever the time zone of the session.
+const userAge = `extract(year FROM age((now() AT TIME ZONE 'UTC')::date, birth_date))::integer`
+
+// potentialMatchColumns are the columns read by scanPotentialMatch, likes_me
+// is whether the user has favourably swiped the user $1 and shared_interests
+// how many interests they have in common with them.
+const potentialMatchColumns = `
+		id, name, gender, pronouns, ` + userAge + ` AS age, location, desirability,
+		last_active,
+		EXISTS(
+		    SELECT 1 FROM swipes
+			WHERE swiper = users.id
+			AND swipee = $1
+			AND decision = true
+		) AS likes_me,
+		(
+		    SELECT count(*)
+			FROM interests AS theirs
+			JOIN interests AS mine ON mine.interest = theirs.interest
+			WHERE theirs.user_id = users.id
+			AND mine.user_id = $1
+			AND users.id != $1
+		) AS shared_interests
+`
+
+// scanPotentialMatch reads a row selecting potentialMatchColumns.
+func scanPotentialMatch(row pgx.Row) (PotentialMatch, error) {
+	var (
+		user       PotentialMatch
+		lastActive *time.Time
+	)
+
+	if err := row.Scan(
+		&user.Id,
+		&user.Name,
+		&user.Gender,
+		&user.Pronouns,
+		&user.Age,
+		&user.Location,
+		&user.Desirability,
+		&lastActive,
+		&user.LikesMe,
+		&user.SharedInterests,
+	); err != nil {
+		return PotentialMatch{}, err
+	}
+	if lastActive != nil {
+		user.LastActive = *lastActive
 	}
 
-	return password, nil
+	return user, nil
 }
 
+// reciprocal leaves out the users whose preferences exclude the user $1,
+// unless they asked to be shown outside them. Every stated preference counts,
+// dealbreaker or not; an unknown user $1 is excluded by no one.
+const reciprocal = `
+		AND NOT EXISTS (
+		    SELECT 1
+			FROM preferences AS theirs
+			JOIN (
+			    SELECT id, gender, location, ` + userAge + ` AS age FROM users
+			) AS me ON me.id = $1
+			WHERE theirs.user_id = users.id
+			AND NOT theirs.show_outside_preferences
+			AND NOT (
+			    (cardinality(theirs.genders) = 0 OR me.gender = ANY(theirs.genders))
+				AND (theirs.min_age IS NULL OR me.age >= theirs.min_age)
+				AND (theirs.max_age IS NULL OR me.age <= theirs.max_age)
+				AND (
+				    theirs.max_distance IS NULL
+					OR abs(me.location - users.location) <= theirs.max_distance
+				)
+			)
+		)
+`
+
+// filtered keeps the users meeting the filters of the user $1: the genders
+// $2, the ages from $3 to $4 and the distance $5 from them, each one unset
+// when null or empty.
+const filtered = `
+		AND (cardinality($2::varchar[]) = 0 OR gender = ANY($2::varchar[]))
+		AND ($3::integer IS NULL OR ` + userAge + ` >= $3)
+		AND ($4::integer IS NULL OR ` + userAge + ` <= $4)
+		AND (
+		    $5::integer IS NULL
+			OR abs(users.location - (SELECT me.location FROM users AS me WHERE me.id = $1)) <= $5
+		)
+`
+
 const (
 	discover = `
-        SELECT id, name, gender, age, location
+        SELECT` + potentialMatchColumns + `
 		FROM users
 		WHERE id != $1
 		AND id NOT IN (
 		    SELECT swipee
 			FROM swipes
 			WHERE swiper = $1
-		)
-	`
+		)` + reciprocal + filtered
 )
 
 func (store *tinydatesPgStore) Discover(
 	ctx context.Context,
 	id int,
+	filters Filters,
 ) ([]PotentialMatch, error) {
 	potentials := make([]PotentialMatch, 0)
 
-	rows, err := store.Db.Query(ctx, discover, id)
+	rows, err := store.Db.Query(
+		ctx,
+		discover,
+		id,
+		nonNil(filters.Genders),
+		filters.MinAge,
+		filters.MaxAge,
+		filters.MaxDistance,
+	)
 	if err != nil {
-		return nil, err
+		return nil, wrapErr(ctx, err)
 	}
 	defer rows.Close()
-	
-	for rows.Next() {
-		var user PotentialMatch
 
-		if err := rows.Scan(
-			&user.Id,
-			&user.Name,
-			&user.Gender,
-			&user.Age,
-			&user.Location,
-		); err != nil {
-			return nil, err
+	for rows.Next() {
+		user, err := scanPotentialMatch(rows)
+		if err != nil {
+			return nil, wrapErr(ctx, err)
 		}
 		potentials = append(potentials, user)
 	}
 
+	// iteration stops early when the context is cancelled part way through,
+	// without this check a partial result would be returned as complete
+	if err := rows.Err(); err != nil {
+		return nil, wrapErr(ctx, err)
+	}
+
 	return potentials, nil
 }
 
 const (
 	discoverByPopularity = `
-        WITH
-		popularity_counts AS (
-		    SELECT swipee, decision, COUNT(*) AS popularity
-			FROM swipes
-			GROUP BY swipee, decision
-			HAVING swipee != $1 AND decision = true
-		),
-		discovery AS (
-			SELECT id, name, gender, age, location
-			FROM users
-			WHERE id != $1
-		)
-		SELECT id, name, gender, age, location, COALESCE(popularity, 0) AS popularity
-		FROM discovery LEFT JOIN popularity_counts ON id = swipee
-		ORDER BY popularity DESC
+        SELECT` + potentialMatchColumns + `
+		FROM users
+		WHERE id != $1` + reciprocal + filtered + `
+		ORDER BY desirability DESC, id
 	`
 )
 
 func (store *tinydatesPgStore) DiscoverByPopularity(
 	ctx context.Context,
 	id int,
+	filters Filters,
 ) ([]PotentialMatch, error) {
 	potentials := make([]PotentialMatch, 0)
 
-	rows, err := store.Db.Query(ctx, discoverByPopularity, id)
+	rows, err := store.Db.Query(
+		ctx,
+		discoverByPopularity,
+		id,
+		nonNil(filters.Genders),
+		filters.MinAge,
+		filters.MaxAge,
+		filters.MaxDistance,
+	)
 	if err != nil {
-		return nil, err
+		return nil, wrapErr(ctx, err)
 	}
 	defer rows.Close()
-	
+
 	for rows.Next() {
-		var user PotentialMatch
+		user, err := scanPotentialMatch(rows)
+		if err != nil {
+			return nil, wrapErr(ctx, err)
+		}
+		potentials = append(potentials, user)
+	}
 
-		if err := rows.Scan(
-			&user.Id,
-			&user.Name,
-			&user.Gender,
-			&user.Age,
-			&user.Location,
-			&user.Popularity,
-		); err != nil {
-			return nil, err
+	// iteration stops early when the context is cancelled part way through,
+	// without this check a partial result would be returned as complete
+	if err := rows.Err(); err != nil {
+		return nil, wrapErr(ctx, err)
+	}
+
+	return potentials, nil
+}
+
+const (
+	discoverBySharedInterests = discover + `
+		ORDER BY
+		    shared_interests DESC,
+			abs(location - (SELECT location FROM users WHERE id = $1)),
+			id
+	`
+)
+
+func (store *tinydatesPgStore) DiscoverBySharedInterests(
+	ctx context.Context,
+	id int,
+	filters Filters,
+) ([]PotentialMatch, error) {
+	potentials := make([]PotentialMatch, 0)
+
+	rows, err := store.Db.Query(
+		ctx,
+		discoverBySharedInterests,
+		id,
+		nonNil(filters.Genders),
+		filters.MinAge,
+		filters.MaxAge,
+		filters.MaxDistance,
+	)
+	if err != nil {
+		return nil, wrapErr(ctx, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		user, err := scanPotentialMatch(rows)
+		if err != nil {
+			return nil, wrapErr(ctx, err)
 		}
 		potentials = append(potentials, user)
 	}
 
+	if err := rows.Err(); err != nil {
+		return nil, wrapErr(ctx, err)
+	}
+
 	return potentials, nil
 }
 
 const (
 	swipe = `
-        INSERT INTO swipes (swiper, swipee, decision)
+        WITH active AS (
+		    UPDATE users
+			SET last_active = now()
+			WHERE id = $1
+		)
+		INSERT INTO swipes (swiper, swipee, decision)
 		VALUES ($1, $2, $3)
 		RETURNING id;
 	`
@@ -195,7 +388,7 @@ func (store *tinydatesPgStore) Swipe(
 	).Scan(
 		&matchId,
 	); err != nil {
-		return 0, err
+		return 0, wrapErr(ctx, err)
 	}
 
 	return matchId, nil
@@ -226,7 +419,7 @@ func (store *tinydatesPgStore) IsMatch(
 	).Scan(
 		&match,
 	); err != nil {
-		return false, err
+		return false, wrapErr(ctx, err)
 	}
 
 	return match, nil
@@ -251,8 +444,822 @@ func (store *tinydatesPgStore) GetLocation(
 		location,
 		id,
 	).Scan(&userLocation); err != nil {
-		return 0, err
+		return 0, wrapErr(ctx, err)
 	}
 
 	return userLocation, nil
 }
+
+const (
+	getProfile = `
+        SELECT` + potentialMatchColumns + `
+		FROM users
+		WHERE id = $1
+	`
+)
+
+func (store *tinydatesPgStore) GetProfile(
+	ctx context.Context,
+	id int,
+) (PotentialMatch, error) {
+	user, err := scanPotentialMatch(store.Db.QueryRow(ctx, getProfile, id))
+	if err != nil {
+		return PotentialMatch{}, wrapErr(ctx, err)
+	}
+
+	return user, nil
+}
+
+const (
+	getProfiles = `
+        SELECT` + potentialMatchColumns + `
+		FROM users
+		WHERE id = ANY($2)
+	`
+)
+
+func (store *tinydatesPgStore) GetProfiles(
+	ctx context.Context,
+	viewer int,
+	ids []int,
+) ([]PotentialMatch, error) {
+	profiles := make([]PotentialMatch, 0, len(ids))
+
+	rows, err := store.Db.Query(ctx, getProfiles, viewer, ids)
+	if err != nil {
+		return nil, wrapErr(ctx, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		user, err := scanPotentialMatch(rows)
+		if err != nil {
+			return nil, wrapErr(ctx, err)
+		}
+		profiles = append(profiles, user)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, wrapErr(ctx, err)
+	}
+
+	return profiles, nil
+}
+
+const (
+	getDesirability = `
+        SELECT desirability
+		FROM users
+		WHERE id = $1
+	`
+)
+
+func (store *tinydatesPgStore) GetDesirability(
+	ctx context.Context,
+	id int,
+) (float64, error) {
+	var desirability float64
+
+	if err := store.Db.QueryRow(
+		ctx,
+		getDesirability,
+		id,
+	).Scan(&desirability); err != nil {
+		return 0, wrapErr(ctx, err)
+	}
+
+	return desirability, nil
+}
+
+const (
+	adjustDesirability = `
+        UPDATE users
+		SET desirability = desirability + $2
+		WHERE id = $1
+	`
+)
+
+func (store *tinydatesPgStore) AdjustDesirability(
+	ctx context.Context,
+	id int,
+	delta float64,
+) error {
+	tag, err := store.Db.Exec(ctx, adjustDesirability, id, delta)
+	if err != nil {
+		return wrapErr(ctx, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+const (
+	lockDesirability = `
+        SELECT desirability
+		FROM users
+		WHERE id = $1
+		FOR UPDATE
+	`
+)
+
+// RateDesirability locks the row of the swipee for the length of the
+// transaction, so that concurrent ratings of them queue on it.
+func (store *tinydatesPgStore) RateDesirability(
+	ctx context.Context,
+	swiper, swipee int,
+	rate func(swiper, swipee float64) float64,
+) error {
+	tx, err := store.Db.Begin(ctx)
+	if err != nil {
+		return wrapErr(ctx, err)
+	}
+	defer tx.Rollback(ctx)
+
+	var swipeeScore, swiperScore float64
+	if err := tx.QueryRow(ctx, lockDesirability, swipee).Scan(&swipeeScore); err != nil {
+		return wrapErr(ctx, err)
+	}
+	if err := tx.QueryRow(ctx, getDesirability, swiper).Scan(&swiperScore); err != nil {
+		return wrapErr(ctx, err)
+	}
+
+	if _, err := tx.Exec(
+		ctx,
+		adjustDesirability,
+		swipee,
+		rate(swiperScore, swipeeScore),
+	); err != nil {
+		return wrapErr(ctx, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return wrapErr(ctx, err)
+	}
+
+	return nil
+}
+
+const (
+	getPreferences = `
+        SELECT
+		    coalesce(preferences.genders, '{}'),
+			preferences.min_age,
+			preferences.max_age,
+			preferences.max_distance,
+			coalesce(preferences.dealbreakers, '{}'),
+			coalesce(preferences.show_outside_preferences, false)
+		FROM users
+		LEFT JOIN preferences ON preferences.user_id = users.id
+		WHERE users.id = $1
+	`
+)
+
+func (store *tinydatesPgStore) GetPreferences(
+	ctx context.Context,
+	id int,
+) (Preferences, error) {
+	var preferences Preferences
+
+	if err := store.Db.QueryRow(
+		ctx,
+		getPreferences,
+		id,
+	).Scan(
+		&preferences.Genders,
+		&preferences.MinAge,
+		&preferences.MaxAge,
+		&preferences.MaxDistance,
+		&preferences.Dealbreakers,
+		&preferences.ShowOutsidePreferences,
+	); err != nil {
+		return Preferences{}, wrapErr(ctx, err)
+	}
+
+	return preferences, nil
+}
+
+const (
+	// setPreferences only inserts a row for a user that exists, so that no
+	// row being written tells an unknown user apart; the parameters are cast
+	// as their types cannot be inferred from a select list
+	setPreferences = `
+        INSERT INTO preferences (
+		    user_id, genders, min_age, max_age, max_distance, dealbreakers,
+			show_outside_preferences
+		)
+		SELECT
+		    id, $2::varchar[], $3::integer, $4::integer, $5::integer,
+			$6::varchar[], $7::boolean
+		FROM users
+		WHERE id = $1
+		ON CONFLICT (user_id) DO UPDATE
+		SET genders = excluded.genders,
+			min_age = excluded.min_age,
+			max_age = excluded.max_age,
+			max_distance = excluded.max_distance,
+			dealbreakers = excluded.dealbreakers,
+			show_outside_preferences = excluded.show_outside_preferences
+	`
+)
+
+func (store *tinydatesPgStore) SetPreferences(
+	ctx context.Context,
+	id int,
+	preferences Preferences,
+) error {
+	tag, err := store.Db.Exec(
+		ctx,
+		setPreferences,
+		id,
+		nonNil(preferences.Genders),
+		preferences.MinAge,
+		preferences.MaxAge,
+		preferences.MaxDistance,
+		nonNil(preferences.Dealbreakers),
+		preferences.ShowOutsidePreferences,
+	)
+	if err != nil {
+		return wrapErr(ctx, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+const (
+	setIdentity = `
+        UPDATE users
+		SET gender = $2, pronouns = $3
+		WHERE id = $1
+	`
+)
+
+func (store *tinydatesPgStore) SetIdentity(
+	ctx context.Context,
+	id int,
+	gender, pronouns string,
+) error {
+	tag, err := store.Db.Exec(ctx, setIdentity, id, gender, pronouns)
+	if err != nil {
+		return wrapErr(ctx, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+const (
+	// getAbout lists the prompts and answers apart, in the same order
+	getAbout = `
+        SELECT
+		    bio,
+			coalesce(
+			    (
+				    SELECT array_agg(prompt ORDER BY position)
+					FROM prompt_answers
+					WHERE user_id = users.id
+				),
+				'{}'
+			),
+			coalesce(
+			    (
+				    SELECT array_agg(answer ORDER BY position)
+					FROM prompt_answers
+					WHERE user_id = users.id
+				),
+				'{}'
+			),
+			coalesce(
+			    (
+				    SELECT array_agg(interest ORDER BY interest)
+					FROM interests
+					WHERE user_id = users.id
+				),
+				'{}'
+			)
+		FROM users
+		WHERE id = $1
+	`
+)
+
+func (store *tinydatesPgStore) GetAbout(ctx context.Context, id int) (About, error) {
+	var (
+		about   About
+		prompts []string
+		answers []string
+	)
+
+	if err := store.Db.QueryRow(
+		ctx,
+		getAbout,
+		id,
+	).Scan(
+		&about.Bio,
+		&prompts,
+		&answers,
+		&about.Interests,
+	); err != nil {
+		return About{}, wrapErr(ctx, err)
+	}
+
+	about.Prompts = make([]PromptAnswer, 0, len(prompts))
+	for i, prompt := range prompts {
+		about.Prompts = append(about.Prompts, PromptAnswer{
+			Prompt: prompt,
+			Answer: answers[i],
+		})
+	}
+
+	return about, nil
+}
+
+const (
+	setBio = `
+        UPDATE users
+		SET bio = $2
+		WHERE id = $1
+	`
+
+	deletePromptAnswers = `
+        DELETE FROM prompt_answers
+		WHERE user_id = $1
+	`
+
+	// addPromptAnswers positions the answers in the order they are listed
+	addPromptAnswers = `
+        INSERT INTO prompt_answers (user_id, prompt, position, answer)
+		SELECT $1, answered.prompt, answered.position - 1, answered.answer
+		FROM unnest($2::varchar[], $3::varchar[]) WITH ORDINALITY
+		    AS answered (prompt, answer, position)
+	`
+
+	deleteInterests = `
+        DELETE FROM interests
+		WHERE user_id = $1
+	`
+
+	addInterests = `
+        INSERT INTO interests (user_id, interest)
+		SELECT $1, unnest($2::varchar[])
+	`
+)
+
+// SetAbout replaces the bio, prompt answers and interests in one transaction
+// so that they are never read half replaced.
+func (store *tinydatesPgStore) SetAbout(
+	ctx context.Context,
+	id int,
+	about About,
+) error {
+	tx, err := store.Db.Begin(ctx)
+	if err != nil {
+		return wrapErr(ctx, err)
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, setBio, id, about.Bio)
+	if err != nil {
+		return wrapErr(ctx, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	prompts := make([]string, 0, len(about.Prompts))
+	answers := make([]string, 0, len(about.Prompts))
+	for _, answered := range about.Prompts {
+		prompts = append(prompts, answered.Prompt)
+		answers = append(answers, answered.Answer)
+	}
+
+	for _, statement := range []struct {
+		sql  string
+		args []any
+	}{
+		{deletePromptAnswers, []any{id}},
+		{addPromptAnswers, []any{id, prompts, answers}},
+		{deleteInterests, []any{id}},
+		{addInterests, []any{id, nonNil(about.Interests)}},
+	} {
+		if _, err := tx.Exec(ctx, statement.sql, statement.args...); err != nil {
+			return wrapErr(ctx, err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return wrapErr(ctx, err)
+	}
+
+	return nil
+}
+
+// photoColumns are the columns read by scanPhoto.
+const photoColumns = `
+		id, user_id, position, key, thumbnail_key, width, height
+`
+
+// scanPhoto reads a row selecting photoColumns.
+func scanPhoto(row pgx.Row) (Photo, error) {
+	var photo Photo
+
+	err := row.Scan(
+		&photo.Id,
+		&photo.UserId,
+		&photo.Position,
+		&photo.Key,
+		&photo.ThumbnailKey,
+		&photo.Width,
+		&photo.Height,
+	)
+
+	return photo, err
+}
+
+const (
+	getPhotos = `
+        SELECT` + photoColumns + `
+		FROM photos
+		WHERE user_id = ANY($1)
+		ORDER BY user_id, position, id
+	`
+)
+
+func (store *tinydatesPgStore) GetPhotos(
+	ctx context.Context,
+	ids []int,
+) (map[int][]Photo, error) {
+	photos := make(map[int][]Photo)
+
+	rows, err := store.Db.Query(ctx, getPhotos, ids)
+	if err != nil {
+		return nil, wrapErr(ctx, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		photo, err := scanPhoto(rows)
+		if err != nil {
+			return nil, wrapErr(ctx, err)
+		}
+		photos[photo.UserId] = append(photos[photo.UserId], photo)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, wrapErr(ctx, err)
+	}
+
+	return photos, nil
+}
+
+const (
+	// lockPhotos locks the row of the user, the photos of a user are only
+	// counted and added holding it
+	lockPhotos = `
+        SELECT id
+		FROM users
+		WHERE id = $1
+		FOR UPDATE
+	`
+
+	countPhotos = `
+        SELECT count(*)
+		FROM photos
+		WHERE user_id = $1
+	`
+
+	// addPhoto only inserts a row for a user that exists, placed after the
+	// other photos of the user
+	addPhoto = `
+        INSERT INTO photos (user_id, position, key, thumbnail_key, width, height)
+		SELECT
+		    id,
+			(SELECT coalesce(max(position) + 1, 0) FROM photos WHERE user_id = $1),
+			$2::varchar, $3::varchar, $4::integer, $5::integer
+		FROM users
+		WHERE id = $1
+		RETURNING id, position
+	`
+)
+
+// AddPhoto counts the photos of the user holding the lock of their row, so
+// that concurrent uploads queue on it rather than all passing the limit.
+func (store *tinydatesPgStore) AddPhoto(
+	ctx context.Context,
+	id int,
+	photo Photo,
+	limit int,
+) (Photo, error) {
+	photo.UserId = id
+
+	tx, err := store.Db.Begin(ctx)
+	if err != nil {
+		return Photo{}, wrapErr(ctx, err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := tx.QueryRow(ctx, lockPhotos, id).Scan(&id); err != nil {
+		return Photo{}, wrapErr(ctx, err)
+	}
+
+	// read after the lock was taken, so that the photos added by whoever
+	// held it are counted
+	var count int
+	if err := tx.QueryRow(ctx, countPhotos, id).Scan(&count); err != nil {
+		return Photo{}, wrapErr(ctx, err)
+	}
+	if count >= limit {
+		return Photo{}, ErrLimitReached
+	}
+
+	if err := tx.QueryRow(
+		ctx,
+		addPhoto,
+		id,
+		photo.Key,
+		photo.ThumbnailKey,
+		photo.Width,
+		photo.Height,
+	).Scan(&photo.Id, &photo.Position); err != nil {
+		return Photo{}, wrapErr(ctx, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return Photo{}, wrapErr(ctx, err)
+	}
+
+	return photo, nil
+}
+
+const (
+	// reorderPhotos updates nothing unless every id is a photo of the user,
+	// listed once
+	reorderPhotos = `
+        UPDATE photos
+		SET position = ordered.position - 1
+		FROM unnest($2::bigint[]) WITH ORDINALITY AS ordered (id, position)
+		WHERE photos.id = ordered.id
+		AND photos.user_id = $1
+		AND (
+		    SELECT count(*)
+			FROM photos
+			WHERE user_id = $1
+			AND id = ANY($2)
+		) = cardinality($2)
+	`
+)
+
+func (store *tinydatesPgStore) ReorderPhotos(
+	ctx context.Context,
+	id int,
+	photoIds []int,
+) error {
+	tag, err := store.Db.Exec(ctx, reorderPhotos, id, photoIds)
+	if err != nil {
+		return wrapErr(ctx, err)
+	}
+	if tag.RowsAffected() != int64(len(photoIds)) {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+const (
+	deletePhoto = `
+        DELETE FROM photos
+		WHERE id = $2
+		AND user_id = $1
+		RETURNING` + photoColumns
+)
+
+func (store *tinydatesPgStore) DeletePhoto(
+	ctx context.Context,
+	id, photoId int,
+) (Photo, error) {
+	photo, err := scanPhoto(store.Db.QueryRow(ctx, deletePhoto, id, photoId))
+	if err != nil {
+		return Photo{}, wrapErr(ctx, err)
+	}
+
+	return photo, nil
+}
+
+// nonNil returns an empty list in place of nil, which would be written as
+// NULL.
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+
+	return values
+}
+
+const (
+	lockUsers = `
+        LOCK TABLE users IN EXCLUSIVE MODE
+	`
+
+	lastUserId = `
+        SELECT coalesce(max(id), 0)
+		FROM users
+	`
+
+	resetUserIds = `
+        SELECT setval(pg_get_serial_sequence('users', 'id'), max(id))
+		FROM users
+	`
+)
+
+// LoadUsers copies the users in with ids following the highest one in use,
+// then moves the id sequence past them. The table is locked meanwhile so that
+// no other insert takes one of these ids.
+func (store *tinydatesPgStore) LoadUsers(
+	ctx context.Context,
+	next func() (NewUser, bool),
+) (int, error) {
+	tx, err := store.Db.Begin(ctx)
+	if err != nil {
+		return 0, wrapErr(ctx, err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err = tx.Exec(ctx, lockUsers); err != nil {
+		return 0, wrapErr(ctx, err)
+	}
+
+	var lastId int
+	if err = tx.QueryRow(ctx, lastUserId).Scan(&lastId); err != nil {
+		return 0, wrapErr(ctx, err)
+	}
+
+	id := lastId
+	if _, err = tx.CopyFrom(
+		ctx,
+		pgx.Identifier{"users"},
+		[]string{"id", "email", "password", "name", "gender", "birth_date", "location"},
+		pgx.CopyFromFunc(func() ([]any, error) {
+			user, ok := next()
+			if !ok {
+				return nil, nil
+			}
+
+			id++
+			return []any{
+				id,
+				user.Email,
+				user.Password,
+				user.Name,
+				user.Gender,
+				user.BirthDate,
+				user.Location,
+			}, nil
+		}),
+	); err != nil {
+		return 0, wrapErr(ctx, err)
+	}
+
+	if _, err = tx.Exec(ctx, resetUserIds); err != nil {
+		return 0, wrapErr(ctx, err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return 0, wrapErr(ctx, err)
+	}
+
+	return lastId + 1, nil
+}
+
+func (store *tinydatesPgStore) LoadSwipes(
+	ctx context.Context,
+	next func() (NewSwipe, bool),
+) error {
+	if _, err := store.Db.CopyFrom(
+		ctx,
+		pgx.Identifier{"swipes"},
+		[]string{"swiper", "swipee", "decision"},
+		pgx.CopyFromFunc(func() ([]any, error) {
+			swipe, ok := next()
+			if !ok {
+				return nil, nil
+			}
+
+			return []any{swipe.Swiper, swipe.Swipee, swipe.Decision}, nil
+		}),
+	); err != nil {
+		return wrapErr(ctx, err)
+	}
+
+	return nil
+}
+
+const (
+	replaySwipes = `
+        SELECT swiper, swipee, decision
+		FROM swipes
+		ORDER BY id
+	`
+)
+
+func (store *tinydatesPgStore) ReplaySwipes(
+	ctx context.Context,
+	fn func(NewSwipe) error,
+) error {
+	rows, err := store.Db.Query(ctx, replaySwipes)
+	if err != nil {
+		return wrapErr(ctx, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var swipe NewSwipe
+
+		if err := rows.Scan(
+			&swipe.Swiper,
+			&swipe.Swipee,
+			&swipe.Decision,
+		); err != nil {
+			return wrapErr(ctx, err)
+		}
+		if err := fn(swipe); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return wrapErr(ctx, err)
+	}
+
+	return nil
+}
+
+const (
+	resetDesirability = `
+        UPDATE users
+		SET desirability = $1
+	`
+
+	createDesirabilityBackfill = `
+        CREATE TEMPORARY TABLE desirability_backfill (
+		    id bigint PRIMARY KEY,
+			desirability double precision NOT NULL
+		) ON COMMIT DROP
+	`
+
+	applyDesirabilityBackfill = `
+        UPDATE users
+		SET desirability = desirability_backfill.desirability
+		FROM desirability_backfill
+		WHERE users.id = desirability_backfill.id
+	`
+)
+
+// ResetDesirability copies the scores into a temporary table and updates the
+// users from it, in a single transaction so that readers never see a partial
+// backfill.
+func (store *tinydatesPgStore) ResetDesirability(
+	ctx context.Context,
+	scores map[int]float64,
+) error {
+	tx, err := store.Db.Begin(ctx)
+	if err != nil {
+		return wrapErr(ctx, err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err = tx.Exec(ctx, resetDesirability, InitialDesirability); err != nil {
+		return wrapErr(ctx, err)
+	}
+
+	if _, err = tx.Exec(ctx, createDesirabilityBackfill); err != nil {
+		return wrapErr(ctx, err)
+	}
+
+	rows := make([][]any, 0, len(scores))
+	for id, score := range scores {
+		rows = append(rows, []any{id, score})
+	}
+	if _, err = tx.CopyFrom(
+		ctx,
+		pgx.Identifier{"desirability_backfill"},
+		[]string{"id", "desirability"},
+		pgx.CopyFromRows(rows),
+	); err != nil {
+		return wrapErr(ctx, err)
+	}
+
+	if _, err = tx.Exec(ctx, applyDesirabilityBackfill); err != nil {
+		return wrapErr(ctx, err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return wrapErr(ctx, err)
+	}
+
+	return nil
+}
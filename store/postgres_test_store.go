@@ -2,7 +2,10 @@ package store
 
 import (
 	"context"
+	"errors"
+	"tinydates/migration"
 
+	"github.com/golang-migrate/migrate/v4"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -10,77 +13,30 @@ func NewTestTinydatesPgStore(db *pgxpool.Pool) TestStore {
 	return &tinydatesPgStore{Db: db}
 }
 
-const (
-	Up = `
-        -- migration 000001
-		CREATE TABLE IF NOT EXISTS "users" (
-			"id" bigserial PRIMARY KEY,
-			"email" varchar NOT NULL,
-			"password" varchar NOT NULL,
-			"name" varchar NOT NULL,
-			"gender" varchar NOT NULL,
-			"age" integer NOT NULL,
-			UNIQUE(id, email)
-		);
-
-        -- migration 000002
-		CREATE TABLE IF NOT EXISTS "swipes" (
-			"id" bigserial PRIMARY KEY,
-			"swiper" integer NOT NULL,
-			"swipee" integer NOT NULL,
-			"decision" boolean NOT NULL
-		);
-
-        -- migration 000003
-		ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "location" integer NOT NULL DEFAULT 0;
-	`
-)
-
+// Up applies every embedded Postgres migration.
 func (store *tinydatesPgStore) Up(ctx context.Context) error {
-	tx, err := store.Db.Begin(ctx)
+	m, err := migration.Postgres(store.Db)
 	if err != nil {
 		return err
 	}
+	defer m.Close()
 
-	defer tx.Rollback(ctx)
-
-	if _, err = store.Db.Exec(ctx, Up); err != nil {
-		return err
-	}
-
-	if err = tx.Commit(ctx); err != nil {
+	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
 		return err
 	}
 
 	return nil
 }
 
-const (
-	Down = `
-        -- migration 000003
-		ALTER TABLE IF EXISTS "users" DROP COLUMN "location";
-
-        -- migration 000002
-		DROP TABLE IF EXISTS "swipes";
-
-        -- migration 000001
-		DROP TABLE IF EXISTS "users";
-	`
-)
-
+// Down reverts every embedded Postgres migration.
 func (store *tinydatesPgStore) Down(ctx context.Context) error {
-	tx, err := store.Db.Begin(ctx)
+	m, err := migration.Postgres(store.Db)
 	if err != nil {
 		return err
 	}
+	defer m.Close()
 
-	defer tx.Rollback(ctx)
-
-	if _, err = store.Db.Exec(ctx, Down); err != nil {
-		return err
-	}
-
-	if err = tx.Commit(ctx); err != nil {
+	if err := m.Down(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
 		return err
 	}
 
@@ -0,0 +1,1263 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// tinydatesSqliteStore provide access to the Store methods for a SQLite
+// backed database, for demos, local development and edge deployments where
+// running Postgres is not worth it. The pure Go driver means no cgo.
+type tinydatesSqliteStore struct {
+	Db *sql.DB
+}
+
+func NewTinydatesSqliteStore(db *sql.DB) Store {
+	return &tinydatesSqliteStore{Db: db}
+}
+
+// OpenSqlite opens the SQLite database at path, created when missing. Writers
+// wait on each other rather than failing while the database is locked.
+func OpenSqlite(path string) (*sql.DB, error) {
+	return sql.Open(
+		"sqlite",
+		fmt.Sprintf(
+			"file:%s?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)",
+			path,
+		),
+	)
+}
+
+// wrapSqliteErr converts missing rows and context errors into ErrNotFound,
+// ErrTimeout or ErrCanceled; other errors, constraint violations among them,
+// are returned unchanged even when the context has expired since.
+func wrapSqliteErr(ctx context.Context, err error) error {
+	var sqliteErr *sqlite.Error
+
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return fmt.Errorf("%w: %w", ErrNotFound, err)
+	case errors.Is(err, context.DeadlineExceeded):
+		return fmt.Errorf("%w: %w", ErrTimeout, err)
+	case errors.Is(err, context.Canceled):
+		return fmt.Errorf("%w: %w", ErrCanceled, err)
+	case errors.As(err, &sqliteErr) && sqliteErr.Code() == sqlite3.SQLITE_INTERRUPT:
+		// the driver interrupts the statement when the context is done,
+		// which surfaces as its own error rather than the context error
+		if ctxErr := contextErr(ctx); ctxErr != nil {
+			return fmt.Errorf("%w: %w", ctxErr, err)
+		}
+		return err
+	default:
+		return err
+	}
+}
+
+const (
+	sqliteStoreUser = `
+        INSERT INTO users (email, password, name, gender, birth_date, location)
+		VALUES (?1, ?2, ?3, ?4, ?5, ?6)
+		RETURNING id
+	`
+)
+
+func (store *tinydatesSqliteStore) StoreNewUser(
+	ctx context.Context,
+	email, password, name, gender string,
+	birthDate time.Time,
+	location int,
+) (int, error) {
+	var id int
+
+	if err := store.Db.QueryRowContext(
+		ctx,
+		sqliteStoreUser,
+		email,
+		password,
+		name,
+		gender,
+		birthDate.Format(time.DateOnly),
+		location,
+	).Scan(
+		&id,
+	); err != nil {
+		return 0, wrapSqliteErr(ctx, err)
+	}
+
+	return id, nil
+}
+
+const (
+	sqliteGetPassword = `
+        SELECT id, password
+		FROM users
+		WHERE email = ?1
+		ORDER BY id
+		LIMIT 1
+	`
+)
+
+func (store *tinydatesSqliteStore) GetPassword(
+	ctx context.Context,
+	email string,
+) (int, string, error) {
+	var (
+		id       int
+		password string
+	)
+
+	if err := store.Db.QueryRowContext(
+		ctx,
+		sqliteGetPassword,
+		email,
+	).Scan(
+		&id,
+		&password,
+	); err != nil {
+		return 0, "", wrapSqliteErr(ctx, err)
+	}
+
+	return id, password, nil
+}
+
+// sqliteUserAge is the age of a user in whole years, computed from their
+// birth date as userAge does for Postgres; a year is taken off until their
+// birthday.
+const sqliteUserAge = `(
+		    CAST(strftime('%Y', 'now') AS INTEGER)
+			- CAST(strftime('%Y', birth_date) AS INTEGER)
+			- (strftime('%m-%d', 'now') < strftime('%m-%d', birth_date))
+		)`
+
+// sqlitePotentialMatchColumns are the columns read by
+// scanSqlitePotentialMatch, likes_me is whether the user has favourably
+// swiped the user ?1 and shared_interests how many interests they have in
+// common with them.
+const sqlitePotentialMatchColumns = `
+		id, name, gender, pronouns, ` + sqliteUserAge + ` AS age, location,
+		desirability, last_active,
+		EXISTS(
+		    SELECT 1 FROM swipes
+			WHERE swiper = users.id
+			AND swipee = ?1
+			AND decision = true
+		) AS likes_me,
+		(
+		    SELECT count(*)
+			FROM interests AS theirs
+			JOIN interests AS mine ON mine.interest = theirs.interest
+			WHERE theirs.user_id = users.id
+			AND mine.user_id = ?1
+			AND users.id != ?1
+		) AS shared_interests
+`
+
+// scanSqlitePotentialMatch reads a row selecting sqlitePotentialMatchColumns.
+func scanSqlitePotentialMatch(row interface{ Scan(...any) error }) (PotentialMatch, error) {
+	var (
+		user       PotentialMatch
+		lastActive sql.NullTime
+	)
+
+	if err := row.Scan(
+		&user.Id,
+		&user.Name,
+		&user.Gender,
+		&user.Pronouns,
+		&user.Age,
+		&user.Location,
+		&user.Desirability,
+		&lastActive,
+		&user.LikesMe,
+		&user.SharedInterests,
+	); err != nil {
+		return PotentialMatch{}, err
+	}
+	user.LastActive = lastActive.Time
+
+	return user, nil
+}
+
+// sqliteReciprocal leaves out the users whose preferences exclude the user
+// ?1, as reciprocal does for Postgres.
+const sqliteReciprocal = `
+		AND NOT EXISTS (
+		    SELECT 1
+			FROM preferences AS theirs
+			JOIN (
+			    SELECT id, gender, location, ` + sqliteUserAge + ` AS age FROM users
+			) AS me ON me.id = ?1
+			WHERE theirs.user_id = users.id
+			AND NOT theirs.show_outside_preferences
+			AND NOT (
+			    (
+				    json_array_length(theirs.genders) = 0
+					OR me.gender IN (SELECT value FROM json_each(theirs.genders))
+				)
+				AND (theirs.min_age IS NULL OR me.age >= theirs.min_age)
+				AND (theirs.max_age IS NULL OR me.age <= theirs.max_age)
+				AND (
+				    theirs.max_distance IS NULL
+					OR abs(me.location - users.location) <= theirs.max_distance
+				)
+			)
+		)
+`
+
+// sqliteFiltered keeps the users meeting the filters of the user ?1, as
+// filtered does for Postgres: the genders ?2, a JSON array, the ages from ?3
+// to ?4 and the distance ?5 from them.
+const sqliteFiltered = `
+		AND (json_array_length(?2) = 0 OR gender IN (SELECT value FROM json_each(?2)))
+		AND (?3 IS NULL OR ` + sqliteUserAge + ` >= ?3)
+		AND (?4 IS NULL OR ` + sqliteUserAge + ` <= ?4)
+		AND (
+		    ?5 IS NULL
+			OR abs(users.location - (SELECT me.location FROM users AS me WHERE me.id = ?1)) <= ?5
+		)
+`
+
+const (
+	sqliteDiscover = `
+        SELECT` + sqlitePotentialMatchColumns + `
+		FROM users
+		WHERE id != ?1
+		AND id NOT IN (
+		    SELECT swipee
+			FROM swipes
+			WHERE swiper = ?1
+		)` + sqliteReciprocal + sqliteFiltered
+)
+
+func (store *tinydatesSqliteStore) Discover(
+	ctx context.Context,
+	id int,
+	filters Filters,
+) ([]PotentialMatch, error) {
+	potentials := make([]PotentialMatch, 0)
+
+	genders, err := json.Marshal(nonNil(filters.Genders))
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := store.Db.QueryContext(
+		ctx,
+		sqliteDiscover,
+		id,
+		string(genders),
+		filters.MinAge,
+		filters.MaxAge,
+		filters.MaxDistance,
+	)
+	if err != nil {
+		return nil, wrapSqliteErr(ctx, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		user, err := scanSqlitePotentialMatch(rows)
+		if err != nil {
+			return nil, wrapSqliteErr(ctx, err)
+		}
+		potentials = append(potentials, user)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, wrapSqliteErr(ctx, err)
+	}
+
+	return potentials, nil
+}
+
+const (
+	sqliteDiscoverByPopularity = `
+        SELECT` + sqlitePotentialMatchColumns + `
+		FROM users
+		WHERE id != ?1` + sqliteReciprocal + sqliteFiltered + `
+		ORDER BY desirability DESC, id
+	`
+)
+
+func (store *tinydatesSqliteStore) DiscoverByPopularity(
+	ctx context.Context,
+	id int,
+	filters Filters,
+) ([]PotentialMatch, error) {
+	potentials := make([]PotentialMatch, 0)
+
+	genders, err := json.Marshal(nonNil(filters.Genders))
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := store.Db.QueryContext(
+		ctx,
+		sqliteDiscoverByPopularity,
+		id,
+		string(genders),
+		filters.MinAge,
+		filters.MaxAge,
+		filters.MaxDistance,
+	)
+	if err != nil {
+		return nil, wrapSqliteErr(ctx, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		user, err := scanSqlitePotentialMatch(rows)
+		if err != nil {
+			return nil, wrapSqliteErr(ctx, err)
+		}
+		potentials = append(potentials, user)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, wrapSqliteErr(ctx, err)
+	}
+
+	return potentials, nil
+}
+
+const (
+	sqliteDiscoverBySharedInterests = sqliteDiscover + `
+		ORDER BY
+		    shared_interests DESC,
+			abs(location - (SELECT location FROM users WHERE id = ?1)),
+			id
+	`
+)
+
+func (store *tinydatesSqliteStore) DiscoverBySharedInterests(
+	ctx context.Context,
+	id int,
+	filters Filters,
+) ([]PotentialMatch, error) {
+	potentials := make([]PotentialMatch, 0)
+
+	genders, err := json.Marshal(nonNil(filters.Genders))
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := store.Db.QueryContext(
+		ctx,
+		sqliteDiscoverBySharedInterests,
+		id,
+		string(genders),
+		filters.MinAge,
+		filters.MaxAge,
+		filters.MaxDistance,
+	)
+	if err != nil {
+		return nil, wrapSqliteErr(ctx, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		user, err := scanSqlitePotentialMatch(rows)
+		if err != nil {
+			return nil, wrapSqliteErr(ctx, err)
+		}
+		potentials = append(potentials, user)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, wrapSqliteErr(ctx, err)
+	}
+
+	return potentials, nil
+}
+
+const (
+	sqliteSwipe = `
+        INSERT INTO swipes (swiper, swipee, decision)
+		VALUES (?1, ?2, ?3)
+		RETURNING id
+	`
+
+	sqliteRecordActivity = `
+        UPDATE users
+		SET last_active = ?2
+		WHERE id = ?1
+	`
+)
+
+// Swipe inserts the swipe and records the activity of the swiper in one
+// transaction, SQLite has no data modifying common table expressions.
+func (store *tinydatesSqliteStore) Swipe(
+	ctx context.Context,
+	swiperId int,
+	swipeeId int,
+	decision bool,
+) (int, error) {
+	tx, err := store.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, wrapSqliteErr(ctx, err)
+	}
+	defer tx.Rollback()
+
+	var matchId int
+
+	if err := tx.QueryRowContext(
+		ctx,
+		sqliteSwipe,
+		swiperId,
+		swipeeId,
+		decision,
+	).Scan(
+		&matchId,
+	); err != nil {
+		return 0, wrapSqliteErr(ctx, err)
+	}
+
+	if _, err := tx.ExecContext(
+		ctx,
+		sqliteRecordActivity,
+		swiperId,
+		time.Now().UTC(),
+	); err != nil {
+		return 0, wrapSqliteErr(ctx, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, wrapSqliteErr(ctx, err)
+	}
+
+	return matchId, nil
+}
+
+const (
+	sqliteIsMatch = `
+        SELECT EXISTS(
+			SELECT 1 FROM swipes
+			WHERE swiper = ?1
+			AND swipee = ?2
+		)
+	`
+)
+
+func (store *tinydatesSqliteStore) IsMatch(
+	ctx context.Context,
+	swipeeId int,
+	swiperId int,
+) (bool, error) {
+	var match bool
+
+	if err := store.Db.QueryRowContext(
+		ctx,
+		sqliteIsMatch,
+		swipeeId,
+		swiperId,
+	).Scan(
+		&match,
+	); err != nil {
+		return false, wrapSqliteErr(ctx, err)
+	}
+
+	return match, nil
+}
+
+const (
+	sqliteLocation = `
+        SELECT location
+		FROM users
+		WHERE id = ?1
+	`
+)
+
+func (store *tinydatesSqliteStore) GetLocation(
+	ctx context.Context,
+	id int,
+) (int, error) {
+	var userLocation int
+
+	if err := store.Db.QueryRowContext(
+		ctx,
+		sqliteLocation,
+		id,
+	).Scan(&userLocation); err != nil {
+		return 0, wrapSqliteErr(ctx, err)
+	}
+
+	return userLocation, nil
+}
+
+const (
+	sqliteGetProfile = `
+        SELECT` + sqlitePotentialMatchColumns + `
+		FROM users
+		WHERE id = ?1
+	`
+)
+
+func (store *tinydatesSqliteStore) GetProfile(
+	ctx context.Context,
+	id int,
+) (PotentialMatch, error) {
+	user, err := scanSqlitePotentialMatch(
+		store.Db.QueryRowContext(ctx, sqliteGetProfile, id),
+	)
+	if err != nil {
+		return PotentialMatch{}, wrapSqliteErr(ctx, err)
+	}
+
+	return user, nil
+}
+
+const (
+	// the ids are bound as a JSON array, SQLite has no array parameters
+	sqliteGetProfiles = `
+        SELECT` + sqlitePotentialMatchColumns + `
+		FROM users
+		WHERE id IN (SELECT value FROM json_each(?2))
+	`
+)
+
+func (store *tinydatesSqliteStore) GetProfiles(
+	ctx context.Context,
+	viewer int,
+	ids []int,
+) ([]PotentialMatch, error) {
+	encoded, err := json.Marshal(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	profiles := make([]PotentialMatch, 0, len(ids))
+
+	rows, err := store.Db.QueryContext(ctx, sqliteGetProfiles, viewer, string(encoded))
+	if err != nil {
+		return nil, wrapSqliteErr(ctx, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		user, err := scanSqlitePotentialMatch(rows)
+		if err != nil {
+			return nil, wrapSqliteErr(ctx, err)
+		}
+		profiles = append(profiles, user)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, wrapSqliteErr(ctx, err)
+	}
+
+	return profiles, nil
+}
+
+const (
+	sqliteGetDesirability = `
+        SELECT desirability
+		FROM users
+		WHERE id = ?1
+	`
+)
+
+func (store *tinydatesSqliteStore) GetDesirability(
+	ctx context.Context,
+	id int,
+) (float64, error) {
+	var desirability float64
+
+	if err := store.Db.QueryRowContext(
+		ctx,
+		sqliteGetDesirability,
+		id,
+	).Scan(&desirability); err != nil {
+		return 0, wrapSqliteErr(ctx, err)
+	}
+
+	return desirability, nil
+}
+
+const (
+	sqliteAdjustDesirability = `
+        UPDATE users
+		SET desirability = desirability + ?2
+		WHERE id = ?1
+	`
+)
+
+func (store *tinydatesSqliteStore) AdjustDesirability(
+	ctx context.Context,
+	id int,
+	delta float64,
+) error {
+	result, err := store.Db.ExecContext(ctx, sqliteAdjustDesirability, id, delta)
+	if err != nil {
+		return wrapSqliteErr(ctx, err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return wrapSqliteErr(ctx, err)
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+const (
+	// sqliteLockDesirability takes the write lock of the database up front,
+	// a transaction reading before it writes could not take it once another
+	// has written in the meantime
+	sqliteLockDesirability = `
+        UPDATE users
+		SET desirability = desirability
+		WHERE id = ?1
+	`
+)
+
+// RateDesirability rates in a transaction holding the write lock of the
+// database, so that concurrent ratings wait on each other.
+func (store *tinydatesSqliteStore) RateDesirability(
+	ctx context.Context,
+	swiper, swipee int,
+	rate func(swiper, swipee float64) float64,
+) error {
+	tx, err := store.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return wrapSqliteErr(ctx, err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, sqliteLockDesirability, swipee); err != nil {
+		return wrapSqliteErr(ctx, err)
+	}
+
+	var swipeeScore, swiperScore float64
+	if err := tx.QueryRowContext(ctx, sqliteGetDesirability, swipee).Scan(&swipeeScore); err != nil {
+		return wrapSqliteErr(ctx, err)
+	}
+	if err := tx.QueryRowContext(ctx, sqliteGetDesirability, swiper).Scan(&swiperScore); err != nil {
+		return wrapSqliteErr(ctx, err)
+	}
+
+	if _, err := tx.ExecContext(
+		ctx,
+		sqliteAdjustDesirability,
+		swipee,
+		rate(swiperScore, swipeeScore),
+	); err != nil {
+		return wrapSqliteErr(ctx, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return wrapSqliteErr(ctx, err)
+	}
+
+	return nil
+}
+
+const (
+	sqliteGetPreferences = `
+        SELECT
+		    coalesce(preferences.genders, '[]'),
+			preferences.min_age,
+			preferences.max_age,
+			preferences.max_distance,
+			coalesce(preferences.dealbreakers, '[]'),
+			coalesce(preferences.show_outside_preferences, false)
+		FROM users
+		LEFT JOIN preferences ON preferences.user_id = users.id
+		WHERE users.id = ?1
+	`
+)
+
+func (store *tinydatesSqliteStore) GetPreferences(
+	ctx context.Context,
+	id int,
+) (Preferences, error) {
+	var (
+		preferences           Preferences
+		genders, dealbreakers string
+	)
+
+	if err := store.Db.QueryRowContext(
+		ctx,
+		sqliteGetPreferences,
+		id,
+	).Scan(
+		&genders,
+		&preferences.MinAge,
+		&preferences.MaxAge,
+		&preferences.MaxDistance,
+		&dealbreakers,
+		&preferences.ShowOutsidePreferences,
+	); err != nil {
+		return Preferences{}, wrapSqliteErr(ctx, err)
+	}
+
+	if err := json.Unmarshal([]byte(genders), &preferences.Genders); err != nil {
+		return Preferences{}, err
+	}
+	if err := json.Unmarshal([]byte(dealbreakers), &preferences.Dealbreakers); err != nil {
+		return Preferences{}, err
+	}
+
+	return preferences, nil
+}
+
+const (
+	// the lists are bound as JSON arrays, a row is only inserted for a user
+	// that exists
+	sqliteSetPreferences = `
+        INSERT INTO preferences (
+		    user_id, genders, min_age, max_age, max_distance, dealbreakers,
+			show_outside_preferences
+		)
+		SELECT id, ?2, ?3, ?4, ?5, ?6, ?7
+		FROM users
+		WHERE id = ?1
+		ON CONFLICT (user_id) DO UPDATE
+		SET genders = excluded.genders,
+			min_age = excluded.min_age,
+			max_age = excluded.max_age,
+			max_distance = excluded.max_distance,
+			dealbreakers = excluded.dealbreakers,
+			show_outside_preferences = excluded.show_outside_preferences
+	`
+)
+
+func (store *tinydatesSqliteStore) SetPreferences(
+	ctx context.Context,
+	id int,
+	preferences Preferences,
+) error {
+	genders, err := json.Marshal(nonNil(preferences.Genders))
+	if err != nil {
+		return err
+	}
+	dealbreakers, err := json.Marshal(nonNil(preferences.Dealbreakers))
+	if err != nil {
+		return err
+	}
+
+	result, err := store.Db.ExecContext(
+		ctx,
+		sqliteSetPreferences,
+		id,
+		string(genders),
+		preferences.MinAge,
+		preferences.MaxAge,
+		preferences.MaxDistance,
+		string(dealbreakers),
+		preferences.ShowOutsidePreferences,
+	)
+	if err != nil {
+		return wrapSqliteErr(ctx, err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return wrapSqliteErr(ctx, err)
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+const (
+	sqliteSetIdentity = `
+        UPDATE users
+		SET gender = ?2, pronouns = ?3
+		WHERE id = ?1
+	`
+)
+
+func (store *tinydatesSqliteStore) SetIdentity(
+	ctx context.Context,
+	id int,
+	gender, pronouns string,
+) error {
+	result, err := store.Db.ExecContext(ctx, sqliteSetIdentity, id, gender, pronouns)
+	if err != nil {
+		return wrapSqliteErr(ctx, err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return wrapSqliteErr(ctx, err)
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+const (
+	// the prompt answers and interests are read as JSON arrays, the answers
+	// as objects of the fields of PromptAnswer
+	sqliteGetAbout = `
+        SELECT
+		    bio,
+			(
+			    SELECT json_group_array(
+				    json_object('Prompt', prompt, 'Answer', answer)
+				)
+				FROM (
+				    SELECT prompt, answer
+					FROM prompt_answers
+					WHERE user_id = users.id
+					ORDER BY position
+				)
+			),
+			(
+			    SELECT json_group_array(interest)
+				FROM (
+				    SELECT interest
+					FROM interests
+					WHERE user_id = users.id
+					ORDER BY interest
+				)
+			)
+		FROM users
+		WHERE id = ?1
+	`
+)
+
+func (store *tinydatesSqliteStore) GetAbout(ctx context.Context, id int) (About, error) {
+	var (
+		about     About
+		prompts   string
+		interests string
+	)
+
+	if err := store.Db.QueryRowContext(
+		ctx,
+		sqliteGetAbout,
+		id,
+	).Scan(
+		&about.Bio,
+		&prompts,
+		&interests,
+	); err != nil {
+		return About{}, wrapSqliteErr(ctx, err)
+	}
+
+	if err := json.Unmarshal([]byte(prompts), &about.Prompts); err != nil {
+		return About{}, err
+	}
+	if err := json.Unmarshal([]byte(interests), &about.Interests); err != nil {
+		return About{}, err
+	}
+
+	return about, nil
+}
+
+const (
+	sqliteSetBio = `
+        UPDATE users
+		SET bio = ?2
+		WHERE id = ?1
+	`
+
+	sqliteDeletePromptAnswers = `
+        DELETE FROM prompt_answers
+		WHERE user_id = ?1
+	`
+
+	// the answers are bound as a JSON array of the fields of PromptAnswer,
+	// positioned in the order they are listed
+	sqliteAddPromptAnswers = `
+        INSERT INTO prompt_answers (user_id, prompt, position, answer)
+		SELECT ?1, json_extract(value, '$.Prompt'), key, json_extract(value, '$.Answer')
+		FROM json_each(?2)
+	`
+
+	sqliteDeleteInterests = `
+        DELETE FROM interests
+		WHERE user_id = ?1
+	`
+
+	sqliteAddInterests = `
+        INSERT INTO interests (user_id, interest)
+		SELECT ?1, value
+		FROM json_each(?2)
+	`
+)
+
+// SetAbout replaces the bio, prompt answers and interests in one
+// transaction, as the Postgres store does.
+func (store *tinydatesSqliteStore) SetAbout(
+	ctx context.Context,
+	id int,
+	about About,
+) error {
+	prompts, err := json.Marshal(nonNilAnswers(about.Prompts))
+	if err != nil {
+		return err
+	}
+	interests, err := json.Marshal(nonNil(about.Interests))
+	if err != nil {
+		return err
+	}
+
+	tx, err := store.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return wrapSqliteErr(ctx, err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, sqliteSetBio, id, about.Bio)
+	if err != nil {
+		return wrapSqliteErr(ctx, err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return wrapSqliteErr(ctx, err)
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+
+	for _, statement := range []struct {
+		sql  string
+		args []any
+	}{
+		{sqliteDeletePromptAnswers, []any{id}},
+		{sqliteAddPromptAnswers, []any{id, string(prompts)}},
+		{sqliteDeleteInterests, []any{id}},
+		{sqliteAddInterests, []any{id, string(interests)}},
+	} {
+		if _, err := tx.ExecContext(ctx, statement.sql, statement.args...); err != nil {
+			return wrapSqliteErr(ctx, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return wrapSqliteErr(ctx, err)
+	}
+
+	return nil
+}
+
+// nonNilAnswers returns an empty list in place of nil, which would be
+// encoded as null.
+func nonNilAnswers(answers []PromptAnswer) []PromptAnswer {
+	if answers == nil {
+		return []PromptAnswer{}
+	}
+
+	return answers
+}
+
+// sqlitePhotoColumns are the columns read by scanSqlitePhoto.
+const sqlitePhotoColumns = `
+		id, user_id, position, key, thumbnail_key, width, height
+`
+
+// scanSqlitePhoto reads a row selecting sqlitePhotoColumns.
+func scanSqlitePhoto(row interface{ Scan(...any) error }) (Photo, error) {
+	var photo Photo
+
+	err := row.Scan(
+		&photo.Id,
+		&photo.UserId,
+		&photo.Position,
+		&photo.Key,
+		&photo.ThumbnailKey,
+		&photo.Width,
+		&photo.Height,
+	)
+
+	return photo, err
+}
+
+const (
+	// the ids are bound as a JSON array, SQLite has no array parameters
+	sqliteGetPhotos = `
+        SELECT` + sqlitePhotoColumns + `
+		FROM photos
+		WHERE user_id IN (SELECT value FROM json_each(?1))
+		ORDER BY user_id, position, id
+	`
+)
+
+func (store *tinydatesSqliteStore) GetPhotos(
+	ctx context.Context,
+	ids []int,
+) (map[int][]Photo, error) {
+	encoded, err := json.Marshal(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	photos := make(map[int][]Photo)
+
+	rows, err := store.Db.QueryContext(ctx, sqliteGetPhotos, string(encoded))
+	if err != nil {
+		return nil, wrapSqliteErr(ctx, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		photo, err := scanSqlitePhoto(rows)
+		if err != nil {
+			return nil, wrapSqliteErr(ctx, err)
+		}
+		photos[photo.UserId] = append(photos[photo.UserId], photo)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, wrapSqliteErr(ctx, err)
+	}
+
+	return photos, nil
+}
+
+const (
+	// a row is only inserted for a user that exists, placed after the other
+	// photos of the user
+	// sqliteLockPhotos takes the write lock of the database up front, as
+	// sqliteLockDesirability does, for the photos to be counted and added
+	// without another upload in between
+	sqliteLockPhotos = `
+        UPDATE users
+		SET id = id
+		WHERE id = ?1
+	`
+
+	sqliteCountPhotos = `
+        SELECT count(*)
+		FROM photos
+		WHERE user_id = ?1
+	`
+
+	sqliteAddPhoto = `
+        INSERT INTO photos (user_id, position, key, thumbnail_key, width, height)
+		SELECT
+		    id,
+			(SELECT coalesce(max(position) + 1, 0) FROM photos WHERE user_id = ?1),
+			?2, ?3, ?4, ?5
+		FROM users
+		WHERE id = ?1
+		RETURNING id, position
+	`
+)
+
+// AddPhoto counts and adds the photo in a transaction holding the write lock
+// of the database, so that concurrent uploads cannot all pass the limit.
+func (store *tinydatesSqliteStore) AddPhoto(
+	ctx context.Context,
+	id int,
+	photo Photo,
+	limit int,
+) (Photo, error) {
+	photo.UserId = id
+
+	tx, err := store.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return Photo{}, wrapSqliteErr(ctx, err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, sqliteLockPhotos, id)
+	if err != nil {
+		return Photo{}, wrapSqliteErr(ctx, err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return Photo{}, wrapSqliteErr(ctx, err)
+	}
+	if affected == 0 {
+		return Photo{}, ErrNotFound
+	}
+
+	var count int
+	if err := tx.QueryRowContext(ctx, sqliteCountPhotos, id).Scan(&count); err != nil {
+		return Photo{}, wrapSqliteErr(ctx, err)
+	}
+	if count >= limit {
+		return Photo{}, ErrLimitReached
+	}
+
+	if err := tx.QueryRowContext(
+		ctx,
+		sqliteAddPhoto,
+		id,
+		photo.Key,
+		photo.ThumbnailKey,
+		photo.Width,
+		photo.Height,
+	).Scan(&photo.Id, &photo.Position); err != nil {
+		return Photo{}, wrapSqliteErr(ctx, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Photo{}, wrapSqliteErr(ctx, err)
+	}
+
+	return photo, nil
+}
+
+const (
+	// the ids are bound as a JSON array, the position of a photo is its index
+	// in it; nothing is updated unless every id is a photo of the user,
+	// listed once
+	sqliteReorderPhotos = `
+        UPDATE photos
+		SET position = (SELECT key FROM json_each(?2) WHERE value = photos.id)
+		WHERE user_id = ?1
+		AND id IN (SELECT value FROM json_each(?2))
+		AND (
+		    SELECT count(*)
+			FROM photos
+			WHERE user_id = ?1
+			AND id IN (SELECT value FROM json_each(?2))
+		) = json_array_length(?2)
+	`
+)
+
+func (store *tinydatesSqliteStore) ReorderPhotos(
+	ctx context.Context,
+	id int,
+	photoIds []int,
+) error {
+	encoded, err := json.Marshal(photoIds)
+	if err != nil {
+		return err
+	}
+
+	result, err := store.Db.ExecContext(ctx, sqliteReorderPhotos, id, string(encoded))
+	if err != nil {
+		return wrapSqliteErr(ctx, err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return wrapSqliteErr(ctx, err)
+	}
+	if affected != int64(len(photoIds)) {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+const (
+	sqliteDeletePhoto = `
+        DELETE FROM photos
+		WHERE id = ?2
+		AND user_id = ?1
+		RETURNING` + sqlitePhotoColumns
+)
+
+func (store *tinydatesSqliteStore) DeletePhoto(
+	ctx context.Context,
+	id, photoId int,
+) (Photo, error) {
+	photo, err := scanSqlitePhoto(store.Db.QueryRowContext(ctx, sqliteDeletePhoto, id, photoId))
+	if err != nil {
+		return Photo{}, wrapSqliteErr(ctx, err)
+	}
+
+	return photo, nil
+}
+
+const (
+	sqliteReplaySwipes = `
+        SELECT swiper, swipee, decision
+		FROM swipes
+		ORDER BY id
+	`
+)
+
+func (store *tinydatesSqliteStore) ReplaySwipes(
+	ctx context.Context,
+	fn func(NewSwipe) error,
+) error {
+	rows, err := store.Db.QueryContext(ctx, sqliteReplaySwipes)
+	if err != nil {
+		return wrapSqliteErr(ctx, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var swipe NewSwipe
+
+		if err := rows.Scan(
+			&swipe.Swiper,
+			&swipe.Swipee,
+			&swipe.Decision,
+		); err != nil {
+			return wrapSqliteErr(ctx, err)
+		}
+		if err := fn(swipe); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return wrapSqliteErr(ctx, err)
+	}
+
+	return nil
+}
+
+const (
+	sqliteResetDesirability = `
+        UPDATE users
+		SET desirability = ?1
+	`
+
+	sqliteSetDesirability = `
+        UPDATE users
+		SET desirability = ?2
+		WHERE id = ?1
+	`
+)
+
+// ResetDesirability updates every user in a single transaction so that
+// readers never see a partial backfill.
+func (store *tinydatesSqliteStore) ResetDesirability(
+	ctx context.Context,
+	scores map[int]float64,
+) error {
+	tx, err := store.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return wrapSqliteErr(ctx, err)
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.ExecContext(
+		ctx,
+		sqliteResetDesirability,
+		InitialDesirability,
+	); err != nil {
+		return wrapSqliteErr(ctx, err)
+	}
+
+	set, err := tx.PrepareContext(ctx, sqliteSetDesirability)
+	if err != nil {
+		return wrapSqliteErr(ctx, err)
+	}
+	defer set.Close()
+
+	for id, score := range scores {
+		if _, err = set.ExecContext(ctx, id, score); err != nil {
+			return wrapSqliteErr(ctx, err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return wrapSqliteErr(ctx, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,42 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"tinydates/migration"
+
+	"github.com/golang-migrate/migrate/v4"
+)
+
+func NewTestTinydatesSqliteStore(db *sql.DB) TestStore {
+	return &tinydatesSqliteStore{Db: db}
+}
+
+// Up applies every embedded SQLite migration.
+func (store *tinydatesSqliteStore) Up(ctx context.Context) error {
+	m, err := migration.Sqlite(store.Db)
+	if err != nil {
+		return err
+	}
+
+	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return err
+	}
+
+	return nil
+}
+
+// Down reverts every embedded SQLite migration.
+func (store *tinydatesSqliteStore) Down(ctx context.Context) error {
+	m, err := migration.Sqlite(store.Db)
+	if err != nil {
+		return err
+	}
+
+	if err := m.Down(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return err
+	}
+
+	return nil
+}
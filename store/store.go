@@ -1,32 +1,90 @@
 package store
 
-import "context"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// InitialDesirability is the desirability score users start with, the default
+// of the desirability column.
+const InitialDesirability = 1500.0
+
+var (
+	// ErrNotFound is returned when the requested record does not exist.
+	ErrNotFound = errors.New("store record not found")
+
+	// ErrTimeout is returned when an operation did not complete before the
+	// deadline of its context, or the database cancelled it for taking too
+	// long.
+	ErrTimeout = errors.New("store operation timed out")
+
+	// ErrCanceled is returned when the context of an operation was cancelled
+	// before it completed, typically because the client went away.
+	ErrCanceled = errors.New("store operation cancelled")
+
+	// ErrLimitReached is returned when a record would take those of a user
+	// past the limit supplied for them.
+	ErrLimitReached = errors.New("store limit reached")
+)
+
+// contextErr returns ErrTimeout or ErrCanceled, wrapping the context error,
+// when ctx is already done and nil otherwise. Stores that do not talk to a
+// server use it to honour cancellation before doing any work.
+func contextErr(ctx context.Context) error {
+	switch err := ctx.Err(); {
+	case errors.Is(err, context.DeadlineExceeded):
+		return fmt.Errorf("%w: %w", ErrTimeout, err)
+	case err != nil:
+		return fmt.Errorf("%w: %w", ErrCanceled, err)
+	default:
+		return nil
+	}
+}
 
 // Store are the core methods required from the database for tinydates.
 type Store interface {
 	// StoreNewUser inserts a new user into the database returning their
-	// autoincremented user id as the value.
+	// autoincremented user id as the value; only the date of birthDate is
+	// kept, their age is computed from it whenever they are read.
 	StoreNewUser(
 		ctx context.Context,
 		email, password, name, gender string,
-		age, location int,
+		birthDate time.Time,
+		location int,
 	) (int, error)
 
 	// GetPassword returns the password for the user with the supplied email 
-	GetPassword(ctx context.Context, email string) (string, error)
+	// along with their id
+	GetPassword(ctx context.Context, email string) (int, string, error)
 
-	// Discover finds profiles that are a match for the user with supplied id
-	Discover(ctx context.Context, id int) ([]PotentialMatch, error)
+	// Discover finds profiles that are a match for the user with supplied id,
+	// meeting filters and leaving out those whose preferences exclude the
+	// user unless they asked to be shown outside them
+	Discover(ctx context.Context, id int, filters Filters) ([]PotentialMatch, error)
 
 	// DiscoverWithPopulariy finds potential profiles that are a match for the 
-	// user supplied id and ordered by popularity
+	// user supplied id and ordered by desirability, most desirable first;
+	// filters and preferences are honoured as by Discover
 	DiscoverByPopularity(
 		ctx context.Context,
 		id int,
+		filters Filters,
+	) ([]PotentialMatch, error)
+
+	// DiscoverBySharedInterests finds the profiles Discover does, ordered by
+	// how many interests they share with the user with the supplied id, most
+	// first, then closest first
+	DiscoverBySharedInterests(
+		ctx context.Context,
+		id int,
+		filters Filters,
 	) ([]PotentialMatch, error)
 
 	// Swipe adds a swipe decision for the swiper and returns the match id and
-	// whether the swiper has also been favourably swiped by the swipee
+	// whether the swiper has also been favourably swiped by the swipee; the
+	// swiper is recorded as last active now
 	Swipe(
 		ctx context.Context,
 		swiperId, swipeeId int,
@@ -39,6 +97,71 @@ type Store interface {
 
 	// GetLocation returns the location for the user with the supplied id
 	GetLocation(ctx context.Context, id int) (int, error)
+
+	// GetProfile returns the profile of the user with the supplied id, as
+	// they are seen by the users discovering them
+	GetProfile(ctx context.Context, id int) (PotentialMatch, error)
+
+	// GetProfiles returns the profiles of the users with the supplied ids as
+	// they are seen by the user viewer, in no particular order; unknown ids
+	// are left out
+	GetProfiles(ctx context.Context, viewer int, ids []int) ([]PotentialMatch, error)
+
+	// GetDesirability returns the desirability score of the user with the
+	// supplied id
+	GetDesirability(ctx context.Context, id int) (float64, error)
+
+	// AdjustDesirability adds delta to the desirability score of the user with
+	// the supplied id, concurrent adjustments are never lost
+	AdjustDesirability(ctx context.Context, id int, delta float64) error
+
+	// RateDesirability adds to the desirability score of swipee the delta
+	// rate returns for the scores of swiper and swipee, read and updated
+	// atomically so that concurrent ratings of a user are applied one after
+	// the other, each against the score left by the last
+	RateDesirability(
+		ctx context.Context,
+		swiper, swipee int,
+		rate func(swiper, swipee float64) float64,
+	) error
+
+	// GetPreferences returns the discovery preferences of the user with the
+	// supplied id, the zero value when they have never set them
+	GetPreferences(ctx context.Context, id int) (Preferences, error)
+
+	// SetPreferences replaces the discovery preferences of the user with the
+	// supplied id
+	SetPreferences(ctx context.Context, id int, preferences Preferences) error
+
+	// SetIdentity replaces the gender and pronouns of the user with the
+	// supplied id
+	SetIdentity(ctx context.Context, id int, gender, pronouns string) error
+
+	// GetAbout returns what the user with the supplied id tells about
+	// themselves, an empty bio and lists until they do
+	GetAbout(ctx context.Context, id int) (About, error)
+
+	// SetAbout replaces what the user with the supplied id tells about
+	// themselves
+	SetAbout(ctx context.Context, id int, about About) error
+
+	// GetPhotos returns the photos of the users with the supplied ids keyed
+	// by user, each in order of position; users without photos are left out
+	GetPhotos(ctx context.Context, ids []int) (map[int][]Photo, error)
+
+	// AddPhoto stores photo as the last of the user with the supplied id,
+	// returning it with its id and position; ErrLimitReached is returned
+	// when they already have limit photos, however many are added at once
+	AddPhoto(ctx context.Context, id int, photo Photo, limit int) (Photo, error)
+
+	// ReorderPhotos sets the position of the photos of the user with the
+	// supplied id to their index in photoIds; unless they all are photos of
+	// the user, listed once, nothing changes and ErrNotFound is returned
+	ReorderPhotos(ctx context.Context, id int, photoIds []int) error
+
+	// DeletePhoto removes the photo with photoId of the user with the
+	// supplied id, returning it so that its images can be deleted
+	DeletePhoto(ctx context.Context, id, photoId int) (Photo, error)
 }
 
 // TestStore are the test methods used for testing the tinydates database.
@@ -56,3 +179,27 @@ type TestStore interface {
 	// Down is a database destruction method for testing only
 	Down(ctx context.Context) error
 }
+
+// Loader is implemented by stores that can bulk load generated data, such as
+// the output of the seed command, far faster than inserting it row by row.
+// Both methods read rows from next until it returns false.
+type Loader interface {
+	// LoadUsers inserts the users returning the id given to the first one,
+	// the others follow it consecutively.
+	LoadUsers(ctx context.Context, next func() (NewUser, bool)) (int, error)
+
+	// LoadSwipes inserts the swipes, their users must already exist.
+	LoadSwipes(ctx context.Context, next func() (NewSwipe, bool)) error
+}
+
+// Backfiller is implemented by stores whose desirability scores can be
+// recomputed from the history of swipes, as done by rating.Backfill.
+type Backfiller interface {
+	// ReplaySwipes calls fn with every swipe in the order they were made,
+	// stopping at the first error it returns.
+	ReplaySwipes(ctx context.Context, fn func(NewSwipe) error) error
+
+	// ResetDesirability sets the desirability score of every user to their
+	// entry in scores, users without one are reset to InitialDesirability.
+	ResetDesirability(ctx context.Context, scores map[int]float64) error
+}
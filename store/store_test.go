@@ -0,0 +1,63 @@
+package store_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+	"tinydates/store"
+	"tinydates/store/storetest"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInMemoryStore(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) store.TestStore {
+		return store.NewTestTinydatesInMemoryStore()
+	})
+}
+
+func TestPgStore(t *testing.T) {
+	db := storetest.Postgres(t)
+	storetest.Run(t, func(t *testing.T) store.TestStore {
+		return store.NewTestTinydatesPgStore(db)
+	})
+}
+
+func TestPgAgeIgnoresTheSessionTimeZone(t *testing.T) {
+	ctx := context.Background()
+	db := storetest.Postgres(t)
+
+	// a day ahead of UTC for most of the day
+	config := db.Config()
+	config.ConnConfig.RuntimeParams["timezone"] = "Pacific/Kiritimati"
+	ahead, err := pgxpool.NewWithConfig(ctx, config)
+	require.NoError(t, err)
+	t.Cleanup(ahead.Close)
+
+	s := store.NewTestTinydatesPgStore(ahead)
+	require.NoError(t, s.Up(ctx))
+	t.Cleanup(func() { require.NoError(t, s.Down(ctx)) })
+
+	// turning 30 tomorrow in UTC, as Age has it
+	today := time.Now().UTC()
+	born := today.AddDate(-30, 0, 1)
+	id, err := s.StoreNewUser(ctx, "turning@mail.com", "pw", "turning", "other", born, 0)
+	require.NoError(t, err)
+
+	profile, err := s.GetProfile(ctx, id)
+	require.NoError(t, err)
+	require.Equal(t, store.Age(born, today), profile.Age)
+	require.Equal(t, 29, profile.Age)
+}
+
+func TestSqliteStore(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) store.TestStore {
+		db, err := store.OpenSqlite(filepath.Join(t.TempDir(), "tinydates.db"))
+		require.NoError(t, err)
+		t.Cleanup(func() { db.Close() })
+
+		return store.NewTestTinydatesSqliteStore(db)
+	})
+}
@@ -0,0 +1,110 @@
+package store
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// statementNames maps each SQL statement of the Postgres store back to the
+// name of the constant it is declared as, these become the span names.
+var statementNames = map[string]string{
+	storeUser:            "storeUser",
+	getPassword:          "getPassword",
+	discover:             "discover",
+	discoverByPopularity: "discoverByPopularity",
+	swipe:                "swipe",
+	isMatch:              "isMatch",
+	location:             "location",
+	getProfile:           "getProfile",
+	getProfiles:          "getProfiles",
+	getDesirability:      "getDesirability",
+	adjustDesirability:   "adjustDesirability",
+	lockDesirability:     "lockDesirability",
+	getPreferences:       "getPreferences",
+	setPreferences:       "setPreferences",
+	setIdentity:          "setIdentity",
+	getAbout:             "getAbout",
+	setBio:               "setBio",
+	deletePromptAnswers:  "deletePromptAnswers",
+	addPromptAnswers:     "addPromptAnswers",
+	deleteInterests:      "deleteInterests",
+	addInterests:         "addInterests",
+	getPhotos:            "getPhotos",
+	lockPhotos:           "lockPhotos",
+	countPhotos:          "countPhotos",
+	addPhoto:             "addPhoto",
+	reorderPhotos:        "reorderPhotos",
+	deletePhoto:          "deletePhoto",
+	lockUsers:            "lockUsers",
+	lastUserId:           "lastUserId",
+	resetUserIds:         "resetUserIds",
+	replaySwipes:         "replaySwipes",
+	resetDesirability:    "resetDesirability",
+
+	discoverBySharedInterests:  "discoverBySharedInterests",
+	createDesirabilityBackfill: "createDesirabilityBackfill",
+	applyDesirabilityBackfill:  "applyDesirabilityBackfill",
+}
+
+// StatementName returns the name of a known store statement, unknown
+// statements are reported as "query".
+func StatementName(sql string) string {
+	if name, ok := statementNames[sql]; ok {
+		return name
+	}
+
+	return "query"
+}
+
+// queryTracer implements pgx.QueryTracer creating a span around each query
+// named after the statement being executed.
+type queryTracer struct {
+	tracer trace.Tracer
+}
+
+// NewQueryTracer returns a pgx tracer creating spans with tp, it is installed
+// on the connection config of the pool.
+func NewQueryTracer(tp trace.TracerProvider) pgx.QueryTracer {
+	return &queryTracer{tracer: tp.Tracer("tinydates/store")}
+}
+
+func (qt *queryTracer) TraceQueryStart(
+	ctx context.Context,
+	conn *pgx.Conn,
+	data pgx.TraceQueryStartData,
+) context.Context {
+	ctx, _ = qt.tracer.Start(
+		ctx,
+		StatementName(data.SQL),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "postgresql"),
+			attribute.String("db.statement", data.SQL),
+		),
+	)
+
+	return ctx
+}
+
+func (qt *queryTracer) TraceQueryEnd(
+	ctx context.Context,
+	conn *pgx.Conn,
+	data pgx.TraceQueryEndData,
+) {
+	span := trace.SpanFromContext(ctx)
+	defer span.End()
+
+	if data.Err != nil {
+		span.RecordError(data.Err)
+		span.SetStatus(codes.Error, data.Err.Error())
+		return
+	}
+
+	span.SetAttributes(
+		attribute.Int64("db.rows_affected", data.CommandTag.RowsAffected()),
+	)
+}
@@ -0,0 +1,39 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestQueryTracerNamesSpansAfterStatements(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	tracer := NewQueryTracer(tp)
+
+	ctx := tracer.TraceQueryStart(
+		context.Background(),
+		nil,
+		pgx.TraceQueryStartData{SQL: discoverByPopularity},
+	)
+	tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{})
+
+	ctx = tracer.TraceQueryStart(
+		context.Background(),
+		nil,
+		pgx.TraceQueryStartData{SQL: "SELECT 1"},
+	)
+	tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{Err: errors.New("boom")})
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+	require.Equal(t, "discoverByPopularity", spans[0].Name)
+	require.Equal(t, "query", spans[1].Name)
+	require.Equal(t, codes.Error, spans[1].Status.Code)
+}
@@ -1,13 +1,128 @@
 package store
 
+import "time"
+
 // PotentialMatch is a transient object of the user obtained from the call to
 // Discover methods. This is the cost required to abstract the store methods
 // over a concrete implementation.
 type PotentialMatch struct {
-	Id         int
-	Name       string
-	Gender     string
-	Age        int
-	Location   int
-	Popularity int
+	Id           int
+	Name         string
+	Gender       string
+	Location     int
+	Desirability float64
+
+	// Age is computed from the birth date of the user when they are read.
+	Age int
+
+	// Pronouns are those the user picked, empty until they do.
+	Pronouns string
+
+	// LastActive is when the user last swiped, the zero time if they never
+	// have.
+	LastActive time.Time
+
+	// LikesMe is whether the user has favourably swiped the user discovering
+	// them, it is always false in the result of GetProfile.
+	LikesMe bool
+
+	// SharedInterests is how many interests the user has in common with the
+	// user discovering them, it is always 0 in the result of GetProfile.
+	SharedInterests int
+}
+
+// About is what a user tells about themselves on their profile.
+type About struct {
+	Bio string
+
+	// Prompts are the prompts the user answered, in the order they are
+	// shown.
+	Prompts []PromptAnswer
+
+	// Interests are the ids of the interests of the user, sorted.
+	Interests []string
+}
+
+// PromptAnswer is the answer of a user to the prompt with the id Prompt.
+type PromptAnswer struct {
+	Prompt string
+	Answer string
+}
+
+// Photo is a photo of a user, its images are held by a blob store.
+type Photo struct {
+	Id     int
+	UserId int
+
+	// Position orders the photos of a user, lowest first.
+	Position int
+
+	// Key and ThumbnailKey are the keys of the photo and of its thumbnail in
+	// the blob store.
+	Key          string
+	ThumbnailKey string
+
+	Width  int
+	Height int
+}
+
+// NewUser is a user to be bulk loaded by a Loader.
+type NewUser struct {
+	Email    string
+	Password string
+	Name     string
+	Gender   string
+	Location int
+
+	// BirthDate is the day the user was born, the time of day is ignored.
+	BirthDate time.Time
+}
+
+// Age returns the age in whole years, on the day of now, of someone born on
+// birthDate, as the stores compute it when now is in UTC.
+func Age(birthDate, now time.Time) int {
+	age := now.Year() - birthDate.Year()
+	if now.Month() < birthDate.Month() ||
+		now.Month() == birthDate.Month() && now.Day() < birthDate.Day() {
+		age--
+	}
+
+	return age
+}
+
+// NewSwipe is a swipe to be bulk loaded by a Loader, or replayed by a
+// Backfiller.
+type NewSwipe struct {
+	Swiper   int
+	Swipee   int
+	Decision bool
+}
+
+// Filters narrow the profiles discovered, every field is unset when nil or
+// empty. Ages are those of PotentialMatch.Age and distances are from the user
+// discovering.
+type Filters struct {
+	Genders     []string
+	MinAge      *int
+	MaxAge      *int
+	MaxDistance *int
+}
+
+// Preferences are the discovery preferences of a user, every field is unset
+// when nil or empty.
+type Preferences struct {
+	// Genders are the genders the user is interested in.
+	Genders []string
+
+	MinAge      *int
+	MaxAge      *int
+	MaxDistance *int
+
+	// Dealbreakers name the preferences profiles must meet to be discovered
+	// at all, the others only rank the profiles meeting them first.
+	Dealbreakers []string
+
+	// ShowOutsidePreferences is whether the user is discovered by the users
+	// their preferences exclude, they are hidden from them otherwise.
+	ShowOutsidePreferences bool
 }
@@ -0,0 +1,47 @@
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// ServiceName identifies tinydates in the traces it exports.
+const ServiceName = "tinydates"
+
+// Propagator is the W3C trace context and baggage propagator used for every
+// inbound and outbound call.
+var Propagator = propagation.NewCompositeTextMapPropagator(
+	propagation.TraceContext{},
+	propagation.Baggage{},
+)
+
+// NewProvider creates a tracer provider batching spans to exporter; the
+// provider must be shut down to flush any remaining spans.
+func NewProvider(exporter sdktrace.SpanExporter) *sdktrace.TracerProvider {
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(
+			semconv.ServiceName(ServiceName),
+		)),
+	)
+}
+
+// NewOTLPExporter creates an exporter sending spans over OTLP/HTTP. The
+// endpoint and headers are configured through the standard
+// OTEL_EXPORTER_OTLP_* environment variables.
+func NewOTLPExporter(ctx context.Context) (sdktrace.SpanExporter, error) {
+	return otlptracehttp.New(ctx)
+}
+
+// SetGlobal installs tp and the propagator as the process wide defaults so
+// that libraries using the global otel API take part in the same traces.
+func SetGlobal(tp *sdktrace.TracerProvider) {
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(Propagator)
+}
@@ -1,5 +1,7 @@
 package tinydates
 
+import "log/slog"
+
 type User struct {
 	Id       int    `json:"id"`
 	Email    string `json:"email"`
@@ -8,6 +10,20 @@ type User struct {
 	Gender   string `json:"gender"`
 	Age      int    `json:"age"`
 	Location int    `json:"location"`
+
+	// BirthDate is the day the user was born, such as 1990-04-21; Age is
+	// derived from it.
+	BirthDate string `json:"birthDate"`
+}
+
+// LogValue implements slog.LogValuer so that logging a user never writes out
+// their password.
+func (u User) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.Int("id", u.Id),
+		slog.String("email", u.Email),
+		slog.String("name", u.Name),
+	)
 }
 
 type LoginRequest struct {
@@ -15,33 +31,66 @@ type LoginRequest struct {
 	Password string `json:"password"`
 }
 
+// LogValue implements slog.LogValuer omitting the supplied password.
+func (lr LoginRequest) LogValue() slog.Value {
+	return slog.GroupValue(slog.String("email", lr.Email))
+}
+
 type LoginResponse struct {
 	Token string `json:"token"`
 }
 
+// LogValue implements slog.LogValuer omitting the session token.
+func (lr LoginResponse) LogValue() slog.Value {
+	return slog.GroupValue(slog.Bool("issued", lr.Token != ""))
+}
+
+// DiscoverRequest asks for the profiles the user with the supplied id may
+// discover. The filters left unset fall back to the stored preferences of the
+// user, those set replace them for this request only and every profile
+// discovered meets them.
+type DiscoverRequest struct {
+	// MinAge and MaxAge bound the age of the profiles, both ends included,
+	// the range is open ended on the side left unset.
+	MinAge *int
+	MaxAge *int
+
+	// MaxDistance is the furthest away the profiles may be.
+	MaxDistance *int
+
+	// Genders are the genders of the profiles, ids or aliases of the
+	// taxonomy.
+	Genders []string
+
+	// Sort names the strategy ranking the profiles, the default one when
+	// empty.
+	Sort string
+
+	// Limit is the most profiles returned, a page of them when 0.
+	Limit int
+}
+
+// DiscoveredUser is a profile returned by Discover, Age is derived from their
+// birth date on the day of the discovery.
 type DiscoveredUser struct {
 	Id             int    `json:"id"`
 	Name           string `json:"name"`
 	Gender         string `json:"gender"`
+	Pronouns       string `json:"pronouns,omitempty"`
 	Age            int    `json:"age"`
 	DistanceFromMe int    `json:"distanceFromMe"`
 	Popularity     int    `json:"popularity"`
-}
 
-// needed intermediary type to implement sort below
-type DiscoveredUsers []DiscoveredUser
+	// SharedInterests is how many interests the user has in common with the
+	// user discovering them.
+	SharedInterests int `json:"sharedInterests"`
 
-func (du DiscoveredUsers) Len() int {
-	return len(du)
+	// Photos are those of the user in the order they chose, empty when they
+	// have none.
+	Photos []Photo `json:"photos"`
 }
 
-func (du DiscoveredUsers) Less(i, j int) bool {
-	return du[i].DistanceFromMe < du[j].DistanceFromMe
-}
-
-func (du DiscoveredUsers) Swap(i, j int) {
-	du[i], du[j] = du[j], du[i]
-}
+type DiscoveredUsers []DiscoveredUser
 
 type DiscoverResponse struct {
 	Results DiscoveredUsers `json:"results"`
@@ -58,9 +107,109 @@ type SwipeResponse struct {
 	MatchId int  `json:"matchID,omitempty"`
 }
 
-// GenericErrResponse is a generic error result return to the caller after an
-// error is raised from an endpoint. The appropriate error reason should be
-// returned to the caller.
-type GenericErrResponse struct {
-	Err string `json:"error,omitempty"`
+// Preferences are the discovery preferences of a user, applied by Discover
+// unless the request replaces them; those left unset do not narrow
+// discovery. Genders are the genders the user is interested in, ids or
+// aliases of the taxonomy stored as ids. Dealbreakers name the preferences,
+// among DealbreakerGenders, DealbreakerAge and DealbreakerDistance, that
+// profiles must meet to be discovered at all, the profiles meeting the others
+// are only served first.
+//
+// Preferences are honoured both ways: a user is not discovered by the users
+// their preferences exclude, unless ShowOutsidePreferences is set.
+type Preferences struct {
+	Genders      []string `json:"genders"`
+	MinAge       *int     `json:"minAge,omitempty"`
+	MaxAge       *int     `json:"maxAge,omitempty"`
+	MaxDistance  *int     `json:"maxDistance,omitempty"`
+	Dealbreakers []string `json:"dealbreakers"`
+
+	ShowOutsidePreferences bool `json:"showOutsidePreferences"`
+}
+
+// Identity is how a user describes themselves: their gender, an id of the
+// taxonomy, and optionally their pronouns. Who they are interested in is
+// chosen apart, as the genders of their Preferences.
+type Identity struct {
+	Gender   string `json:"gender"`
+	Pronouns string `json:"pronouns"`
+}
+
+// Taxonomy lists the genders and pronouns users may pick from.
+type Taxonomy struct {
+	Genders  []Gender `json:"genders"`
+	Pronouns []string `json:"pronouns"`
+}
+
+// Gender is a gender of the taxonomy, stored and filtered on by Id and shown
+// to users as Label.
+type Gender struct {
+	Id    string `json:"id"`
+	Label string `json:"label"`
+}
+
+// Photo is a photo of a user, served from Url and as a square thumbnail from
+// ThumbnailUrl. Width and Height are those of the image at Url.
+type Photo struct {
+	Id           int    `json:"id"`
+	Url          string `json:"url"`
+	ThumbnailUrl string `json:"thumbnailUrl"`
+	Width        int    `json:"width"`
+	Height       int    `json:"height"`
+}
+
+// PhotoOrder lists the ids of the photos of a user in the order they are to
+// be shown.
+type PhotoOrder struct {
+	Ids []int `json:"ids"`
+}
+
+// About is what a user tells about themselves on their profile: a bio, their
+// answers to prompts of the catalog, in the order they are shown, and their
+// interests, ids or labels of the catalog stored as ids.
+type About struct {
+	Bio       string         `json:"bio"`
+	Prompts   []PromptAnswer `json:"prompts"`
+	Interests []string       `json:"interests"`
+}
+
+// PromptAnswer is the answer of a user to the prompt with the id Prompt.
+type PromptAnswer struct {
+	Prompt string `json:"prompt"`
+	Answer string `json:"answer"`
+}
+
+// Catalog lists the prompts users may answer and the interests they may pick
+// for their profile.
+type Catalog struct {
+	Prompts   []Prompt   `json:"prompts"`
+	Interests []Interest `json:"interests"`
+}
+
+// Prompt is a prompt of the catalog, answered by Id and shown as Text.
+type Prompt struct {
+	Id   string `json:"id"`
+	Text string `json:"text"`
+}
+
+// Interest is an interest of the catalog, stored and counted by Id and shown
+// as Label among the interests of its Category.
+type Interest struct {
+	Id       string `json:"id"`
+	Label    string `json:"label"`
+	Category string `json:"category,omitempty"`
+}
+
+// ProblemDetails is the RFC 7807 body returned to the caller, with the
+// application/problem+json content type, whenever an endpoint raises an
+// error. Code is the machine readable error code and is stable across
+// releases, Detail is a message that is safe to show to the user.
+type ProblemDetails struct {
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Status    int    `json:"status"`
+	Detail    string `json:"detail,omitempty"`
+	Instance  string `json:"instance,omitempty"`
+	Code      string `json:"code"`
+	RequestId string `json:"requestId,omitempty"`
 }